@@ -0,0 +1,55 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"foodcourt-backend/internal/config"
+)
+
+const usage = `Usage: config [flags] <command>
+
+Commands:
+  print    print the effective configuration as YAML, with secrets redacted
+  check    load the configuration and report every problem
+
+Flags:
+`
+
+func main() {
+	var opts config.Options
+	opts.RegisterFlags(flag.CommandLine)
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load(opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	switch args[0] {
+	case "print":
+		if err := cfg.Print(os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+	case "check":
+		if err := cfg.Validate(); err != nil {
+			log.Fatal(err)
+		}
+		log.Println("Configuration is valid")
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
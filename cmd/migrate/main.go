@@ -1,17 +1,67 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"fmt"
 	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
 
 	"foodcourt-backend/internal/config"
 	"foodcourt-backend/internal/database"
 )
 
+const usage = `Usage: migrate [flags] <command> [arguments] [flags]
+
+Commands:
+  up                      apply all pending migrations
+  down [N]                revert the last N applied migrations (default 1)
+  status                  list migrations and whether they are applied
+  create NAME             create up and down files for a new migration
+  force VERSION [state]   after repairing a failed migration by hand, record
+                          VERSION as "applied" (default) or "reverted"
+
+Flags:
+`
+
+var migrationName = regexp.MustCompile(`^\w+$`)
+
 func main() {
-	log.Println("Starting database migration...")
+	dryRun := flag.Bool("dry-run", false, "print the SQL of up or down instead of running it")
+	dir := flag.String("dir", filepath.Join("internal", "database", "migrations"), "directory create writes new migrations to")
+	var opts config.Options
+	opts.RegisterFlags(flag.CommandLine)
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	args := parseArgs(flag.CommandLine)
+	if len(args) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// create only writes files, it does not need a database
+	if args[0] == "create" {
+		if len(args) != 2 || !migrationName.MatchString(args[1]) {
+			log.Fatal("create expects a NAME of letters, digits and underscores")
+		}
+		if err := create(*dir, args[1]); err != nil {
+			log.Fatalf("Failed to create migration: %v", err)
+		}
+		return
+	}
 
 	// Load configuration
-	cfg := config.Load()
+	cfg, err := config.Load(opts)
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
 
 	// Connect to database
 	db, err := database.New(cfg)
@@ -20,10 +70,169 @@ func main() {
 	}
 	defer db.Close()
 
-	// Run migrations
-	if err := db.Migrate(); err != nil {
-		log.Fatalf("Failed to run migrations: %v", err)
+	migrator, err := db.Migrator()
+	if err != nil {
+		log.Fatalf("Failed to load migrations: %v", err)
+	}
+
+	ctx := context.Background()
+
+	switch args[0] {
+	case "up":
+		if *dryRun {
+			plan, err := migrator.PlanUp(ctx)
+			if err != nil {
+				log.Fatalf("Failed to plan migrations: %v", err)
+			}
+			printPlan(plan, true)
+			return
+		}
+
+		applied, err := migrator.Up(ctx)
+		for _, migration := range applied {
+			log.Printf("Applied %06d_%s", migration.Version, migration.Name)
+		}
+		if err != nil {
+			log.Fatalf("Failed to run migrations: %v", err)
+		}
+		if len(applied) == 0 {
+			log.Println("No pending migrations")
+		}
+
+	case "down":
+		n := 1
+		if len(args) > 1 {
+			n, err = strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				log.Fatal("down expects a positive number of migrations")
+			}
+		}
+
+		if *dryRun {
+			plan, err := migrator.PlanDown(ctx, n)
+			if err != nil {
+				log.Fatalf("Failed to plan migrations: %v", err)
+			}
+			printPlan(plan, false)
+			return
+		}
+
+		reverted, err := migrator.Down(ctx, n)
+		for _, migration := range reverted {
+			log.Printf("Reverted %06d_%s", migration.Version, migration.Name)
+		}
+		if err != nil {
+			log.Fatalf("Failed to revert migrations: %v", err)
+		}
+		if len(reverted) == 0 {
+			log.Println("No applied migrations")
+		}
+
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			log.Fatalf("Failed to read migration status: %v", err)
+		}
+		for _, status := range statuses {
+			state := "pending"
+			switch {
+			case status.Dirty:
+				state = "DIRTY"
+			case status.Applied && status.Up == "":
+				state = "applied, file missing"
+			case status.Applied:
+				state = "applied " + status.AppliedAt.Local().Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%06d_%-40s %s\n", status.Version, status.Name, state)
+		}
+
+	case "force":
+		if len(args) < 2 || len(args) > 3 {
+			log.Fatal("force expects a VERSION and optionally applied or reverted")
+		}
+		version, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			log.Fatalf("Invalid version %s", args[1])
+		}
+		state := "applied"
+		if len(args) == 3 {
+			state = args[2]
+		}
+		if state != "applied" && state != "reverted" {
+			log.Fatalf("Invalid state %s, expected applied or reverted", state)
+		}
+
+		if err := migrator.Force(ctx, version, state == "applied"); err != nil {
+			log.Fatalf("Failed to force migration: %v", err)
+		}
+		log.Printf("Recorded migration %d as %s", version, state)
+
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
+
+// parseArgs returns the command and its arguments, parsing the flags among
+// them into fs. The flag package stops at the first argument, which would
+// silently ignore "migrate up --dry-run" and apply the migrations instead.
+func parseArgs(fs *flag.FlagSet) []string {
+	var args []string
+	for rest := fs.Args(); len(rest) > 0; rest = fs.Args() {
+		args = append(args, rest[0])
+		// fs exits on errors
+		_ = fs.Parse(rest[1:])
+	}
+	return args
+}
+
+// printPlan writes the SQL a run would execute
+func printPlan(plan []database.Migration, up bool) {
+	if len(plan) == 0 {
+		fmt.Println("-- nothing to run")
+		return
 	}
 
-	log.Println("Database migration completed successfully!")
+	for _, migration := range plan {
+		direction, script := "up", migration.Up
+		if !up {
+			direction, script = "down", migration.Down
+		}
+		fmt.Printf("-- %06d_%s (%s)\n%s\n", migration.Version, migration.Name, direction, script)
+	}
+}
+
+// create writes up and down files numbered after the latest migration in dir.
+// They start with a comment only, since migrations without an up script are
+// refused when loading and would keep the server from starting.
+func create(dir, name string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.sql"))
+	if err != nil {
+		return err
+	}
+
+	version := int64(1)
+	for _, file := range files {
+		var existing int64
+		if _, err := fmt.Sscanf(filepath.Base(file), "%d_", &existing); err == nil && existing >= version {
+			version = existing + 1
+		}
+	}
+
+	for _, direction := range []string{"up", "down"} {
+		file := filepath.Join(dir, fmt.Sprintf("%06d_%s.%s.sql", version, name, direction))
+		f, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(f, "-- %06d_%s (%s): write the statements of the migration here\n", version, name, direction)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+		log.Printf("Created %s", file)
+	}
+	return nil
 }
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"log"
+	"os"
+	"reflect"
+	"testing"
+
+	"foodcourt-backend/internal/database"
+)
+
+func TestFlagsMayFollowTheCommand(t *testing.T) {
+	tests := [][]string{
+		{"--dry-run", "down", "2"},
+		{"down", "--dry-run", "2"},
+		{"down", "2", "--dry-run"},
+	}
+
+	for _, arguments := range tests {
+		fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+		dryRun := fs.Bool("dry-run", false, "")
+		if err := fs.Parse(arguments); err != nil {
+			t.Fatalf("parse %v: %v", arguments, err)
+		}
+
+		args := parseArgs(fs)
+		if !*dryRun || !reflect.DeepEqual(args, []string{"down", "2"}) {
+			t.Errorf("%v: expected a dry run of down 2, got %v and dry run %v", arguments, args, *dryRun)
+		}
+	}
+}
+
+func TestCreatedMigrationsLoad(t *testing.T) {
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	dir := t.TempDir()
+	if err := create(dir, "add_notes"); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if err := create(dir, "add_tags"); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	// Untouched migrations must not keep the server from starting
+	loaded, err := database.LoadMigrations(os.DirFS(dir))
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if len(loaded) != 2 || loaded[0].Name != "add_notes" || loaded[1].Version != 2 {
+		t.Fatalf("unexpected migrations %+v", loaded)
+	}
+}
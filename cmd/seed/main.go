@@ -1,17 +1,54 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"log"
+	"strings"
 
 	"foodcourt-backend/internal/config"
 	"foodcourt-backend/internal/database"
+	"foodcourt-backend/internal/seed"
+
+	"github.com/gin-gonic/gin"
 )
 
 func main() {
+	env := flag.String("env", "demo", "built-in fixture to load: "+strings.Join(seed.Environments, ", "))
+	file := flag.String("file", "", "YAML or JSON fixture file to load instead of -env")
+	reset := flag.Bool("reset", false, "delete all data before seeding")
+	force := flag.Bool("force", false, "allow -reset and the test fixture when GIN_MODE is release")
+	days := flag.Int("history-days", 0, "generate order history for this many days before today")
+	ordersPerDay := flag.Int("orders-per-day", 200, "average orders per day of the generated history")
+	randomSeed := flag.Int64("random-seed", 1, "seed of the history generator, the same seed gives the same history")
+	var opts config.Options
+	opts.RegisterFlags(flag.CommandLine)
+	flag.Parse()
+
 	log.Println("Starting database seeding...")
 
 	// Load configuration
-	cfg := config.Load()
+	cfg, err := config.Load(opts)
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	// The test fixture has known passwords that never have to be changed
+	release := cfg.Server.GinMode == gin.ReleaseMode
+	if release && *file == "" && *env == "test" && !*force {
+		log.Fatal("Refusing to load the test fixture into a release database without -force")
+	}
+
+	// Load the fixture before touching the database
+	var fixture *seed.Fixture
+	if *file != "" {
+		fixture, err = seed.LoadFile(*file)
+	} else {
+		fixture, err = seed.Builtin(*env)
+	}
+	if err != nil {
+		log.Fatalf("Failed to load fixture: %v", err)
+	}
 
 	// Connect to database
 	db, err := database.New(cfg)
@@ -20,9 +57,37 @@ func main() {
 	}
 	defer db.Close()
 
-	// Run seeder
-	if err := db.Seed(); err != nil {
-		log.Fatalf("Failed to run seeder: %v", err)
+	ctx := context.Background()
+
+	if *reset {
+		if release && !*force {
+			log.Fatal("Refusing to reset a release database without -force")
+		}
+		if err := seed.Reset(ctx, db.DB); err != nil {
+			log.Fatalf("Failed to reset database: %v", err)
+		}
+		log.Println("Deleted all data")
+	}
+
+	result, err := seed.Apply(ctx, db.DB, fixture)
+	if err != nil {
+		log.Fatalf("Failed to load fixture: %v", err)
+	}
+	log.Printf("Loaded fixture, %d records created, %d already existed", result.Created, result.Skipped)
+
+	if *days > 0 {
+		calendar := cfg.Business.Calendar()
+		orders, err := seed.GenerateHistory(ctx, db.DB, seed.HistoryOptions{
+			Days:         *days,
+			OrdersPerDay: *ordersPerDay,
+			Seed:         *randomSeed,
+			Tax:          cfg.Business.Tax(),
+			Calendar:     &calendar,
+		})
+		if err != nil {
+			log.Fatalf("Failed to generate order history after %d orders: %v", orders, err)
+		}
+		log.Printf("Generated %d orders over %d days", orders, *days)
 	}
 
 	log.Println("Database seeding completed successfully!")
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"foodcourt-backend/internal/config"
+	"foodcourt-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestLogin(t *testing.T) {
+	s := newTestServer(t)
+
+	w := s.request(http.MethodPost, "/api/v1/auth/login", "", gin.H{
+		"username": "cashier",
+		"password": seedPassword,
+	})
+	expectStatus(t, w, http.StatusOK)
+
+	var resp struct {
+		Token string `json:"token"`
+		User  struct {
+			Username string `json:"username"`
+			Role     string `json:"role"`
+		} `json:"user"`
+	}
+	decode(t, w, &resp)
+	if resp.Token == "" {
+		t.Fatal("expected a token")
+	}
+	if resp.User.Username != "cashier" || resp.User.Role != "cashier" {
+		t.Fatalf("unexpected user %+v", resp.User)
+	}
+
+	w = s.request(http.MethodGet, "/api/v1/me", resp.Token, nil)
+	expectStatus(t, w, http.StatusOK)
+}
+
+// enableRegistration opens public sign-up
+func enableRegistration(cfg *config.Config) {
+	cfg.Features.Enabled = []string{config.FeatureRegistration}
+}
+
+func TestRegistrationIsAFeatureFlag(t *testing.T) {
+	account := gin.H{
+		"username": "new_cashier", "email": "new@foodcourt.local", "password": "Sup3rSecret-42",
+		"full_name": "New Cashier", "role": "cashier",
+	}
+
+	s := newTestServer(t)
+	w := s.request(http.MethodPost, "/api/v1/auth/register", "", account)
+	expectStatus(t, w, http.StatusNotFound)
+
+	s = newTestServer(t, enableRegistration)
+	w = s.request(http.MethodPost, "/api/v1/auth/register", "", account)
+	expectStatus(t, w, http.StatusCreated)
+}
+
+func TestLoginRejectsInvalidCredentials(t *testing.T) {
+	s := newTestServer(t)
+
+	tests := []struct {
+		name     string
+		username string
+		password string
+	}{
+		{"wrong password", "cashier", "wrong-password"},
+		{"unknown user", "nobody", seedPassword},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := s.request(http.MethodPost, "/api/v1/auth/login", "", gin.H{
+				"username": tt.username,
+				"password": tt.password,
+			})
+			expectStatus(t, w, http.StatusUnauthorized)
+		})
+	}
+}
+
+func TestLoginBackoffAfterRepeatedFailures(t *testing.T) {
+	s := newTestServer(t)
+
+	credentials := gin.H{"username": "padang_user", "password": "wrong-password"}
+	for i := 0; i < 3; i++ {
+		w := s.request(http.MethodPost, "/api/v1/auth/login", "", credentials)
+		expectStatus(t, w, http.StatusUnauthorized)
+	}
+
+	// Even the correct password is rejected while the backoff lasts
+	w := s.request(http.MethodPost, "/api/v1/auth/login", "", gin.H{
+		"username": "padang_user",
+		"password": seedPassword,
+	})
+	expectStatus(t, w, http.StatusTooManyRequests)
+	if w.Header().Get("Retry-After") == "" {
+		t.Fatal("expected a Retry-After header")
+	}
+}
+
+func TestProtectedRoutesRequireToken(t *testing.T) {
+	s := newTestServer(t)
+
+	w := s.request(http.MethodGet, "/api/v1/me", "", nil)
+	expectStatus(t, w, http.StatusUnauthorized)
+
+	w = s.request(http.MethodGet, "/api/v1/me", "not-a-token", nil)
+	expectStatus(t, w, http.StatusUnauthorized)
+}
+
+func TestCashierOnlyRoutes(t *testing.T) {
+	s := newTestServer(t)
+	token := s.login("padang_user")
+
+	w := s.request(http.MethodGet, "/api/v1/orders/", token, nil)
+	expectStatus(t, w, http.StatusForbidden)
+
+	w = s.request(http.MethodPost, "/api/v1/kios/", token, gin.H{"name": "Kios Baru"})
+	expectStatus(t, w, http.StatusForbidden)
+}
+
+func TestChangePasswordRevokesOtherSessions(t *testing.T) {
+	s := newTestServer(t)
+	oldToken := s.login("cashier")
+
+	w := s.request(http.MethodPost, "/api/v1/me/password", oldToken, gin.H{
+		"current_password": seedPassword,
+		"new_password":     "Another-Secret-42",
+	})
+	expectStatus(t, w, http.StatusOK)
+
+	var resp struct {
+		Token string `json:"token"`
+	}
+	decode(t, w, &resp)
+
+	w = s.request(http.MethodGet, "/api/v1/me", oldToken, nil)
+	expectStatus(t, w, http.StatusUnauthorized)
+
+	w = s.request(http.MethodGet, "/api/v1/me", resp.Token, nil)
+	expectStatus(t, w, http.StatusOK)
+
+	w = s.request(http.MethodPost, "/api/v1/auth/login", "", gin.H{
+		"username": "cashier",
+		"password": "Another-Secret-42",
+	})
+	expectStatus(t, w, http.StatusOK)
+}
+
+func TestPasswordReset(t *testing.T) {
+	s := newTestServer(t)
+
+	// Unknown accounts get the same answer and no message
+	w := s.request(http.MethodPost, "/api/v1/auth/password/forgot", "", gin.H{"identifier": "nobody@foodcourt.com"})
+	expectStatus(t, w, http.StatusAccepted)
+	if _, ok := s.notifier.lastReset(); ok {
+		t.Fatal("expected no reset message for an unknown account")
+	}
+
+	w = s.request(http.MethodPost, "/api/v1/auth/password/forgot", "", gin.H{"identifier": "cashier@foodcourt.com"})
+	expectStatus(t, w, http.StatusAccepted)
+	reset, ok := s.notifier.lastReset()
+	if !ok {
+		t.Fatal("expected a reset message")
+	}
+
+	body := gin.H{"token": reset.Token, "new_password": "Fresh-Password-77"}
+	w = s.request(http.MethodPost, "/api/v1/auth/password/reset", "", body)
+	expectStatus(t, w, http.StatusOK)
+
+	// Reset tokens work only once
+	w = s.request(http.MethodPost, "/api/v1/auth/password/reset", "", body)
+	expectStatus(t, w, http.StatusBadRequest)
+
+	w = s.request(http.MethodPost, "/api/v1/auth/login", "", gin.H{
+		"username": "cashier",
+		"password": "Fresh-Password-77",
+	})
+	expectStatus(t, w, http.StatusOK)
+}
+
+func TestPasswordResetsAreThrottledPerAccount(t *testing.T) {
+	s := newTestServer(t, func(cfg *config.Config) {
+		cfg.Password.ResetCooldown = time.Minute
+	})
+
+	// Repeated requests get the same answer, but only the first sends a message
+	for i := 0; i < 3; i++ {
+		w := s.request(http.MethodPost, "/api/v1/auth/password/forgot", "", gin.H{"identifier": "cashier@foodcourt.com"})
+		expectStatus(t, w, http.StatusAccepted)
+	}
+	if count := s.notifier.resetCount(); count != 1 {
+		t.Fatalf("expected one reset message, got %d", count)
+	}
+	reset, _ := s.notifier.lastReset()
+
+	// Other accounts are not held back
+	w := s.request(http.MethodPost, "/api/v1/auth/password/forgot", "", gin.H{"identifier": "padang_user"})
+	expectStatus(t, w, http.StatusAccepted)
+	if count := s.notifier.resetCount(); count != 2 {
+		t.Fatalf("expected a message to the other account, got %d", count)
+	}
+
+	// The token sent first stays valid
+	w = s.request(http.MethodPost, "/api/v1/auth/password/reset", "", gin.H{"token": reset.Token, "new_password": "Fresh-Password-77"})
+	expectStatus(t, w, http.StatusOK)
+}
+
+func TestSeededAccountMustChangePassword(t *testing.T) {
+	s := newTestServer(t)
+	if err := s.db.Model(&models.User{}).Where("username = ?", "padang_user").Update("must_change_password", true).Error; err != nil {
+		t.Fatalf("flag user: %v", err)
+	}
+
+	w := s.request(http.MethodPost, "/api/v1/auth/login", "", gin.H{
+		"username": "padang_user",
+		"password": seedPassword,
+	})
+	expectStatus(t, w, http.StatusOK)
+
+	var login struct {
+		Token                  string `json:"token"`
+		PasswordChangeRequired bool   `json:"password_change_required"`
+	}
+	decode(t, w, &login)
+	if !login.PasswordChangeRequired {
+		t.Fatal("expected the login to require a password change")
+	}
+
+	// Only the profile and the password change are open until then
+	w = s.request(http.MethodGet, "/api/v1/kios/", login.Token, nil)
+	expectStatus(t, w, http.StatusForbidden)
+	w = s.request(http.MethodGet, "/api/v1/kios/1/queue", login.Token, nil)
+	expectStatus(t, w, http.StatusForbidden)
+	w = s.request(http.MethodGet, "/api/v1/me", login.Token, nil)
+	expectStatus(t, w, http.StatusOK)
+
+	w = s.request(http.MethodPost, "/api/v1/me/password", login.Token, gin.H{
+		"current_password": seedPassword,
+		"new_password":     "Rendang-Enak-24",
+	})
+	expectStatus(t, w, http.StatusOK)
+
+	var changed struct {
+		Token string `json:"token"`
+	}
+	decode(t, w, &changed)
+
+	w = s.request(http.MethodGet, "/api/v1/kios/", changed.Token, nil)
+	expectStatus(t, w, http.StatusOK)
+
+	w = s.request(http.MethodGet, "/api/v1/me", changed.Token, nil)
+	expectStatus(t, w, http.StatusOK)
+
+	var me struct {
+		User struct {
+			MustChangePassword bool `json:"must_change_password"`
+		} `json:"user"`
+	}
+	decode(t, w, &me)
+	if me.User.MustChangePassword {
+		t.Fatal("expected the password change requirement lifted")
+	}
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"foodcourt-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// conditionalGet fetches path, revalidating etag when it is not empty
+func (s *testServer) conditionalGet(path, token, etag string) *httptest.ResponseRecorder {
+	s.t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	return w
+}
+
+func TestListingsAreRevalidatedWithETags(t *testing.T) {
+	s := newTestServer(t)
+	token := s.login("cashier")
+
+	w := s.conditionalGet("/api/v1/kios/1/menus", token, "")
+	expectStatus(t, w, http.StatusOK)
+	etag := w.Header().Get("ETag")
+	if etag == "" || w.Header().Get("Cache-Control") != "private, no-cache" {
+		t.Fatalf("expected an ETag that must be revalidated, got %v", w.Header())
+	}
+
+	w = s.conditionalGet("/api/v1/kios/1/menus", token, etag)
+	expectStatus(t, w, http.StatusNotModified)
+	if w.Body.Len() != 0 || w.Header().Get("ETag") != etag {
+		t.Fatalf("expected an empty 304 with the same ETag, got %q and %v", w.Body.String(), w.Header())
+	}
+
+	// Weak validators and lists of them match as well
+	w = s.conditionalGet("/api/v1/kios/1/menus", token, `"other", W/`+etag)
+	expectStatus(t, w, http.StatusNotModified)
+
+	// Other queries are other responses
+	w = s.conditionalGet("/api/v1/kios/1/menus?limit=1", token, etag)
+	expectStatus(t, w, http.StatusOK)
+}
+
+func TestResourcesAreTaggedByTheirRepresentation(t *testing.T) {
+	s := newTestServer(t)
+	cashier := s.login("cashier")
+
+	w := s.conditionalGet("/api/v1/kios/1", cashier, "")
+	expectStatus(t, w, http.StatusOK)
+	etag := w.Header().Get("ETag")
+	if etag == "" || etag == `"1"` || w.Header().Get("X-Resource-Version") != "1" {
+		t.Fatalf("expected a content tag and the version in its own header, got %v", w.Header())
+	}
+	w = s.conditionalGet("/api/v1/kios/1", cashier, etag)
+	expectStatus(t, w, http.StatusNotModified)
+
+	// New orders change the figures of the kios but not its version
+	s.createOrder(s.login("padang_user"), 1, gin.H{"menu_id": 1, "quantity": 1})
+	w = s.conditionalGet("/api/v1/kios/1", cashier, etag)
+	expectStatus(t, w, http.StatusOK)
+	if w.Header().Get("ETag") == etag || w.Header().Get("X-Resource-Version") != "1" {
+		t.Fatalf("expected a new tag of the same version, got %v", w.Header())
+	}
+
+	// The version still guards changes
+	w = s.requestIfMatch(http.MethodPut, "/api/v1/kios/1", cashier, etag, gin.H{"location": "Lantai 2"})
+	expectStatus(t, w, http.StatusPreconditionFailed)
+	w = s.requestIfMatch(http.MethodPut, "/api/v1/kios/1", cashier, `"1"`, gin.H{"location": "Lantai 2"})
+	expectStatus(t, w, http.StatusOK)
+}
+
+func TestMenuChangesInvalidateCachedListings(t *testing.T) {
+	s := newTestServer(t)
+	token := s.login("cashier")
+
+	menus := s.conditionalGet("/api/v1/kios/1/menus", token, "")
+	expectStatus(t, menus, http.StatusOK)
+	kios := s.conditionalGet("/api/v1/kios/", token, "")
+	expectStatus(t, kios, http.StatusOK)
+
+	w := s.request(http.MethodPut, "/api/v1/menus/1", token, gin.H{"name": "Rendang Sapi Spesial"})
+	expectStatus(t, w, http.StatusOK)
+
+	w = s.conditionalGet("/api/v1/kios/1/menus", token, menus.Header().Get("ETag"))
+	expectStatus(t, w, http.StatusOK)
+	var page struct {
+		Data []struct {
+			ID   uint   `json:"id"`
+			Name string `json:"name"`
+		} `json:"data"`
+	}
+	decode(t, w, &page)
+	found := false
+	for _, menu := range page.Data {
+		if menu.ID == 1 {
+			found = menu.Name == "Rendang Sapi Spesial"
+		}
+	}
+	if !found {
+		t.Fatalf("expected the updated menu, got %+v", page.Data)
+	}
+
+	w = s.request(http.MethodPost, "/api/v1/kios/1/menus", token, gin.H{"name": "Es Cendol", "price": 8000, "category": "drink"})
+	expectStatus(t, w, http.StatusCreated)
+
+	// Kios listings count the menus
+	w = s.conditionalGet("/api/v1/kios/", token, kios.Header().Get("ETag"))
+	expectStatus(t, w, http.StatusOK)
+}
+
+func TestKiosAndOrderChangesInvalidateCachedListings(t *testing.T) {
+	s := newTestServer(t)
+	cashier := s.login("cashier")
+
+	type kiosFigures struct {
+		ID         uint   `json:"id"`
+		Name       string `json:"name"`
+		OrderCount int64  `json:"order_count"`
+	}
+	listKios := func(etag string) (*httptest.ResponseRecorder, map[uint]kiosFigures) {
+		t.Helper()
+		w := s.conditionalGet("/api/v1/kios/", cashier, etag)
+		if w.Code != http.StatusOK {
+			return w, nil
+		}
+		var page struct {
+			Data []kiosFigures `json:"data"`
+		}
+		decode(t, w, &page)
+		figures := make(map[uint]kiosFigures, len(page.Data))
+		for _, k := range page.Data {
+			figures[k.ID] = k
+		}
+		return w, figures
+	}
+
+	w, before := listKios("")
+	expectStatus(t, w, http.StatusOK)
+	etag := w.Header().Get("ETag")
+
+	s.createOrder(s.login("padang_user"), 1, gin.H{"menu_id": 1, "quantity": 1})
+	w, after := listKios(etag)
+	expectStatus(t, w, http.StatusOK)
+	if after[1].OrderCount != before[1].OrderCount+1 {
+		t.Fatalf("expected the new order to be counted, got %d then %d", before[1].OrderCount, after[1].OrderCount)
+	}
+	etag = w.Header().Get("ETag")
+
+	w = s.request(http.MethodPut, "/api/v1/kios/1", cashier, gin.H{"name": "Warung Padang Baru"})
+	expectStatus(t, w, http.StatusOK)
+	w, after = listKios(etag)
+	expectStatus(t, w, http.StatusOK)
+	if after[1].Name != "Warung Padang Baru" {
+		t.Fatalf("expected the renamed kios, got %q", after[1].Name)
+	}
+
+	// Unchanged listings are still revalidated
+	w, _ = listKios(w.Header().Get("ETag"))
+	expectStatus(t, w, http.StatusNotModified)
+}
+
+func TestOrdersOnlyInvalidateCachedFigures(t *testing.T) {
+	s := newTestServer(t)
+	cashier := s.login("cashier")
+
+	type kiosFigures struct {
+		ID         uint   `json:"id"`
+		Name       string `json:"name"`
+		OrderCount int64  `json:"order_count"`
+	}
+	listKios := func() map[uint]kiosFigures {
+		t.Helper()
+		w := s.request(http.MethodGet, "/api/v1/kios/", cashier, nil)
+		expectStatus(t, w, http.StatusOK)
+		var page struct {
+			Data []kiosFigures `json:"data"`
+		}
+		decode(t, w, &page)
+		figures := make(map[uint]kiosFigures, len(page.Data))
+		for _, k := range page.Data {
+			figures[k.ID] = k
+		}
+		return figures
+	}
+
+	before := listKios()
+
+	// A change behind the back of the server shows whether the page is read again
+	if err := s.db.Model(&models.Kios{}).Where("id = ?", 1).Update("name", "Warung Lain").Error; err != nil {
+		t.Fatalf("rename kios: %v", err)
+	}
+	s.createOrder(s.login("padang_user"), 1, gin.H{"menu_id": 1, "quantity": 1})
+
+	after := listKios()
+	if after[1].OrderCount != before[1].OrderCount+1 {
+		t.Fatalf("expected the new order to be counted, got %d then %d", before[1].OrderCount, after[1].OrderCount)
+	}
+	if after[1].Name != before[1].Name {
+		t.Fatalf("expected the cached page to be kept, got %q", after[1].Name)
+	}
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"foodcourt-backend/internal/apierror"
+	"foodcourt-backend/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// requestIfMatch sends a request like request, changing the resource only if
+// it is at a version named by ifMatch
+func (s *testServer) requestIfMatch(method, path, token, ifMatch string, body interface{}) *httptest.ResponseRecorder {
+	s.t.Helper()
+
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			s.t.Fatalf("encode request body: %v", err)
+		}
+	}
+
+	req := httptest.NewRequest(method, path, bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("If-Match", ifMatch)
+
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	return w
+}
+
+func TestMenuUpdatesRequireTheCurrentVersion(t *testing.T) {
+	s := newTestServer(t)
+	token := s.login("cashier")
+
+	w := s.request(http.MethodGet, "/api/v1/menus/1", token, nil)
+	expectStatus(t, w, http.StatusOK)
+	var resp struct {
+		Data struct {
+			Version uint `json:"version"`
+		} `json:"data"`
+	}
+	decode(t, w, &resp)
+	if resp.Data.Version != 1 || w.Header().Get("X-Resource-Version") != "1" {
+		t.Fatalf("expected version 1, got %d and header %q", resp.Data.Version, w.Header().Get("X-Resource-Version"))
+	}
+
+	w = s.requestIfMatch(http.MethodPut, "/api/v1/menus/1", token, `"1"`, gin.H{"price": 30000})
+	expectStatus(t, w, http.StatusOK)
+	if version := w.Header().Get("X-Resource-Version"); version != "2" {
+		t.Fatalf("expected the next version, got %q", version)
+	}
+
+	// A cashier still editing version 1 does not overwrite the change
+	w = s.requestIfMatch(http.MethodPut, "/api/v1/menus/1", token, `"1"`, gin.H{"price": 28000})
+	expectError(t, w, http.StatusPreconditionFailed, apierror.CodePreconditionFailed)
+	w = s.requestIfMatch(http.MethodDelete, "/api/v1/menus/1", token, `"1"`, nil)
+	expectError(t, w, http.StatusPreconditionFailed, apierror.CodePreconditionFailed)
+
+	// Weak tags never match, lists match any of their versions
+	w = s.requestIfMatch(http.MethodPut, "/api/v1/menus/1", token, `W/"2"`, gin.H{"price": 28000})
+	expectError(t, w, http.StatusPreconditionFailed, apierror.CodePreconditionFailed)
+	w = s.requestIfMatch(http.MethodPut, "/api/v1/menus/1", token, `"1", "2"`, gin.H{"price": 28000})
+	expectStatus(t, w, http.StatusOK)
+
+	w = s.requestIfMatch(http.MethodDelete, "/api/v1/menus/1", token, "*", nil)
+	expectStatus(t, w, http.StatusOK)
+	w = s.requestIfMatch(http.MethodDelete, "/api/v1/menus/1", token, "*", nil)
+	expectError(t, w, http.StatusNotFound, apierror.CodeNotFound)
+}
+
+func TestKiosUpdatesRequireTheCurrentVersion(t *testing.T) {
+	s := newTestServer(t)
+	token := s.login("cashier")
+
+	w := s.requestIfMatch(http.MethodPut, "/api/v1/kios/1", token, `"1"`, gin.H{"location": "Lantai 2"})
+	expectStatus(t, w, http.StatusOK)
+
+	w = s.requestIfMatch(http.MethodPut, "/api/v1/kios/1", token, `"1"`, gin.H{"location": "Lantai 3"})
+	expectError(t, w, http.StatusPreconditionFailed, apierror.CodePreconditionFailed)
+	w = s.requestIfMatch(http.MethodDelete, "/api/v1/kios/1", token, `"1"`, nil)
+	expectError(t, w, http.StatusPreconditionFailed, apierror.CodePreconditionFailed)
+
+	w = s.request(http.MethodGet, "/api/v1/kios/1", token, nil)
+	expectStatus(t, w, http.StatusOK)
+	if version := w.Header().Get("X-Resource-Version"); version != "2" {
+		t.Fatalf("expected version 2, got %q", version)
+	}
+}
+
+func TestOrderStatusIsMovedOnceByConcurrentStaff(t *testing.T) {
+	s := newTestServer(t)
+	token := s.login("padang_user")
+	order := s.createOrder(token, 1, gin.H{"menu_id": 1, "quantity": 1})
+	if order.Version != 1 {
+		t.Fatalf("expected new orders at version 1, got %d", order.Version)
+	}
+	statusPath := fmt.Sprintf("/api/v1/orders/%d/status", order.ID)
+
+	// Staff who saw the pending order both try to mark it as paid
+	const attempts = 5
+	responses := make([]*httptest.ResponseRecorder, attempts)
+	var wg sync.WaitGroup
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			responses[i] = s.requestIfMatch(http.MethodPut, statusPath, token, `"1"`, gin.H{"status": "paid", "payment_method": "cash"})
+		}(i)
+	}
+	wg.Wait()
+
+	succeeded := 0
+	for _, w := range responses {
+		if w.Code == http.StatusOK {
+			succeeded++
+			continue
+		}
+		expectError(t, w, http.StatusPreconditionFailed, apierror.CodePreconditionFailed)
+	}
+	if succeeded != 1 {
+		t.Fatalf("expected exactly one status update, got %d", succeeded)
+	}
+}
+
+func TestStaleWritesAreRejected(t *testing.T) {
+	s := newTestServer(t)
+	ctx := context.Background()
+	store := repository.NewStore(s.db)
+	order := s.createOrder(s.login("padang_user"), 1, gin.H{"menu_id": 1, "quantity": 1})
+
+	// Two staff read the order before either of them writes
+	first, err := store.Orders().FindByID(ctx, order.ID)
+	if err != nil {
+		t.Fatalf("find order: %v", err)
+	}
+	second, err := store.Orders().FindByID(ctx, order.ID)
+	if err != nil {
+		t.Fatalf("find order: %v", err)
+	}
+
+	first.Status = "paid"
+	if err := store.Orders().Update(ctx, first); err != nil {
+		t.Fatalf("update order: %v", err)
+	}
+	if first.Version != 2 {
+		t.Fatalf("expected version 2, got %d", first.Version)
+	}
+
+	second.Status = "cancelled"
+	if err := store.Orders().Update(ctx, second); !errors.Is(err, repository.ErrStale) {
+		t.Fatalf("expected a stale write, got %v", err)
+	}
+	if second.Version != 1 {
+		t.Fatalf("expected the version of the stale copy to be kept, got %d", second.Version)
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"foodcourt-backend/internal/apierror"
+	"foodcourt-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+type errorBody struct {
+	Error apierror.Error `json:"error"`
+}
+
+func expectError(t *testing.T, w *httptest.ResponseRecorder, status int, code apierror.Code) apierror.Error {
+	t.Helper()
+
+	expectStatus(t, w, status)
+	var body errorBody
+	decode(t, w, &body)
+	if body.Error.Code != code {
+		t.Fatalf("expected error code %s, got %s in %s", code, body.Error.Code, w.Body.String())
+	}
+	if body.Error.Message == "" {
+		t.Errorf("expected an error message, got %s", w.Body.String())
+	}
+	if body.Error.RequestID == "" || body.Error.RequestID != w.Header().Get("X-Request-ID") {
+		t.Errorf("expected the request ID %q in the error, got %q", w.Header().Get("X-Request-ID"), body.Error.RequestID)
+	}
+	return body.Error
+}
+
+func TestErrorEnvelopeListsInvalidFields(t *testing.T) {
+	s := newTestServer(t)
+	token := s.login("padang_user")
+
+	w := s.request(http.MethodPost, "/api/v1/kios/1/orders", token, gin.H{
+		"customer_name": strings.Repeat("x", 101),
+		"items":         []gin.H{{"menu_id": 1, "quantity": 0}},
+	})
+	apiErr := expectError(t, w, http.StatusBadRequest, apierror.CodeValidationFailed)
+
+	fields := map[string]string{}
+	for _, field := range apiErr.Fields {
+		fields[field.Field] = field.Code
+	}
+	if fields["customer_name"] != "max" || fields["items[0].quantity"] != "required" || len(fields) != 2 {
+		t.Fatalf("expected customer_name and items[0].quantity by their JSON names, got %+v", apiErr.Fields)
+	}
+
+	w = s.request(http.MethodPost, "/api/v1/kios/1/orders", token, gin.H{"items": "none"})
+	apiErr = expectError(t, w, http.StatusBadRequest, apierror.CodeValidationFailed)
+	if len(apiErr.Fields) != 1 || apiErr.Fields[0].Field != "items" {
+		t.Fatalf("expected the mistyped field, got %+v", apiErr.Fields)
+	}
+}
+
+func TestErrorEnvelopeCodes(t *testing.T) {
+	s := newTestServer(t, enableRegistration)
+	token := s.login("padang_user")
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		token  string
+		body   interface{}
+		status int
+		code   apierror.Code
+	}{
+		{"missing token", http.MethodGet, "/api/v1/kios/", "", nil, http.StatusUnauthorized, apierror.CodeUnauthenticated},
+		{"invalid parameter", http.MethodGet, "/api/v1/kios/abc", token, nil, http.StatusBadRequest, apierror.CodeInvalidParameter},
+		{"invalid query", http.MethodGet, "/api/v1/kios/?limit=1000", token, nil, http.StatusBadRequest, apierror.CodeInvalidParameter},
+		{"missing record", http.MethodGet, "/api/v1/kios/999", token, nil, http.StatusNotFound, apierror.CodeNotFound},
+		{"unknown route", http.MethodGet, "/api/v1/nothing", token, nil, http.StatusNotFound, apierror.CodeNotFound},
+		{"wrong credentials", http.MethodPost, "/api/v1/auth/login", "", gin.H{"username": "padang_user", "password": "wrong"}, http.StatusUnauthorized, "invalid_credentials"},
+		{"taken username", http.MethodPost, "/api/v1/auth/register", "", gin.H{
+			"username": "padang_user", "email": "new@foodcourt.local", "password": "Sup3rSecret",
+			"full_name": "New User", "role": "cashier",
+		}, http.StatusConflict, apierror.CodeAlreadyExists},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expectError(t, s.request(tt.method, tt.path, tt.token, tt.body), tt.status, tt.code)
+		})
+	}
+}
+
+func TestDatabaseErrorsAreMapped(t *testing.T) {
+	s := newTestServer(t)
+
+	var existing models.User
+	if err := s.db.Where("username = ?", "padang_user").First(&existing).Error; err != nil {
+		t.Fatalf("load user: %v", err)
+	}
+	duplicate := models.User{Username: existing.Username, Email: "other@foodcourt.local", Password: "x", FullName: "Copy", Role: models.RoleCashier}
+	apiErr := apierror.From(s.db.Create(&duplicate).Error)
+	if apiErr == nil || apiErr.Status != http.StatusConflict || apiErr.Code != apierror.CodeAlreadyExists {
+		t.Fatalf("expected a unique violation to map to already_exists, got %+v", apiErr)
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"foodcourt-backend/internal/apierror"
+
+	"github.com/gin-gonic/gin"
+)
+
+// requestIn sends a request like request, asking for responses in acceptLanguage
+func (s *testServer) requestIn(acceptLanguage, method, path, token string, body interface{}) *httptest.ResponseRecorder {
+	s.t.Helper()
+
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			s.t.Fatalf("encode request body: %v", err)
+		}
+	}
+
+	req := httptest.NewRequest(method, path, bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept-Language", acceptLanguage)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	return w
+}
+
+func TestErrorMessagesFollowAcceptLanguage(t *testing.T) {
+	s := newTestServer(t)
+	token := s.login("cashier")
+
+	w := s.requestIn("id-ID,id;q=0.9,en;q=0.8", http.MethodGet, "/api/v1/menus/999", token, nil)
+	apiErr := expectError(t, w, http.StatusNotFound, apierror.CodeNotFound)
+	if apiErr.Message != "Menu tidak ditemukan" {
+		t.Fatalf("expected an Indonesian message, got %q", apiErr.Message)
+	}
+	if lang := w.Header().Get("Content-Language"); lang != "id" {
+		t.Fatalf("expected Content-Language id, got %q", lang)
+	}
+
+	w = s.requestIn("id", http.MethodPost, "/api/v1/kios/1/menus", token, gin.H{"name": "E", "price": 8000, "category": "drink"})
+	apiErr = expectError(t, w, http.StatusBadRequest, apierror.CodeValidationFailed)
+	if len(apiErr.Fields) != 1 || apiErr.Fields[0].Message != "minimal 2 karakter" {
+		t.Fatalf("expected an Indonesian field message, got %+v", apiErr.Fields)
+	}
+
+	// Unsupported languages get the default locale
+	w = s.requestIn("fr-FR", http.MethodGet, "/api/v1/menus/999", token, nil)
+	apiErr = expectError(t, w, http.StatusNotFound, apierror.CodeNotFound)
+	if apiErr.Message != "Menu not found" || w.Header().Get("Content-Language") != "en" {
+		t.Fatalf("expected an English message, got %q in %q", apiErr.Message, w.Header().Get("Content-Language"))
+	}
+}
+
+func TestMenusAreTranslated(t *testing.T) {
+	s := newTestServer(t)
+	token := s.login("cashier")
+
+	w := s.request(http.MethodPut, "/api/v1/menus/1", token, gin.H{
+		"translations": gin.H{"en": gin.H{"name": "Beef Rendang with Rice"}},
+	})
+	expectStatus(t, w, http.StatusOK)
+
+	w = s.request(http.MethodPut, "/api/v1/kios/1", token, gin.H{
+		"translations": gin.H{"en": gin.H{"name": "Padang Rice Stall", "description": "Authentic Padang cuisine"}},
+	})
+	expectStatus(t, w, http.StatusOK)
+
+	type translatedMenu struct {
+		ID          uint   `json:"id"`
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		KiosName    string `json:"kios_name"`
+	}
+	menusIn := func(lang string) map[uint]translatedMenu {
+		w := s.requestIn(lang, http.MethodGet, "/api/v1/kios/1/menus", token, nil)
+		expectStatus(t, w, http.StatusOK)
+
+		var page struct {
+			Data []translatedMenu `json:"data"`
+		}
+		decode(t, w, &page)
+		menus := make(map[uint]translatedMenu, len(page.Data))
+		for _, menu := range page.Data {
+			menus[menu.ID] = menu
+		}
+		return menus
+	}
+
+	english := menusIn("en-US")
+	if menu := english[1]; menu.Name != "Beef Rendang with Rice" || menu.KiosName != "Padang Rice Stall" {
+		t.Fatalf("expected the English name, got %+v", menu)
+	}
+	// Missing descriptions and translations fall back to the original content
+	if menu := english[1]; menu.Description != "Nasi putih dengan rendang daging sapi" {
+		t.Fatalf("expected the original description, got %q", menu.Description)
+	}
+	if menu := english[2]; menu.Name != "Nasi Ayam Pop" {
+		t.Fatalf("expected the untranslated name, got %+v", menu)
+	}
+
+	indonesian := menusIn("id")
+	if menu := indonesian[1]; menu.Name != "Nasi Rendang" || menu.KiosName != "Warung Nasi Padang" {
+		t.Fatalf("expected the Indonesian name, got %+v", menu)
+	}
+
+	// A null translation removes the locale
+	w = s.request(http.MethodPut, "/api/v1/menus/1", token, gin.H{"translations": gin.H{"en": nil}})
+	expectStatus(t, w, http.StatusOK)
+	if menu := menusIn("en")[1]; menu.Name != "Nasi Rendang" {
+		t.Fatalf("expected the translation removed, got %+v", menu)
+	}
+
+	w = s.request(http.MethodPut, "/api/v1/menus/1", token, gin.H{
+		"translations": gin.H{"fr": gin.H{"name": "Riz au rendang"}},
+	})
+	expectError(t, w, http.StatusBadRequest, apierror.CodeValidationFailed)
+}
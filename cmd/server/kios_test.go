@@ -0,0 +1,223 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"foodcourt-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+type kiosBody struct {
+	ID              uint    `json:"id"`
+	Name            string  `json:"name"`
+	Location        string  `json:"location"`
+	IsActive        bool    `json:"is_active"`
+	MenuCount       int     `json:"menu_count"`
+	OrderCount      int     `json:"order_count"`
+	TodayOrderCount int     `json:"today_order_count"`
+	QueueLength     int     `json:"queue_length"`
+	TodayRevenue    float64 `json:"today_revenue"`
+}
+
+func TestListKios(t *testing.T) {
+	s := newTestServer(t)
+	token := s.login("padang_user")
+
+	w := s.request(http.MethodGet, "/api/v1/kios/", token, nil)
+	expectStatus(t, w, http.StatusOK)
+
+	var resp struct {
+		Data       []kiosBody     `json:"data"`
+		Pagination paginationBody `json:"pagination"`
+	}
+	decode(t, w, &resp)
+	if len(resp.Data) != 2 || resp.Pagination.Total != 2 {
+		t.Fatalf("expected 2 seeded kios, got %d of %d", len(resp.Data), resp.Pagination.Total)
+	}
+	for _, kios := range resp.Data {
+		if kios.MenuCount != 5 {
+			t.Errorf("expected 5 menus for %s, got %d", kios.Name, kios.MenuCount)
+		}
+	}
+}
+
+func TestListKiosPaginationAndFilters(t *testing.T) {
+	s := newTestServer(t)
+	token := s.login("cashier")
+
+	for _, name := range []string{"Sate Madura", "Soto Betawi", "Bakso Solo"} {
+		w := s.request(http.MethodPost, "/api/v1/kios/", token, gin.H{"name": name})
+		expectStatus(t, w, http.StatusCreated)
+	}
+	w := s.request(http.MethodPut, "/api/v1/kios/2", token, gin.H{"is_active": false})
+	expectStatus(t, w, http.StatusOK)
+
+	list := func(path string) ([]kiosBody, paginationBody) {
+		t.Helper()
+		w := s.request(http.MethodGet, path, token, nil)
+		expectStatus(t, w, http.StatusOK)
+
+		var resp struct {
+			Data       []kiosBody     `json:"data"`
+			Pagination paginationBody `json:"pagination"`
+		}
+		decode(t, w, &resp)
+		return resp.Data, resp.Pagination
+	}
+
+	kios, page := list("/api/v1/kios/?sort=name&limit=2&page=2")
+	if page.Total != 5 || page.TotalPages != 3 || page.Page != 2 || len(kios) != 2 {
+		t.Fatalf("unexpected page %+v with %d kios", page, len(kios))
+	}
+	if kios[0].Name != "Sate Madura" || kios[1].Name != "Soto Betawi" {
+		t.Fatalf("expected kios sorted by name, got %s and %s", kios[0].Name, kios[1].Name)
+	}
+
+	kios, _ = list("/api/v1/kios/?sort=-name&limit=1")
+	if len(kios) != 1 || kios[0].Name != "Warung Nasi Padang" {
+		t.Fatalf("expected the last name first, got %+v", kios)
+	}
+
+	kios, page = list("/api/v1/kios/?active=false")
+	if page.Total != 1 || kios[0].ID != 2 {
+		t.Fatalf("expected only the inactive kios, got %+v", kios)
+	}
+
+	kios, page = list("/api/v1/kios/?q=SOTO")
+	if page.Total != 1 || kios[0].Name != "Soto Betawi" {
+		t.Fatalf("expected a case-insensitive name search, got %+v", kios)
+	}
+}
+
+func TestGetKios(t *testing.T) {
+	s := newTestServer(t)
+	token := s.login("cashier")
+
+	s.createOrder(token, 1, gin.H{"menu_id": 1, "quantity": 1})
+
+	w := s.request(http.MethodGet, "/api/v1/kios/1", token, nil)
+	expectStatus(t, w, http.StatusOK)
+
+	var resp struct {
+		Data kiosBody `json:"data"`
+	}
+	decode(t, w, &resp)
+	if resp.Data.MenuCount != 5 || resp.Data.OrderCount != 1 {
+		t.Fatalf("expected 5 menus and 1 order, got %+v", resp.Data)
+	}
+
+	w = s.request(http.MethodGet, "/api/v1/kios/999", token, nil)
+	expectStatus(t, w, http.StatusNotFound)
+
+	w = s.request(http.MethodGet, "/api/v1/kios/abc", token, nil)
+	expectStatus(t, w, http.StatusBadRequest)
+}
+
+func TestKiosStats(t *testing.T) {
+	s := newTestServer(t)
+	token := s.login("cashier")
+
+	paid := s.createOrder(token, 1, gin.H{"menu_id": 1, "quantity": 2})
+	s.createOrder(token, 1, gin.H{"menu_id": 4, "quantity": 1})
+	cancelled := s.createOrder(token, 1, gin.H{"menu_id": 3, "quantity": 1})
+	s.createOrder(token, 2, gin.H{"menu_id": 6, "quantity": 1})
+
+	w := s.request(http.MethodPut, fmt.Sprintf("/api/v1/orders/%d/status", paid.ID), token, gin.H{"status": "paid", "payment_method": "cash"})
+	expectStatus(t, w, http.StatusOK)
+	w = s.request(http.MethodPut, fmt.Sprintf("/api/v1/orders/%d/status", cancelled.ID), token, gin.H{"status": "cancelled"})
+	expectStatus(t, w, http.StatusOK)
+
+	// An order of an earlier day counts in the totals only
+	yesterday := time.Now().AddDate(0, 0, -1)
+	if err := s.db.Model(&models.Order{}).Where("id = ?", cancelled.ID).Update("created_at", yesterday).Error; err != nil {
+		t.Fatalf("backdate order: %v", err)
+	}
+
+	w = s.request(http.MethodGet, "/api/v1/kios/", token, nil)
+	expectStatus(t, w, http.StatusOK)
+
+	var resp struct {
+		Data []kiosBody `json:"data"`
+	}
+	decode(t, w, &resp)
+
+	want := []kiosBody{
+		{MenuCount: 5, OrderCount: 3, TodayOrderCount: 2, QueueLength: 1, TodayRevenue: 50000},
+		{MenuCount: 5, OrderCount: 1, TodayOrderCount: 1, QueueLength: 0, TodayRevenue: 0},
+	}
+	for i, kios := range resp.Data {
+		got := kiosBody{
+			MenuCount:       kios.MenuCount,
+			OrderCount:      kios.OrderCount,
+			TodayOrderCount: kios.TodayOrderCount,
+			QueueLength:     kios.QueueLength,
+			TodayRevenue:    kios.TodayRevenue,
+		}
+		if got != want[i] {
+			t.Errorf("kios %d: expected %+v, got %+v", kios.ID, want[i], got)
+		}
+	}
+
+	w = s.request(http.MethodGet, "/api/v1/kios/1", token, nil)
+	expectStatus(t, w, http.StatusOK)
+
+	var single struct {
+		Data kiosBody `json:"data"`
+	}
+	decode(t, w, &single)
+	if single.Data.OrderCount != 3 || single.Data.QueueLength != 1 || single.Data.TodayRevenue != 50000 {
+		t.Fatalf("unexpected figures of a single kios %+v", single.Data)
+	}
+}
+
+func TestKiosLifecycle(t *testing.T) {
+	s := newTestServer(t)
+	token := s.login("cashier")
+
+	w := s.request(http.MethodPost, "/api/v1/kios/", token, gin.H{
+		"name":     "Sate Madura",
+		"location": "Blok B-1",
+	})
+	expectStatus(t, w, http.StatusCreated)
+
+	var created struct {
+		Data kiosBody `json:"data"`
+	}
+	decode(t, w, &created)
+	if !created.Data.IsActive {
+		t.Fatal("expected a new kios to be active")
+	}
+	path := fmt.Sprintf("/api/v1/kios/%d", created.Data.ID)
+
+	w = s.request(http.MethodPut, path, token, gin.H{"location": "Blok B-2", "is_active": false})
+	expectStatus(t, w, http.StatusOK)
+
+	var updated struct {
+		Data kiosBody `json:"data"`
+	}
+	decode(t, w, &updated)
+	if updated.Data.Name != "Sate Madura" || updated.Data.Location != "Blok B-2" || updated.Data.IsActive {
+		t.Fatalf("unexpected kios after update %+v", updated.Data)
+	}
+
+	w = s.request(http.MethodDelete, path, token, nil)
+	expectStatus(t, w, http.StatusOK)
+
+	w = s.request(http.MethodGet, path, token, nil)
+	expectStatus(t, w, http.StatusNotFound)
+
+	w = s.request(http.MethodDelete, path, token, nil)
+	expectStatus(t, w, http.StatusNotFound)
+}
+
+func TestCreateKiosValidation(t *testing.T) {
+	s := newTestServer(t)
+	token := s.login("cashier")
+
+	w := s.request(http.MethodPost, "/api/v1/kios/", token, gin.H{"location": "Blok C-1"})
+	expectStatus(t, w, http.StatusBadRequest)
+}
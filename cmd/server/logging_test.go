@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// captureLogs collects the JSON log records written during the test
+func captureLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+
+	var buf bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+	return &buf
+}
+
+func logRecords(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+
+	var records []map[string]interface{}
+	scanner := bufio.NewScanner(buf)
+	scanner.Buffer(make([]byte, 1024*1024), 1024*1024)
+	for scanner.Scan() {
+		var record map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("decode log record %q: %v", scanner.Text(), err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestRequestIDCorrelatesLogs(t *testing.T) {
+	s := newTestServer(t)
+	token := s.login("padang_user")
+	logs := captureLogs(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/kios/1/menus", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("X-Request-ID", "checkout-42")
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	expectStatus(t, w, http.StatusOK)
+
+	if id := w.Header().Get("X-Request-ID"); id != "checkout-42" {
+		t.Fatalf("expected the request ID echoed, got %q", id)
+	}
+
+	var access, queries int
+	for _, record := range logRecords(t, logs) {
+		if record["request_id"] != "checkout-42" {
+			t.Errorf("log record without the request ID: %v", record)
+			continue
+		}
+		switch record["msg"] {
+		case "Request":
+			access++
+			if record["user_id"] == nil || record["kios_id"] != float64(1) || record["status"] != float64(200) {
+				t.Errorf("expected the caller in the access log, got %v", record)
+			}
+		case "Query":
+			queries++
+		}
+	}
+	if access != 1 || queries == 0 {
+		t.Fatalf("expected one access log and query logs, got %d and %d", access, queries)
+	}
+
+	// Requests without a usable ID get a generated one
+	req = httptest.NewRequest(http.MethodGet, "/livez", nil)
+	req.Header.Set("X-Request-ID", "line\nbreak")
+	w = httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	if id := w.Header().Get("X-Request-ID"); len(id) != 32 {
+		t.Fatalf("expected a generated request ID, got %q", id)
+	}
+}
@@ -1,20 +1,63 @@
 package main
 
 import (
-	"log"
-
+	"context"
+	"flag"
+	"log/slog"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"foodcourt-backend/internal/cache"
 	"foodcourt-backend/internal/config"
 	"foodcourt-backend/internal/database"
-	"foodcourt-backend/internal/handlers"
-	"foodcourt-backend/internal/middleware"
+	"foodcourt-backend/internal/health"
+	"foodcourt-backend/internal/logging"
+	"foodcourt-backend/internal/loginguard"
+	"foodcourt-backend/internal/metrics"
+	"foodcourt-backend/internal/notify"
+	"foodcourt-backend/internal/ratelimit"
+	"foodcourt-backend/internal/seed"
+	"foodcourt-backend/internal/tracing"
 	"foodcourt-backend/pkg/auth"
 
 	"github.com/gin-gonic/gin"
 )
 
+// healthCheckTimeout bounds the dependency checks of the readiness endpoint
+const healthCheckTimeout = 2 * time.Second
+
 func main() {
+	var opts config.Options
+	opts.RegisterFlags(flag.CommandLine)
+	flag.Parse()
+
 	// Load configuration
-	cfg := config.Load()
+	cfg, err := config.Load(opts)
+	if err != nil {
+		fatal("Refusing to start", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		fatal("Refusing to start", err)
+	}
+
+	// Log structured records, also for the standard log package
+	slog.SetDefault(logging.New(cfg.Log, cfg.Server.GinMode, os.Stdout))
+
+	// Development setups without a secret get a random one, so that no known
+	// secret ever signs tokens
+	if cfg.JWT.Secret == "" {
+		cfg.JWT.Secret = config.GenerateSecret()
+		slog.Warn("JWT_SECRET is not set, using a random secret. Tokens will not survive a restart.")
+	}
+
+	// Export spans of requests and queries when tracing is configured
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		fatal("Failed to set up tracing", err)
+	}
 
 	// Set Gin mode
 	gin.SetMode(cfg.Server.GinMode)
@@ -22,108 +65,127 @@ func main() {
 	// Connect to database
 	db, err := database.New(cfg)
 	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+		fatal("Failed to connect to database", err)
 	}
 	defer db.Close()
 
-	// Run migrations
-	if err := db.Migrate(); err != nil {
-		log.Fatalf("Failed to run migrations: %v", err)
+	// Refuse to start on a schema that does not match the migrations
+	migrator, err := db.Migrator()
+	if err != nil {
+		fatal("Failed to load migrations", err)
+	}
+	if err := migrator.Check(context.Background()); err != nil {
+		fatal("Database schema is not up to date, run `go run ./cmd/migrate up`", err)
+	}
+
+	// Create the initial admin on first boot
+	adminPassword, err := seed.Bootstrap(context.Background(), db.DB, cfg.Admin)
+	if err != nil {
+		fatal("Failed to create initial admin", err)
+	}
+	if adminPassword != "" {
+		slog.Warn("Created initial admin, the password is shown only once and must be changed on first login",
+			"username", cfg.Admin.Username, "password", adminPassword)
+	}
+
+	// Connect to redis when configured
+	redisClient, err := database.NewRedis(cfg)
+	if err != nil {
+		fatal("Failed to connect to redis", err)
+	}
+	if redisClient != nil {
+		defer redisClient.Close()
 	}
 
-	// Run seeder
-	if err := db.Seed(); err != nil {
-		log.Fatalf("Failed to run seeder: %v", err)
+	// Initialize login guard, shared through redis when available
+	var loginStore loginguard.Store = loginguard.NewMemoryStore()
+	if redisClient != nil {
+		loginStore = loginguard.NewRedisStore(redisClient)
 	}
+	loginGuard := loginguard.New(loginStore, cfg.LoginGuard)
+
+	// Initialize rate limiter, shared through redis when available
+	var limitStore ratelimit.Store = ratelimit.NewMemoryStore()
+	if redisClient != nil {
+		limitStore = ratelimit.NewRedisStore(redisClient)
+	}
+	limiter := ratelimit.New(limitStore)
+
+	// Initialize response cache, shared through redis when available
+	var cacheStore cache.Store = cache.NewMemoryStore()
+	if redisClient != nil {
+		cacheStore = cache.NewRedisStore(redisClient)
+	}
+	responses := cache.New(cacheStore, cfg.Cache.TTL)
 
 	// Initialize JWT service
 	jwtService, err := auth.NewJWTService(cfg.JWT.Secret, cfg.JWT.ExpiresIn)
 	if err != nil {
-		log.Fatalf("Failed to initialize JWT service: %v", err)
+		fatal("Failed to initialize JWT service", err)
 	}
 
-	// Initialize handlers
-	authHandler := handlers.NewAuthHandler(db.DB, jwtService)
-	kiosHandler := handlers.NewKiosHandler(db.DB)
-	menuHandler := handlers.NewMenuHandler(db.DB)
-	orderHandler := handlers.NewOrderHandler(db.DB)
+	// Initialize notifier for account emails
+	notifier, err := notify.New(cfg.Notifier)
+	if err != nil {
+		fatal("Failed to initialize notifier", err)
+	}
 
-	// Initialize middleware
-	authMiddleware := middleware.NewAuthMiddleware(jwtService)
+	// Readiness depends on the database, its schema and redis when configured
+	checker := health.NewChecker(healthCheckTimeout)
+	checker.Add("database", db.Ping)
+	checker.Add("migrations", migrator.Check)
+	if redisClient != nil {
+		checker.Add("redis", func(ctx context.Context) error {
+			return redisClient.Ping(ctx).Err()
+		})
+	}
 
-	// Initialize Gin router
-	r := gin.Default()
+	// Collect metrics of requests, queries and orders
+	m := metrics.New()
+	if err := m.InstrumentDB(db.DB); err != nil {
+		fatal("Failed to instrument database", err)
+	}
 
-	// Add CORS middleware
-	r.Use(middleware.CORS(cfg.CORS.AllowedOrigins))
+	// Trace queries as children of the request spans
+	if err := tracing.InstrumentDB(db.DB); err != nil {
+		fatal("Failed to instrument database for tracing", err)
+	}
 
-	// Health check
-	r.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{
-			"status":  "ok",
-			"message": "Food Court API is running",
-		})
-	})
-
-	// API routes
-	api := r.Group("/api/v1")
-
-	// Auth routes (public)
-	auth := api.Group("/auth")
-	{
-		auth.POST("/login", authHandler.Login)
-		auth.POST("/register", authHandler.Register)
-		auth.POST("/refresh", authHandler.RefreshToken)
-	}
-
-	// Protected routes
-	protected := api.Group("/")
-	protected.Use(authMiddleware.RequireAuth())
-	{
-		// User profile
-		protected.GET("/me", authHandler.Me)
-
-		// Kios routes
-		kios := protected.Group("/kios")
-		{
-			kios.GET("/", kiosHandler.GetAll)
-			kios.POST("/", authMiddleware.RequireRole("cashier"), kiosHandler.Create)
-
-			// Specific kios routes with ID
-			kios.GET("/:id", kiosHandler.GetByID)
-			kios.PUT("/:id", authMiddleware.RequireRole("cashier"), kiosHandler.Update)
-			kios.DELETE("/:id", authMiddleware.RequireRole("cashier"), kiosHandler.Delete)
-
-			// Menu routes for specific kios
-			kios.GET("/:id/menus", menuHandler.GetByKios)
-			kios.POST("/:id/menus", authMiddleware.RequireRole("cashier"), menuHandler.Create)
-
-			// Order routes for specific kios
-			kios.GET("/:id/orders", orderHandler.GetByKios)
-			kios.POST("/:id/orders", orderHandler.Create)
-			kios.GET("/:id/queue", orderHandler.GetQueue)
-		}
-
-		// Menu routes
-		menu := protected.Group("/menus")
-		{
-			menu.GET("/:id", menuHandler.GetByID)
-			menu.PUT("/:id", menuHandler.Update)
-			menu.DELETE("/:id", authMiddleware.RequireRole("cashier"), menuHandler.Delete)
-		}
-
-		// Order routes
-		orders := protected.Group("/orders")
-		{
-			orders.GET("/", authMiddleware.RequireRole("cashier"), orderHandler.GetAll)
-			orders.GET("/:id", orderHandler.GetByID)
-			orders.PUT("/:id/status", orderHandler.UpdateStatus)
-		}
-	}
-
-	// Start server
-	log.Printf("Starting server on port %s", cfg.Server.Port)
-	if err := r.Run(":" + cfg.Server.Port); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+	// Initialize router
+	r := newRouter(cfg, db.DB, jwtService, loginGuard, notifier, checker, m, limiter, responses)
+
+	// Start server, until SIGINT or SIGTERM asks it to stop
+	srv := newHTTPServer(cfg.Server, r)
+	ln, err := net.Listen("tcp", srv.Addr)
+	if err != nil {
+		fatal("Failed to start server", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	slog.Info("Starting server", "port", cfg.Server.Port)
+	policy := shutdownPolicy{
+		Draining: checker.ShutDown,
+		Delay:    cfg.Server.ShutdownDelay,
+		Timeout:  cfg.Server.ShutdownTimeout,
 	}
+	if err := serve(ctx, srv, ln, policy); err != nil {
+		slog.Error("Server stopped with error", "error", err)
+	}
+
+	// Export the spans still buffered, then the deferred calls close redis and
+	// then the database
+	flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := shutdownTracing(flushCtx); err != nil {
+		slog.Error("Failed to flush traces", "error", err)
+	}
+	slog.Info("Server stopped, closing connections")
+}
+
+// fatal logs the error and exits
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
@@ -0,0 +1,308 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"foodcourt-backend/internal/cache"
+	"foodcourt-backend/internal/config"
+	"foodcourt-backend/internal/database"
+	"foodcourt-backend/internal/health"
+	"foodcourt-backend/internal/logging"
+	"foodcourt-backend/internal/loginguard"
+	"foodcourt-backend/internal/metrics"
+	"foodcourt-backend/internal/notify"
+	"foodcourt-backend/internal/ratelimit"
+	"foodcourt-backend/internal/seed"
+	"foodcourt-backend/internal/tracing"
+	"foodcourt-backend/pkg/auth"
+
+	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+// Password of every user of the test fixture
+const seedPassword = "password"
+
+var databaseCount atomic.Int64
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	gin.DefaultWriter = io.Discard
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// testServer is the full router running against a private in-memory database
+// that has been migrated and seeded
+type testServer struct {
+	t        *testing.T
+	db       *gorm.DB
+	router   *gin.Engine
+	notifier *recordingNotifier
+	checker  *health.Checker
+}
+
+// newTestServer starts a test server with testConfig, changed by configure
+func newTestServer(t *testing.T, configure ...func(*config.Config)) *testServer {
+	t.Helper()
+
+	// Every test gets its own database, named so that all connections share it
+	dsn := fmt.Sprintf("file:test%d?mode=memory&cache=shared", databaseCount.Add(1))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		Logger:         logging.NewGormLogger(time.Second),
+		TranslateError: true,
+	})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+
+	// SQLite allows a single writer, so transactions are serialized on one connection
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("database instance: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	database := &database.Database{DB: db}
+	if err := database.AutoMigrate(); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	fixture, err := seed.Builtin("test")
+	if err != nil {
+		t.Fatalf("seed fixture: %v", err)
+	}
+	if _, err := seed.Apply(context.Background(), db, fixture); err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+
+	cfg := testConfig()
+	for _, f := range configure {
+		f(cfg)
+	}
+	jwtService, err := auth.NewJWTService("test-secret", cfg.JWT.ExpiresIn)
+	if err != nil {
+		t.Fatalf("jwt service: %v", err)
+	}
+	loginGuard := loginguard.New(loginguard.NewMemoryStore(), cfg.LoginGuard)
+	notifier := &recordingNotifier{}
+	checker := health.NewChecker(time.Second)
+	checker.Add("database", database.Ping)
+	m := metrics.New()
+	if err := m.InstrumentDB(db); err != nil {
+		t.Fatalf("instrument database: %v", err)
+	}
+	if err := tracing.InstrumentDB(db); err != nil {
+		t.Fatalf("trace database: %v", err)
+	}
+
+	limiter := ratelimit.New(ratelimit.NewMemoryStore())
+	responses := cache.New(cache.NewMemoryStore(), cfg.Cache.TTL)
+
+	return &testServer{
+		t:        t,
+		db:       db,
+		router:   newRouter(cfg, db, jwtService, loginGuard, notifier, checker, m, limiter, responses),
+		notifier: notifier,
+		checker:  checker,
+	}
+}
+
+const metricsToken = "scrape-token"
+
+func testConfig() *config.Config {
+	return &config.Config{
+		Server: config.ServerConfig{
+			MaxBodyBytes: 1 << 20,
+		},
+		JWT: config.JWTConfig{
+			ExpiresIn: time.Hour,
+		},
+		CORS: config.CORSConfig{
+			AllowedOrigins: []string{"http://localhost:3000"},
+		},
+		LoginGuard: config.LoginGuardConfig{
+			MaxAttempts:     5,
+			IPMaxAttempts:   50,
+			BackoffAfter:    3,
+			BackoffBase:     time.Minute,
+			LockoutDuration: 15 * time.Minute,
+			Window:          15 * time.Minute,
+		},
+		// High enough for any test, low ones are tested on their own
+		RateLimit: config.RateLimitConfig{
+			Enabled:       true,
+			Requests:      10000,
+			Window:        time.Minute,
+			AuthRequests:  10000,
+			AuthWindow:    time.Minute,
+			QueueRequests: 10000,
+			QueueWindow:   time.Minute,
+		},
+		Cache: config.CacheConfig{
+			Enabled: true,
+			TTL:     time.Minute,
+		},
+		TwoFactor: config.TwoFactorConfig{
+			Issuer:           "Food Court",
+			ChallengeExpires: 5 * time.Minute,
+		},
+		Password: config.PasswordConfig{
+			ResetExpiresIn: time.Hour,
+		},
+		Metrics: config.MetricsConfig{
+			Enabled: true,
+			Path:    "/metrics",
+			Token:   metricsToken,
+		},
+		I18n: config.I18nConfig{
+			DefaultLocale: "en",
+		},
+	}
+}
+
+// request sends a JSON request, authenticated with token when it is not empty
+func (s *testServer) request(method, path, token string, body interface{}) *httptest.ResponseRecorder {
+	s.t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			s.t.Fatalf("encode request body: %v", err)
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	return w
+}
+
+// login returns an access token for a seeded user
+func (s *testServer) login(username string) string {
+	s.t.Helper()
+
+	w := s.request(http.MethodPost, "/api/v1/auth/login", "", gin.H{
+		"username": username,
+		"password": seedPassword,
+	})
+	expectStatus(s.t, w, http.StatusOK)
+
+	var resp struct {
+		Token string `json:"token"`
+	}
+	decode(s.t, w, &resp)
+	if resp.Token == "" {
+		s.t.Fatalf("login of %s returned no token", username)
+	}
+	return resp.Token
+}
+
+// count returns the number of rows of a model, including soft-deleted ones
+func (s *testServer) count(model interface{}) int64 {
+	s.t.Helper()
+
+	var n int64
+	if err := s.db.Unscoped().Model(model).Count(&n).Error; err != nil {
+		s.t.Fatalf("count rows: %v", err)
+	}
+	return n
+}
+
+func expectStatus(t *testing.T, w *httptest.ResponseRecorder, status int) {
+	t.Helper()
+	if w.Code != status {
+		t.Fatalf("expected status %d, got %d: %s", status, w.Code, w.Body.String())
+	}
+}
+
+func decode(t *testing.T, w *httptest.ResponseRecorder, v interface{}) {
+	t.Helper()
+	if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+		t.Fatalf("decode response %q: %v", w.Body.String(), err)
+	}
+}
+
+// recordingNotifier keeps sent messages instead of delivering them
+type recordingNotifier struct {
+	mu     sync.Mutex
+	resets []notify.PasswordReset
+}
+
+func (n *recordingNotifier) SendPasswordReset(ctx context.Context, reset notify.PasswordReset) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.resets = append(n.resets, reset)
+	return nil
+}
+
+func (n *recordingNotifier) lastReset() (notify.PasswordReset, bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if len(n.resets) == 0 {
+		return notify.PasswordReset{}, false
+	}
+	return n.resets[len(n.resets)-1], true
+}
+
+func (n *recordingNotifier) resetCount() int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return len(n.resets)
+}
+
+func TestHealth(t *testing.T) {
+	s := newTestServer(t)
+
+	w := s.request(http.MethodGet, "/health", "", nil)
+	expectStatus(t, w, http.StatusOK)
+}
+
+type paginationBody struct {
+	Page       int   `json:"page"`
+	Limit      int   `json:"limit"`
+	Total      int64 `json:"total"`
+	TotalPages int64 `json:"total_pages"`
+}
+
+func TestListParameterValidation(t *testing.T) {
+	s := newTestServer(t)
+	token := s.login("cashier")
+
+	paths := []string{
+		"/api/v1/kios/?page=0",
+		"/api/v1/kios/?limit=1000",
+		"/api/v1/kios/?sort=password",
+		"/api/v1/kios/?active=maybe",
+		"/api/v1/orders/?status=shipped",
+		"/api/v1/orders/?from=yesterday",
+		"/api/v1/users/?role=admin",
+	}
+
+	for _, path := range paths {
+		t.Run(path, func(t *testing.T) {
+			w := s.request(http.MethodGet, path, token, nil)
+			expectStatus(t, w, http.StatusBadRequest)
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type menuBody struct {
+	ID          uint    `json:"id"`
+	KiosID      uint    `json:"kios_id"`
+	Name        string  `json:"name"`
+	Price       float64 `json:"price"`
+	Category    string  `json:"category"`
+	IsAvailable bool    `json:"is_available"`
+}
+
+func TestListMenusOfKios(t *testing.T) {
+	s := newTestServer(t)
+	token := s.login("padang_user")
+
+	w := s.request(http.MethodGet, "/api/v1/kios/1/menus", token, nil)
+	expectStatus(t, w, http.StatusOK)
+
+	var all struct {
+		Data []menuBody `json:"data"`
+	}
+	decode(t, w, &all)
+	if len(all.Data) != 5 {
+		t.Fatalf("expected 5 menus, got %d", len(all.Data))
+	}
+
+	w = s.request(http.MethodGet, "/api/v1/kios/1/menus?category=drink", token, nil)
+	expectStatus(t, w, http.StatusOK)
+
+	var drinks struct {
+		Data []menuBody `json:"data"`
+	}
+	decode(t, w, &drinks)
+	if len(drinks.Data) != 2 {
+		t.Fatalf("expected 2 drinks, got %d", len(drinks.Data))
+	}
+	for _, menu := range drinks.Data {
+		if menu.Category != "drink" || menu.KiosID != 1 {
+			t.Errorf("unexpected menu %+v", menu)
+		}
+	}
+
+	w = s.request(http.MethodGet, "/api/v1/kios/1/menus?sort=-price&limit=2", token, nil)
+	expectStatus(t, w, http.StatusOK)
+
+	var expensive struct {
+		Data       []menuBody     `json:"data"`
+		Pagination paginationBody `json:"pagination"`
+	}
+	decode(t, w, &expensive)
+	if expensive.Pagination.Total != 5 || len(expensive.Data) != 2 {
+		t.Fatalf("expected 2 of 5 menus, got %d of %d", len(expensive.Data), expensive.Pagination.Total)
+	}
+	if expensive.Data[0].Name != "Gulai Kambing" || expensive.Data[1].Name != "Nasi Rendang" {
+		t.Fatalf("expected menus sorted by price, got %+v", expensive.Data)
+	}
+
+	w = s.request(http.MethodGet, "/api/v1/kios/1/menus?q=jeruk", token, nil)
+	expectStatus(t, w, http.StatusOK)
+
+	var search struct {
+		Data []menuBody `json:"data"`
+	}
+	decode(t, w, &search)
+	if len(search.Data) != 1 || search.Data[0].Name != "Es Jeruk" {
+		t.Fatalf("expected only Es Jeruk, got %+v", search.Data)
+	}
+}
+
+func TestMenuLifecycle(t *testing.T) {
+	s := newTestServer(t)
+	token := s.login("cashier")
+
+	w := s.request(http.MethodPost, "/api/v1/kios/2/menus", token, gin.H{
+		"name":     "Pangsit Goreng",
+		"price":    12000,
+		"category": "snack",
+	})
+	expectStatus(t, w, http.StatusCreated)
+
+	var created struct {
+		Data menuBody `json:"data"`
+	}
+	decode(t, w, &created)
+	if created.Data.KiosID != 2 || !created.Data.IsAvailable {
+		t.Fatalf("unexpected menu %+v", created.Data)
+	}
+	path := fmt.Sprintf("/api/v1/menus/%d", created.Data.ID)
+
+	w = s.request(http.MethodPut, path, token, gin.H{"price": 13000, "is_available": false})
+	expectStatus(t, w, http.StatusOK)
+
+	w = s.request(http.MethodGet, path, token, nil)
+	expectStatus(t, w, http.StatusOK)
+
+	var updated struct {
+		Data menuBody `json:"data"`
+	}
+	decode(t, w, &updated)
+	if updated.Data.Price != 13000 || updated.Data.IsAvailable || updated.Data.Name != "Pangsit Goreng" {
+		t.Fatalf("unexpected menu after update %+v", updated.Data)
+	}
+
+	w = s.request(http.MethodDelete, path, token, nil)
+	expectStatus(t, w, http.StatusOK)
+
+	w = s.request(http.MethodGet, path, token, nil)
+	expectStatus(t, w, http.StatusNotFound)
+}
+
+func TestCreateMenuRejectsInvalidInput(t *testing.T) {
+	s := newTestServer(t)
+	token := s.login("cashier")
+
+	tests := []struct {
+		name string
+		path string
+		body gin.H
+	}{
+		{"unknown kios", "/api/v1/kios/999/menus", gin.H{"name": "Es Kopi", "price": 8000, "category": "drink"}},
+		{"negative price", "/api/v1/kios/1/menus", gin.H{"name": "Es Kopi", "price": -1, "category": "drink"}},
+		{"unknown category", "/api/v1/kios/1/menus", gin.H{"name": "Es Kopi", "price": 8000, "category": "coffee"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := s.request(http.MethodPost, tt.path, token, tt.body)
+			expectStatus(t, w, http.StatusBadRequest)
+		})
+	}
+}
+
+func TestOnlyCashierChangesMenus(t *testing.T) {
+	s := newTestServer(t)
+	token := s.login("padang_user")
+
+	w := s.request(http.MethodPost, "/api/v1/kios/1/menus", token, gin.H{
+		"name":     "Es Kopi",
+		"price":    8000,
+		"category": "drink",
+	})
+	expectStatus(t, w, http.StatusForbidden)
+
+	// Not even the menus of their own kios
+	w = s.request(http.MethodPut, "/api/v1/menus/1", token, gin.H{"price": 1000})
+	expectStatus(t, w, http.StatusForbidden)
+	w = s.request(http.MethodPatch, "/api/v1/menus/1", token, gin.H{"is_available": false})
+	expectStatus(t, w, http.StatusForbidden)
+	w = s.request(http.MethodDelete, "/api/v1/menus/1", token, nil)
+	expectStatus(t, w, http.StatusForbidden)
+}
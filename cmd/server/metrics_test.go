@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMetrics(t *testing.T) {
+	s := newTestServer(t)
+	token := s.login("padang_user")
+
+	// Two orders of kios 1 are paid, one stays in the queue and one is cancelled
+	for i := 0; i < 2; i++ {
+		order := s.createOrder(token, 1, gin.H{"menu_id": 1, "quantity": 1})
+		path := fmt.Sprintf("/api/v1/orders/%d/status", order.ID)
+		expectStatus(t, s.request(http.MethodPut, path, token, gin.H{"status": "paid", "payment_method": "cash"}), http.StatusOK)
+		if i == 0 {
+			expectStatus(t, s.request(http.MethodPut, path, token, gin.H{"status": "ready"}), http.StatusOK)
+		}
+	}
+	cancelled := s.createOrder(token, 1, gin.H{"menu_id": 1, "quantity": 1})
+	expectStatus(t, s.request(http.MethodPut, fmt.Sprintf("/api/v1/orders/%d/status", cancelled.ID), token, gin.H{"status": "cancelled"}), http.StatusOK)
+
+	// Scrapers need the token
+	expectStatus(t, s.request(http.MethodGet, "/metrics", "", nil), http.StatusUnauthorized)
+
+	w := s.request(http.MethodGet, "/metrics", metricsToken, nil)
+	expectStatus(t, w, http.StatusOK)
+	body := w.Body.String()
+
+	for _, line := range []string{
+		`foodcourt_http_requests_total{method="POST",route="/api/v1/kios/:id/orders",status="201"} 3`,
+		`foodcourt_orders_created_total{kios_id="1"} 3`,
+		`foodcourt_orders_paid_total{kios_id="1"} 2`,
+		`foodcourt_orders_cancelled_total{kios_id="1"} 1`,
+		`foodcourt_order_paid_to_ready_seconds_count{kios_id="1"} 1`,
+		`foodcourt_queue_length{kios_id="1"} 2`,
+		`foodcourt_queue_length{kios_id="2"} 0`,
+		`foodcourt_db_query_duration_seconds_count{operation="create",table="orders"} 3`,
+		`go_sql_max_open_connections{db_name="foodcourt"} 1`,
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("expected %s in the metrics", line)
+		}
+	}
+}
@@ -0,0 +1,356 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"foodcourt-backend/internal/config"
+	"foodcourt-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+type orderBody struct {
+	ID          uint    `json:"id"`
+	QueueNumber string  `json:"queue_number"`
+	KiosID      uint    `json:"kios_id"`
+	Status      string  `json:"status"`
+	TotalAmount float64 `json:"total_amount"`
+	TaxAmount   float64 `json:"tax_amount"`
+	PaidAt      *string `json:"paid_at"`
+	Version     uint    `json:"version"`
+	OrderItems  []struct {
+		MenuID   uint    `json:"menu_id"`
+		MenuName string  `json:"menu_name"`
+		Quantity int     `json:"quantity"`
+		Subtotal float64 `json:"subtotal"`
+	} `json:"order_items"`
+}
+
+func (s *testServer) createOrder(token string, kiosID uint, items ...gin.H) orderBody {
+	s.t.Helper()
+
+	w := s.request(http.MethodPost, fmt.Sprintf("/api/v1/kios/%d/orders", kiosID), token, gin.H{"items": items})
+	expectStatus(s.t, w, http.StatusCreated)
+
+	var resp struct {
+		Data orderBody `json:"data"`
+	}
+	decode(s.t, w, &resp)
+	return resp.Data
+}
+
+func queueNumber(kiosID uint, sequence int) string {
+	today := testConfig().Business.Calendar().Date(time.Now())
+	return fmt.Sprintf("K%d-%s-%03d", kiosID, today.Format("20060102"), sequence)
+}
+
+func TestCreateOrder(t *testing.T) {
+	s := newTestServer(t)
+	token := s.login("padang_user")
+
+	order := s.createOrder(token, 1,
+		gin.H{"menu_id": 1, "quantity": 2},
+		gin.H{"menu_id": 4, "quantity": 1, "notes": "less sugar"},
+	)
+
+	if order.Status != "pending" {
+		t.Errorf("expected pending order, got %s", order.Status)
+	}
+	if order.TotalAmount != 55000 {
+		t.Errorf("expected total 55000, got %v", order.TotalAmount)
+	}
+	if len(order.OrderItems) != 2 || order.OrderItems[0].MenuName != "Nasi Rendang" || order.OrderItems[0].Subtotal != 50000 {
+		t.Errorf("unexpected items %+v", order.OrderItems)
+	}
+	if want := queueNumber(1, 1); order.QueueNumber != want {
+		t.Errorf("expected queue number %s, got %s", want, order.QueueNumber)
+	}
+
+	// Queue numbers count per kios
+	second := s.createOrder(token, 1, gin.H{"menu_id": 2, "quantity": 1})
+	if want := queueNumber(1, 2); second.QueueNumber != want {
+		t.Errorf("expected queue number %s, got %s", want, second.QueueNumber)
+	}
+	other := s.createOrder(token, 2, gin.H{"menu_id": 6, "quantity": 1})
+	if want := queueNumber(2, 1); other.QueueNumber != want {
+		t.Errorf("expected queue number %s, got %s", want, other.QueueNumber)
+	}
+}
+
+func TestOrderTotalsIncludeTax(t *testing.T) {
+	tests := []struct {
+		name       string
+		included   bool
+		tax, total float64
+	}{
+		{"added to prices", false, 6050, 61050},
+		{"included in prices", true, 5450.45, 55000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t, func(cfg *config.Config) {
+				cfg.Business.TaxRate = 0.11
+				cfg.Business.TaxIncluded = tt.included
+			})
+
+			order := s.createOrder(s.login("padang_user"), 1,
+				gin.H{"menu_id": 1, "quantity": 2},
+				gin.H{"menu_id": 4, "quantity": 1},
+			)
+			if order.TaxAmount != tt.tax || order.TotalAmount != tt.total {
+				t.Fatalf("expected tax %v of %v, got %v of %v", tt.tax, tt.total, order.TaxAmount, order.TotalAmount)
+			}
+			if order.OrderItems[0].Subtotal != 50000 {
+				t.Fatalf("expected item prices without tax changes, got %+v", order.OrderItems)
+			}
+		})
+	}
+}
+
+func TestCreateOrderRejectsInvalidItems(t *testing.T) {
+	s := newTestServer(t)
+	token := s.login("cashier")
+
+	w := s.request(http.MethodPut, "/api/v1/menus/2", token, gin.H{"is_available": false})
+	expectStatus(t, w, http.StatusOK)
+
+	tests := []struct {
+		name  string
+		items []gin.H
+	}{
+		{"unknown menu", []gin.H{{"menu_id": 1, "quantity": 1}, {"menu_id": 999, "quantity": 1}}},
+		{"unavailable menu", []gin.H{{"menu_id": 1, "quantity": 1}, {"menu_id": 2, "quantity": 1}}},
+		{"zero quantity", []gin.H{{"menu_id": 1, "quantity": 0}}},
+		{"no items", []gin.H{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := s.request(http.MethodPost, "/api/v1/kios/1/orders", token, gin.H{"items": tt.items})
+			expectStatus(t, w, http.StatusBadRequest)
+		})
+	}
+
+	if n := s.count(&models.Order{}); n != 0 {
+		t.Fatalf("expected no orders, got %d", n)
+	}
+	if n := s.count(&models.OrderItem{}); n != 0 {
+		t.Fatalf("expected no order items, got %d", n)
+	}
+
+	// Rejected orders do not use up queue numbers
+	order := s.createOrder(token, 1, gin.H{"menu_id": 1, "quantity": 1})
+	if want := queueNumber(1, 1); order.QueueNumber != want {
+		t.Fatalf("expected queue number %s, got %s", want, order.QueueNumber)
+	}
+}
+
+func TestCreateOrderRollsBackOnFailure(t *testing.T) {
+	s := newTestServer(t)
+	token := s.login("cashier")
+
+	// Recording the audit entry is the last step of the order transaction
+	if err := s.db.Migrator().DropTable(&models.AuditLog{}); err != nil {
+		t.Fatalf("drop audit table: %v", err)
+	}
+
+	w := s.request(http.MethodPost, "/api/v1/kios/1/orders", token, gin.H{
+		"items": []gin.H{{"menu_id": 1, "quantity": 1}, {"menu_id": 3, "quantity": 2}},
+	})
+	expectStatus(t, w, http.StatusInternalServerError)
+
+	if n := s.count(&models.Order{}); n != 0 {
+		t.Fatalf("expected the order to be rolled back, got %d orders", n)
+	}
+	if n := s.count(&models.OrderItem{}); n != 0 {
+		t.Fatalf("expected the items to be rolled back, got %d items", n)
+	}
+}
+
+// Parallel requests must not share queue numbers. The test database runs one
+// transaction at a time and SQLite ignores FOR UPDATE, so this covers the
+// numbering of overlapping requests only; it cannot catch a missing kios lock,
+// which only matters on Postgres.
+func TestParallelOrdersGetDistinctQueueNumbers(t *testing.T) {
+	s := newTestServer(t)
+	token := s.login("padang_user")
+
+	const orders = 20
+	body := gin.H{"items": []gin.H{{"menu_id": 1, "quantity": 1}}}
+
+	responses := make([]*httptest.ResponseRecorder, orders)
+	var wg sync.WaitGroup
+	for i := 0; i < orders; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			responses[i] = s.request(http.MethodPost, "/api/v1/kios/1/orders", token, body)
+		}(i)
+	}
+	wg.Wait()
+
+	numbers := make([]string, 0, orders)
+	for _, w := range responses {
+		expectStatus(t, w, http.StatusCreated)
+
+		var resp struct {
+			Data orderBody `json:"data"`
+		}
+		decode(t, w, &resp)
+		numbers = append(numbers, resp.Data.QueueNumber)
+	}
+
+	sort.Strings(numbers)
+	for i, number := range numbers {
+		if want := queueNumber(1, i+1); number != want {
+			t.Fatalf("expected queue numbers 1..%d without gaps or duplicates, got %v", orders, numbers)
+		}
+	}
+}
+
+func TestOrderStatusFlow(t *testing.T) {
+	s := newTestServer(t)
+	token := s.login("padang_user")
+
+	order := s.createOrder(token, 1, gin.H{"menu_id": 1, "quantity": 1})
+	statusPath := fmt.Sprintf("/api/v1/orders/%d/status", order.ID)
+
+	queue := func() []orderBody {
+		t.Helper()
+		w := s.request(http.MethodGet, "/api/v1/kios/1/queue", token, nil)
+		expectStatus(t, w, http.StatusOK)
+
+		var resp struct {
+			Data []orderBody `json:"data"`
+		}
+		decode(t, w, &resp)
+		return resp.Data
+	}
+
+	// Unpaid orders are not queued
+	if q := queue(); len(q) != 0 {
+		t.Fatalf("expected an empty queue, got %d orders", len(q))
+	}
+
+	w := s.request(http.MethodPut, statusPath, token, gin.H{"status": "paid", "payment_method": "cash"})
+	expectStatus(t, w, http.StatusOK)
+
+	var paid struct {
+		Data orderBody `json:"data"`
+	}
+	decode(t, w, &paid)
+	if paid.Data.Status != "paid" || paid.Data.PaidAt == nil {
+		t.Fatalf("unexpected order after payment %+v", paid.Data)
+	}
+	if q := queue(); len(q) != 1 || q[0].ID != order.ID {
+		t.Fatalf("expected the paid order in the queue, got %+v", q)
+	}
+
+	for _, status := range []string{"preparing", "ready", "completed"} {
+		w := s.request(http.MethodPut, statusPath, token, gin.H{"status": status})
+		expectStatus(t, w, http.StatusOK)
+	}
+	if q := queue(); len(q) != 0 {
+		t.Fatalf("expected completed orders to leave the queue, got %d orders", len(q))
+	}
+
+	w = s.request(http.MethodPut, statusPath, token, gin.H{"status": "shipped"})
+	expectStatus(t, w, http.StatusBadRequest)
+
+	w = s.request(http.MethodPut, "/api/v1/orders/999/status", token, gin.H{"status": "paid"})
+	expectStatus(t, w, http.StatusNotFound)
+}
+
+func TestListOrders(t *testing.T) {
+	s := newTestServer(t)
+	token := s.login("cashier")
+
+	first := s.createOrder(token, 1, gin.H{"menu_id": 1, "quantity": 1})
+	s.createOrder(token, 2, gin.H{"menu_id": 6, "quantity": 1})
+
+	w := s.request(http.MethodPut, fmt.Sprintf("/api/v1/orders/%d/status", first.ID), token, gin.H{"status": "paid", "payment_method": "card"})
+	expectStatus(t, w, http.StatusOK)
+
+	list := func(path string) []orderBody {
+		t.Helper()
+		w := s.request(http.MethodGet, path, token, nil)
+		expectStatus(t, w, http.StatusOK)
+
+		var resp struct {
+			Data []orderBody `json:"data"`
+		}
+		decode(t, w, &resp)
+		return resp.Data
+	}
+
+	if orders := list("/api/v1/orders/"); len(orders) != 2 {
+		t.Fatalf("expected 2 orders, got %d", len(orders))
+	}
+	if orders := list("/api/v1/orders/?status=paid"); len(orders) != 1 || orders[0].ID != first.ID {
+		t.Fatalf("expected only the paid order, got %+v", orders)
+	}
+	if orders := list("/api/v1/kios/2/orders"); len(orders) != 1 || orders[0].KiosID != 2 {
+		t.Fatalf("expected only the order of kios 2, got %+v", orders)
+	}
+	if orders := list("/api/v1/orders/?kios_id=1"); len(orders) != 1 || orders[0].KiosID != 1 {
+		t.Fatalf("expected only the order of kios 1, got %+v", orders)
+	}
+	if orders := list("/api/v1/orders/?status=paid&status=pending"); len(orders) != 2 {
+		t.Fatalf("expected orders of both statuses, got %d", len(orders))
+	}
+	if orders := list("/api/v1/orders/?status=paid,cancelled"); len(orders) != 1 {
+		t.Fatalf("expected only the paid order, got %d", len(orders))
+	}
+	if orders := list("/api/v1/orders/?sort=-total_amount"); orders[0].KiosID != 1 {
+		t.Fatalf("expected the most expensive order first, got %+v", orders)
+	}
+
+	today := time.Now().Format("2006-01-02")
+	if orders := list("/api/v1/orders/?from=" + today + "&to=" + today); len(orders) != 2 {
+		t.Fatalf("expected the orders of today, got %d", len(orders))
+	}
+	tomorrow := time.Now().AddDate(0, 0, 1).Format("2006-01-02")
+	if orders := list("/api/v1/orders/?from=" + tomorrow); len(orders) != 0 {
+		t.Fatalf("expected no orders from tomorrow, got %d", len(orders))
+	}
+}
+
+func TestListOrdersPagination(t *testing.T) {
+	s := newTestServer(t)
+	token := s.login("cashier")
+
+	for i := 0; i < 5; i++ {
+		s.createOrder(token, 1, gin.H{"menu_id": 1, "quantity": 1})
+	}
+
+	seen := map[uint]bool{}
+	for page := 1; page <= 3; page++ {
+		w := s.request(http.MethodGet, fmt.Sprintf("/api/v1/orders/?limit=2&page=%d", page), token, nil)
+		expectStatus(t, w, http.StatusOK)
+
+		var resp struct {
+			Data       []orderBody    `json:"data"`
+			Pagination paginationBody `json:"pagination"`
+		}
+		decode(t, w, &resp)
+		if resp.Pagination.Total != 5 || resp.Pagination.TotalPages != 3 {
+			t.Fatalf("unexpected pagination %+v", resp.Pagination)
+		}
+		for _, order := range resp.Data {
+			if seen[order.ID] {
+				t.Fatalf("order %d returned on more than one page", order.ID)
+			}
+			seen[order.ID] = true
+		}
+	}
+	if len(seen) != 5 {
+		t.Fatalf("expected all 5 orders across pages, got %d", len(seen))
+	}
+}
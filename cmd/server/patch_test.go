@@ -0,0 +1,178 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"foodcourt-backend/internal/apierror"
+	"foodcourt-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// expectFieldError checks that a request failed validation of a single field
+func expectFieldError(t *testing.T, w *httptest.ResponseRecorder, field, code string) {
+	t.Helper()
+
+	apiErr := expectError(t, w, http.StatusBadRequest, apierror.CodeValidationFailed)
+	if len(apiErr.Fields) != 1 || apiErr.Fields[0].Field != field || apiErr.Fields[0].Code != code {
+		t.Fatalf("expected %s to be %s, got %+v", field, code, apiErr.Fields)
+	}
+}
+
+func TestPatchMenuClearsFields(t *testing.T) {
+	s := newTestServer(t)
+	token := s.login("cashier")
+
+	w := s.request(http.MethodPatch, "/api/v1/menus/1", token, gin.H{
+		"image_url":    "https://example.com/rendang.jpg",
+		"translations": gin.H{"en": gin.H{"name": "Beef Rendang with Rice"}},
+	})
+	expectStatus(t, w, http.StatusOK)
+	if count := s.count(&models.MenuTranslation{}); count != 1 {
+		t.Fatalf("expected one translation, got %d", count)
+	}
+
+	// Null clears a field, where PUT would leave it alone
+	w = s.request(http.MethodPatch, "/api/v1/menus/1", token, gin.H{
+		"description":  nil,
+		"image_url":    nil,
+		"translations": gin.H{"en": nil},
+	})
+	expectStatus(t, w, http.StatusOK)
+
+	var resp struct {
+		Data models.MenuResponse `json:"data"`
+	}
+	decode(t, w, &resp)
+	if resp.Data.Description != "" || resp.Data.ImageURL != "" {
+		t.Fatalf("expected description and image cleared, got %+v", resp.Data)
+	}
+	if resp.Data.Name != "Nasi Rendang" || resp.Data.Price != 25000 || !resp.Data.IsAvailable {
+		t.Fatalf("expected fields missing from the patch kept, got %+v", resp.Data)
+	}
+	if count := s.count(&models.MenuTranslation{}); count != 0 {
+		t.Fatalf("expected the translation removed, got %d", count)
+	}
+}
+
+func TestPatchValidatesTheResult(t *testing.T) {
+	s := newTestServer(t)
+	token := s.login("cashier")
+
+	w := s.request(http.MethodPatch, "/api/v1/menus/1", token, gin.H{"name": nil})
+	expectFieldError(t, w, "name", "required")
+	w = s.request(http.MethodPatch, "/api/v1/menus/1", token, gin.H{"price": nil})
+	expectFieldError(t, w, "price", "required")
+	w = s.request(http.MethodPatch, "/api/v1/menus/1", token, gin.H{"stock": 10})
+	expectFieldError(t, w, "stock", "unknown")
+	w = s.request(http.MethodPatch, "/api/v1/menus/1", token, gin.H{"stock": nil})
+	expectFieldError(t, w, "stock", "unknown")
+	w = s.request(http.MethodPatch, "/api/v1/kios/1", token, gin.H{"owner": nil})
+	expectFieldError(t, w, "owner", "unknown")
+	w = s.request(http.MethodPatch, "/api/v1/menus/1", token, gin.H{"translations": gin.H{"fr": gin.H{"name": "Riz"}}})
+	expectError(t, w, http.StatusBadRequest, apierror.CodeValidationFailed)
+
+	// A patch that is not an object would replace the whole menu
+	w = s.request(http.MethodPatch, "/api/v1/menus/1", token, []string{"name"})
+	expectError(t, w, http.StatusBadRequest, apierror.CodeInvalidRequest)
+	w = s.request(http.MethodPatch, "/api/v1/menus/1", token, nil)
+	expectError(t, w, http.StatusBadRequest, apierror.CodeInvalidRequest)
+
+	w = s.request(http.MethodPatch, "/api/v1/menus/999", token, gin.H{"price": 1000})
+	expectError(t, w, http.StatusNotFound, apierror.CodeNotFound)
+}
+
+func TestPatchKiosRequiresTheCurrentVersion(t *testing.T) {
+	s := newTestServer(t)
+	token := s.login("cashier")
+
+	w := s.requestIfMatch(http.MethodPatch, "/api/v1/kios/1", token, `"1"`, gin.H{"location": nil})
+	expectStatus(t, w, http.StatusOK)
+	if version := w.Header().Get("X-Resource-Version"); version != "2" {
+		t.Fatalf("expected the next version, got %q", version)
+	}
+
+	var resp struct {
+		Data models.KiosResponse `json:"data"`
+	}
+	decode(t, w, &resp)
+	if resp.Data.Location != "" || resp.Data.Name != "Warung Nasi Padang" {
+		t.Fatalf("expected only the location cleared, got %+v", resp.Data)
+	}
+
+	w = s.requestIfMatch(http.MethodPatch, "/api/v1/kios/1", token, `"1"`, gin.H{"location": "Lantai 2"})
+	expectError(t, w, http.StatusPreconditionFailed, apierror.CodePreconditionFailed)
+
+	w = s.request(http.MethodPatch, "/api/v1/kios/1", s.login("padang_user"), gin.H{"location": "Lantai 2"})
+	expectError(t, w, http.StatusForbidden, apierror.CodeForbidden)
+}
+
+func TestPatchUser(t *testing.T) {
+	s := newTestServer(t)
+	token := s.login("cashier")
+	kiosToken := s.login("padang_user")
+
+	w := s.request(http.MethodPatch, "/api/v1/users/2", token, gin.H{"full_name": "Pelayan Padang"})
+	expectStatus(t, w, http.StatusOK)
+	var resp struct {
+		Data models.UserResponse `json:"data"`
+	}
+	decode(t, w, &resp)
+	if resp.Data.FullName != "Pelayan Padang" || resp.Data.Email != "padang@foodcourt.com" {
+		t.Fatalf("unexpected user after patch %+v", resp.Data)
+	}
+
+	// Profile changes leave sessions alone
+	w = s.request(http.MethodGet, "/api/v1/me", kiosToken, nil)
+	expectStatus(t, w, http.StatusOK)
+
+	w = s.request(http.MethodPatch, "/api/v1/users/2", token, gin.H{"kios_id": nil})
+	expectFieldError(t, w, "kios_id", "required_if")
+	w = s.request(http.MethodPatch, "/api/v1/users/2", token, gin.H{"kios_id": 999})
+	expectError(t, w, http.StatusBadRequest, apierror.CodeInvalidRequest)
+	w = s.request(http.MethodPatch, "/api/v1/users/2", token, gin.H{"password": "secret"})
+	expectFieldError(t, w, "password", "unknown")
+
+	// Cashiers cannot lock themselves out
+	w = s.request(http.MethodPatch, "/api/v1/users/1", token, gin.H{"is_active": false})
+	expectError(t, w, http.StatusForbidden, apierror.CodeForbidden)
+	w = s.request(http.MethodPatch, "/api/v1/users/1", token, gin.H{"role": "kios", "kios_id": 1})
+	expectError(t, w, http.StatusForbidden, apierror.CodeForbidden)
+
+	// Moving a user to another kios ends their sessions
+	w = s.request(http.MethodPatch, "/api/v1/users/2", token, gin.H{"kios_id": 2})
+	expectStatus(t, w, http.StatusOK)
+	w = s.request(http.MethodGet, "/api/v1/me", kiosToken, nil)
+	expectError(t, w, http.StatusUnauthorized, apierror.CodeSessionRevoked)
+
+	w = s.request(http.MethodPatch, "/api/v1/users/2", kiosToken, gin.H{"full_name": "Pelayan"})
+	expectError(t, w, http.StatusUnauthorized, apierror.CodeSessionRevoked)
+}
+
+func TestPatchUserToCashierDropsTheKios(t *testing.T) {
+	s := newTestServer(t)
+	kiosToken := s.login("padang_user")
+
+	w := s.request(http.MethodPatch, "/api/v1/users/2", s.login("cashier"), gin.H{"role": "cashier"})
+	expectStatus(t, w, http.StatusOK)
+	var resp struct {
+		Data models.UserResponse `json:"data"`
+	}
+	decode(t, w, &resp)
+	if resp.Data.Role != models.RoleCashier || resp.Data.KiosID != nil || resp.Data.Kios != nil {
+		t.Fatalf("expected a cashier without a kios, got %+v", resp.Data)
+	}
+
+	var user models.User
+	if err := s.db.First(&user, 2).Error; err != nil {
+		t.Fatalf("load user: %v", err)
+	}
+	if user.KiosID != nil || user.TokenVersion != 1 {
+		t.Fatalf("expected the kios cleared and tokens revoked once, got kios %v and version %d", user.KiosID, user.TokenVersion)
+	}
+
+	w = s.request(http.MethodGet, "/api/v1/me", kiosToken, nil)
+	expectError(t, w, http.StatusUnauthorized, apierror.CodeSessionRevoked)
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"foodcourt-backend/internal/apierror"
+	"foodcourt-backend/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAuthRoutesAreRateLimitedByIP(t *testing.T) {
+	s := newTestServer(t, func(cfg *config.Config) {
+		cfg.RateLimit.AuthRequests = 2
+		cfg.RateLimit.AuthWindow = time.Minute
+	})
+
+	for i := 0; i < 2; i++ {
+		w := s.request(http.MethodPost, "/api/v1/auth/login", "", gin.H{"username": "cashier", "password": seedPassword})
+		expectStatus(t, w, http.StatusOK)
+		if remaining := w.Header().Get("RateLimit-Remaining"); remaining != strconv.Itoa(1-i) {
+			t.Fatalf("expected %d requests remaining, got %q", 1-i, remaining)
+		}
+	}
+
+	w := s.request(http.MethodPost, "/api/v1/auth/login", "", gin.H{"username": "padang_user", "password": seedPassword})
+	apiErr := expectError(t, w, http.StatusTooManyRequests, apierror.CodeTooManyRequests)
+	// A token takes 30s to refill
+	if apiErr.RetryAfter < 1 || apiErr.RetryAfter > 30 || w.Header().Get("Retry-After") != strconv.Itoa(apiErr.RetryAfter) {
+		t.Fatalf("expected to retry after at most 30s, got %d and %q", apiErr.RetryAfter, w.Header().Get("Retry-After"))
+	}
+	if w.Header().Get("RateLimit-Limit") != "2" || w.Header().Get("RateLimit-Policy") != "2;w=60" || w.Header().Get("RateLimit-Remaining") != "0" {
+		t.Fatalf("unexpected rate limit headers %v", w.Header())
+	}
+}
+
+// loginFrom logs in through a proxy that claims to forward for clientIP
+func (s *testServer) loginFrom(clientIP string) *httptest.ResponseRecorder {
+	s.t.Helper()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", strings.NewReader(`{"username":"cashier","password":"`+seedPassword+`"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Forwarded-For", clientIP)
+
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	return w
+}
+
+func TestForwardedForIsOnlyTrustedFromProxies(t *testing.T) {
+	limitAuth := func(cfg *config.Config) {
+		cfg.RateLimit.AuthRequests = 2
+	}
+
+	// Clients cannot escape their bucket by naming another address
+	s := newTestServer(t, limitAuth)
+	expectStatus(t, s.loginFrom("203.0.113.1"), http.StatusOK)
+	expectStatus(t, s.loginFrom("203.0.113.2"), http.StatusOK)
+	expectError(t, s.loginFrom("203.0.113.3"), http.StatusTooManyRequests, apierror.CodeTooManyRequests)
+
+	// Behind a trusted proxy every forwarded client has a bucket of its own
+	s = newTestServer(t, limitAuth, func(cfg *config.Config) {
+		cfg.Server.TrustedProxies = []string{"192.0.2.0/24"}
+	})
+	for i := 0; i < 2; i++ {
+		expectStatus(t, s.loginFrom("203.0.113.1"), http.StatusOK)
+	}
+	expectError(t, s.loginFrom("203.0.113.1"), http.StatusTooManyRequests, apierror.CodeTooManyRequests)
+	expectStatus(t, s.loginFrom("203.0.113.2"), http.StatusOK)
+}
+
+func TestQueuePollingIsRateLimitedPerUser(t *testing.T) {
+	s := newTestServer(t, func(cfg *config.Config) {
+		cfg.RateLimit.QueueRequests = 3
+	})
+	padang := s.login("padang_user")
+	cashier := s.login("cashier")
+
+	for i := 0; i < 3; i++ {
+		expectStatus(t, s.request(http.MethodGet, "/api/v1/kios/1/queue", padang, nil), http.StatusOK)
+	}
+	w := s.request(http.MethodGet, "/api/v1/kios/1/queue", padang, nil)
+	expectError(t, w, http.StatusTooManyRequests, apierror.CodeTooManyRequests)
+
+	// Other users have buckets of their own, and other routes other policies
+	expectStatus(t, s.request(http.MethodGet, "/api/v1/kios/1/queue", cashier, nil), http.StatusOK)
+	expectStatus(t, s.request(http.MethodGet, "/api/v1/kios/1/menus", padang, nil), http.StatusOK)
+}
+
+func TestRequestBodySizeIsLimited(t *testing.T) {
+	s := newTestServer(t, func(cfg *config.Config) {
+		cfg.Server.MaxBodyBytes = 1024
+	})
+	token := s.login("cashier")
+
+	w := s.request(http.MethodPost, "/api/v1/kios/", token, gin.H{
+		"name":        "Kios Besar",
+		"description": strings.Repeat("x", 2048),
+	})
+	expectError(t, w, http.StatusRequestEntityTooLarge, apierror.CodePayloadTooLarge)
+
+	w = s.request(http.MethodPost, "/api/v1/kios/", token, gin.H{"name": "Kios Kecil"})
+	expectStatus(t, w, http.StatusCreated)
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"net/http"
+
+	"foodcourt-backend/internal/apierror"
+	"foodcourt-backend/internal/cache"
+	"foodcourt-backend/internal/config"
+	"foodcourt-backend/internal/handlers"
+	"foodcourt-backend/internal/health"
+	"foodcourt-backend/internal/i18n"
+	"foodcourt-backend/internal/loginguard"
+	"foodcourt-backend/internal/metrics"
+	"foodcourt-backend/internal/middleware"
+	"foodcourt-backend/internal/models"
+	"foodcourt-backend/internal/notify"
+	"foodcourt-backend/internal/ratelimit"
+	"foodcourt-backend/internal/repository"
+	"foodcourt-backend/internal/service"
+	"foodcourt-backend/internal/tracing"
+	"foodcourt-backend/pkg/auth"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// newRouter wires services, handlers and middleware and registers all routes
+func newRouter(cfg *config.Config, db *gorm.DB, jwtService *auth.JWTService, loginGuard *loginguard.Guard, notifier notify.Notifier, checker *health.Checker, m *metrics.Metrics, limiter *ratelimit.Limiter, responses *cache.Cache) *gin.Engine {
+	// Initialize services
+	store := repository.NewStore(db)
+	calendar := cfg.Business.Calendar()
+	authService := service.NewAuthService(store, jwtService, loginGuard, notifier, cfg)
+	kiosService := service.NewKiosService(store, calendar)
+	menuService := service.NewMenuService(store)
+	orderService := service.NewOrderService(store, m, calendar, cfg.Business.Tax())
+	deviceService := service.NewDeviceService(store)
+	auditService := service.NewAuditService(store)
+	userService := service.NewUserService(store)
+
+	// Initialize handlers, caching responses unless disabled
+	if !cfg.Cache.Enabled {
+		responses = nil
+	}
+	authHandler := handlers.NewAuthHandler(authService)
+	kiosHandler := handlers.NewKiosHandler(kiosService, responses)
+	menuHandler := handlers.NewMenuHandler(menuService, responses)
+	orderHandler := handlers.NewOrderHandler(orderService, calendar, responses)
+	deviceHandler := handlers.NewDeviceHandler(deviceService)
+	auditHandler := handlers.NewAuditHandler(auditService, calendar)
+	userHandler := handlers.NewUserHandler(userService)
+	healthHandler := handlers.NewHealthHandler(checker)
+
+	// Initialize middleware
+	authMiddleware := middleware.NewAuthMiddleware(jwtService, db)
+	deviceAuthMiddleware := middleware.NewDeviceAuthMiddleware(db, authMiddleware)
+
+	// Rate limits per policy. They follow authentication so that users and
+	// devices are limited on their own rather than by IP.
+	limit := func(policy ratelimit.Policy) gin.HandlerFunc {
+		if !cfg.RateLimit.Enabled {
+			return func(c *gin.Context) { c.Next() }
+		}
+		return middleware.RateLimit(limiter, policy)
+	}
+	apiLimit := limit(ratelimit.Policy{Name: "api", Requests: cfg.RateLimit.Requests, Window: cfg.RateLimit.Window})
+	authLimit := limit(ratelimit.Policy{Name: "auth", Requests: cfg.RateLimit.AuthRequests, Window: cfg.RateLimit.AuthWindow})
+	queueLimit := limit(ratelimit.Policy{Name: "queue", Requests: cfg.RateLimit.QueueRequests, Window: cfg.RateLimit.QueueWindow})
+
+	// Initialize Gin router, logging requests with slog instead of Gin's logger.
+	// Every error, including unknown routes, is answered with the error envelope.
+	apierror.UseJSONFieldNames()
+	r := gin.New()
+	r.HandleMethodNotAllowed = true
+	// Only trusted proxies may name the client, whose address rate limits and
+	// login throttling key on. config.Load has checked the list already.
+	if err := r.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		panic(err)
+	}
+	r.NoRoute(func(c *gin.Context) {
+		apierror.Abort(c, apierror.New(http.StatusNotFound, apierror.CodeNotFound, "Route not found"))
+	})
+	r.NoMethod(func(c *gin.Context) {
+		apierror.Abort(c, apierror.New(http.StatusMethodNotAllowed, apierror.CodeMethodNotAllowed, "Method not allowed"))
+	})
+	r.Use(middleware.RequestID(), tracing.Middleware(), middleware.AccessLog(), middleware.Recovery())
+	r.Use(middleware.Locale(cfg.I18n.DefaultLocale))
+
+	// Add metrics and CORS middleware
+	r.Use(m.Middleware())
+	r.Use(middleware.CORS(cfg.CORS.AllowedOrigins))
+
+	// Health checks. /health only reports that the process is running, like
+	// /livez; /readyz checks the dependencies as well.
+	r.GET("/health", func(c *gin.Context) {
+		c.JSON(200, gin.H{
+			"status":  "ok",
+			"message": i18n.T(i18n.FromContext(c.Request.Context()), "Food Court API is running"),
+		})
+	})
+	r.GET("/livez", healthHandler.Live)
+	r.GET("/readyz", healthHandler.Ready)
+
+	// Prometheus metrics
+	if cfg.Metrics.Enabled {
+		m.RegisterQueueLength(store.Kios().QueueLengths)
+		r.GET(cfg.Metrics.Path, middleware.RequireToken(cfg.Metrics.Token), gin.WrapH(m.Handler()))
+	}
+
+	// API routes
+	api := r.Group("/api/v1")
+	api.Use(middleware.BodyLimit(int64(cfg.Server.MaxBodyBytes)))
+
+	// Auth routes (public), limited by IP
+	auth := api.Group("/auth")
+	auth.Use(authLimit)
+	{
+		auth.POST("/login", authHandler.Login)
+		if cfg.Features.IsEnabled(config.FeatureRegistration) {
+			auth.POST("/register", authHandler.Register)
+		}
+		auth.POST("/refresh", authHandler.RefreshToken)
+		auth.POST("/password/forgot", authHandler.ForgotPassword)
+		auth.POST("/password/reset", authHandler.ResetPassword)
+		auth.POST("/unlock", authMiddleware.RequireAuth(), authMiddleware.RequireRole("cashier"), authHandler.Unlock)
+
+		// Second login step for accounts with two-factor authentication
+		auth.POST("/2fa/verify", authHandler.VerifyTwoFactor)
+		auth.POST("/2fa/setup", authHandler.StartTwoFactorSetup)
+		auth.POST("/2fa/setup/confirm", authHandler.ConfirmTwoFactorSetup)
+	}
+
+	// User profile, open to accounts that must change their password first
+	api.GET("/me", authMiddleware.RequireAuthForPasswordChange(), apiLimit, authHandler.Me)
+	api.POST("/me/password", authMiddleware.RequireAuthForPasswordChange(), apiLimit, authHandler.ChangePassword)
+
+	// Protected routes
+	protected := api.Group("/")
+	protected.Use(authMiddleware.RequireAuth(), apiLimit)
+	{
+		// Two-factor authentication of the current user
+		protected.POST("/me/2fa/enroll", authHandler.EnrollTwoFactor)
+		protected.POST("/me/2fa/confirm", authHandler.ConfirmTwoFactor)
+		protected.POST("/me/2fa/disable", authHandler.DisableTwoFactor)
+		protected.POST("/me/2fa/recovery-codes", authHandler.RegenerateRecoveryCodes)
+
+		// User administration
+		users := protected.Group("/users")
+		users.Use(authMiddleware.RequireRole("cashier"))
+		{
+			users.GET("/", userHandler.GetAll)
+			users.PATCH("/:id", userHandler.Patch)
+			users.DELETE("/:id/2fa", authHandler.ResetTwoFactor)
+		}
+
+		// Audit log
+		protected.GET("/audit", authMiddleware.RequireRole("cashier"), auditHandler.GetAll)
+
+		// Kios routes
+		kios := protected.Group("/kios")
+		{
+			kios.GET("/", kiosHandler.GetAll)
+			kios.POST("/", authMiddleware.RequireRole("cashier"), kiosHandler.Create)
+
+			// Specific kios routes with ID
+			kios.GET("/:id", kiosHandler.GetByID)
+			kios.PUT("/:id", authMiddleware.RequireRole("cashier"), kiosHandler.Update)
+			kios.PATCH("/:id", authMiddleware.RequireRole("cashier"), kiosHandler.Patch)
+			kios.DELETE("/:id", authMiddleware.RequireRole("cashier"), kiosHandler.Delete)
+
+			// Menu routes for specific kios
+			kios.GET("/:id/menus", menuHandler.GetByKios)
+			kios.POST("/:id/menus", authMiddleware.RequireRole("cashier"), menuHandler.Create)
+
+			// Order routes for specific kios
+			kios.GET("/:id/orders", orderHandler.GetByKios)
+			kios.POST("/:id/orders", orderHandler.Create)
+		}
+
+		// Menu routes
+		menu := protected.Group("/menus")
+		{
+			menu.GET("/:id", menuHandler.GetByID)
+			menu.PUT("/:id", authMiddleware.RequireRole("cashier"), menuHandler.Update)
+			menu.PATCH("/:id", authMiddleware.RequireRole("cashier"), menuHandler.Patch)
+			menu.DELETE("/:id", authMiddleware.RequireRole("cashier"), menuHandler.Delete)
+		}
+
+		// Order routes
+		orders := protected.Group("/orders")
+		{
+			orders.GET("/", authMiddleware.RequireRole("cashier"), orderHandler.GetAll)
+		}
+
+		// Device management routes
+		devices := protected.Group("/devices")
+		devices.Use(authMiddleware.RequireRole("cashier"))
+		{
+			devices.GET("/", deviceHandler.GetAll)
+			devices.POST("/", deviceHandler.Create)
+			devices.GET("/:id", deviceHandler.GetByID)
+			devices.PUT("/:id", deviceHandler.Update)
+			devices.DELETE("/:id", deviceHandler.Delete)
+
+			// API keys for specific device
+			devices.POST("/:id/keys", deviceHandler.CreateKey)
+			devices.POST("/:id/keys/:key_id/rotate", deviceHandler.RotateKey)
+			devices.DELETE("/:id/keys/:key_id", deviceHandler.RevokeKey)
+		}
+	}
+
+	// Routes shared by staff and registered kios devices (displays, printers)
+	// Queue displays poll their queue, so it has a policy of its own
+	api.GET("/kios/:id/queue", deviceAuthMiddleware.RequireUserOrDevice(models.ScopeQueueRead), queueLimit, orderHandler.GetQueue)
+	api.GET("/orders/:id", deviceAuthMiddleware.RequireUserOrDevice(models.ScopeOrdersRead), apiLimit, orderHandler.GetByID)
+	api.PUT("/orders/:id/status", deviceAuthMiddleware.RequireUserOrDevice(models.ScopeOrdersReady), apiLimit, orderHandler.UpdateStatus)
+
+	// Device-only routes
+	device := api.Group("/device")
+	device.Use(deviceAuthMiddleware.RequireDevice(), apiLimit)
+	{
+		device.GET("/me", deviceHandler.Me)
+	}
+
+	return r
+}
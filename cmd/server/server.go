@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"foodcourt-backend/internal/config"
+)
+
+// newHTTPServer wraps the handler in a server with the configured timeouts
+func newHTTPServer(cfg config.ServerConfig, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              ":" + cfg.Port,
+		Handler:           handler,
+		ReadTimeout:       cfg.ReadTimeout,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+	}
+}
+
+// shutdownPolicy describes how serve stops
+type shutdownPolicy struct {
+	Draining func()        // Called first, e.g. to fail the readiness check
+	Delay    time.Duration // Time load balancers get to notice before new requests are refused
+	Timeout  time.Duration // Time requests in flight get to finish
+}
+
+// serve runs srv on ln until ctx is cancelled. It then calls Draining, keeps
+// serving for Delay, stops accepting requests and waits up to Timeout for the
+// ones in flight to finish. Handlers keep their request context while
+// draining, so running transactions complete. Shutdown does not track
+// hijacked connections such as WebSockets; their owners close them through
+// srv.RegisterOnShutdown.
+func serve(ctx context.Context, srv *http.Server, ln net.Listener, policy shutdownPolicy) error {
+	errs := make(chan error, 1)
+	go func() {
+		errs <- srv.Serve(ln)
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	if policy.Draining != nil {
+		policy.Draining()
+	}
+	if policy.Delay > 0 {
+		slog.Info("Shutting down after a delay", "delay", policy.Delay)
+		time.Sleep(policy.Delay)
+	}
+
+	slog.Info("Shutting down, waiting for requests in flight", "timeout", policy.Timeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), policy.Timeout)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		// Cut off what is left rather than hang the deploy
+		srv.Close()
+		return err
+	}
+	if err := <-errs; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"foodcourt-backend/internal/config"
+	"foodcourt-backend/internal/health"
+)
+
+func TestServeDrainsRequestsOnShutdown(t *testing.T) {
+	started := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(200 * time.Millisecond)
+		io.WriteString(w, "done")
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := newHTTPServer(config.Default().Server, handler)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	draining := make(chan struct{})
+	policy := shutdownPolicy{
+		Draining: func() { close(draining) },
+		Timeout:  5 * time.Second,
+	}
+	go func() { served <- serve(ctx, srv, ln, policy) }()
+
+	type result struct {
+		body string
+		err  error
+	}
+	responses := make(chan result, 1)
+	go func() {
+		resp, err := http.Get("http://" + ln.Addr().String())
+		if err != nil {
+			responses <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		responses <- result{string(body), err}
+	}()
+
+	// Shut down while the request is in flight
+	<-started
+	cancel()
+
+	if r := <-responses; r.err != nil || r.body != "done" {
+		t.Fatalf("expected the request in flight to finish, got %q, %v", r.body, r.err)
+	}
+	if err := <-served; err != nil {
+		t.Fatalf("serve: %v", err)
+	}
+	select {
+	case <-draining:
+	default:
+		t.Fatal("expected draining to be signalled")
+	}
+	if _, err := http.Get("http://" + ln.Addr().String()); err == nil {
+		t.Fatal("expected new requests to be refused after shutdown")
+	}
+}
+
+func TestHealthEndpoints(t *testing.T) {
+	s := newTestServer(t)
+
+	w := s.request(http.MethodGet, "/livez", "", nil)
+	expectStatus(t, w, http.StatusOK)
+
+	var report health.Report
+	w = s.request(http.MethodGet, "/readyz", "", nil)
+	expectStatus(t, w, http.StatusOK)
+	decode(t, w, &report)
+	if report.Status != health.StatusOK || report.Components["database"].Status != health.StatusOK {
+		t.Fatalf("expected a ready database, got %+v", report)
+	}
+
+	// A failing dependency fails readiness but not liveness
+	s.checker.Add("redis", func(ctx context.Context) error { return errors.New("connection refused") })
+	w = s.request(http.MethodGet, "/readyz", "", nil)
+	expectStatus(t, w, http.StatusServiceUnavailable)
+	decode(t, w, &report)
+	if redis := report.Components["redis"]; redis.Status != health.StatusFailing || redis.Error != "connection refused" {
+		t.Fatalf("expected redis to fail, got %+v", report)
+	}
+	expectStatus(t, s.request(http.MethodGet, "/livez", "", nil), http.StatusOK)
+}
+
+func TestNotReadyWhileShuttingDown(t *testing.T) {
+	s := newTestServer(t)
+
+	s.checker.ShutDown()
+	w := s.request(http.MethodGet, "/readyz", "", nil)
+	expectStatus(t, w, http.StatusServiceUnavailable)
+
+	var report health.Report
+	decode(t, w, &report)
+	if report.Status != health.StatusShuttingDown {
+		t.Fatalf("expected shutting down, got %+v", report)
+	}
+}
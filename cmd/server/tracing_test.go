@@ -0,0 +1,97 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"foodcourt-backend/internal/tracing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// recordSpans makes the global tracer provider keep the spans ended during the test
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+
+	recorder := tracetest.NewSpanRecorder()
+	previous, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previous)
+		otel.SetTextMapPropagator(previousPropagator)
+	})
+	return recorder
+}
+
+func TestTracingCoversRequestAndQueries(t *testing.T) {
+	s := newTestServer(t)
+	token := s.login("padang_user")
+	spans := recordSpans(t)
+
+	w := s.request(http.MethodPost, "/api/v1/kios/1/orders", token, gin.H{
+		"items": []gin.H{{"menu_id": 1, "quantity": 1}},
+	})
+	expectStatus(t, w, http.StatusCreated)
+
+	var server sdktrace.ReadOnlySpan
+	var queries []sdktrace.ReadOnlySpan
+	for _, span := range spans.Ended() {
+		switch {
+		case span.SpanKind() == trace.SpanKindServer:
+			server = span
+		case strings.HasPrefix(span.Name(), "db."):
+			queries = append(queries, span)
+		}
+	}
+	if server == nil {
+		t.Fatal("expected a span of the request")
+	}
+	if server.Name() != "POST /api/v1/kios/:id/orders" {
+		t.Errorf("expected the span named after the route, got %q", server.Name())
+	}
+	traceID := server.SpanContext().TraceID().String()
+	if got := w.Header().Get(tracing.TraceIDHeader); got != traceID {
+		t.Errorf("expected trace ID %s in the response, got %q", traceID, got)
+	}
+
+	var inserts int
+	for _, query := range queries {
+		if query.SpanContext().TraceID() != server.SpanContext().TraceID() {
+			t.Errorf("query span %q is not part of the request trace", query.Name())
+		}
+		if query.Name() == "db.create order_items" {
+			inserts++
+		}
+	}
+	if len(queries) == 0 || inserts == 0 {
+		t.Fatalf("expected query spans including the item inserts, got %d queries", len(queries))
+	}
+}
+
+func TestTracingContinuesCallerTrace(t *testing.T) {
+	s := newTestServer(t)
+	spans := recordSpans(t)
+
+	const parent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	req := httptest.NewRequest(http.MethodGet, "/livez", nil)
+	req.Header.Set("traceparent", parent)
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	expectStatus(t, w, http.StatusOK)
+
+	if got := w.Header().Get(tracing.TraceIDHeader); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Fatalf("expected the caller's trace ID, got %q", got)
+	}
+	ended := spans.Ended()
+	if len(ended) != 1 || ended[0].Parent().SpanID().String() != "00f067aa0ba902b7" {
+		t.Fatalf("expected one span continuing the caller's span, got %d", len(ended))
+	}
+}
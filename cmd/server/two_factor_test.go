@@ -0,0 +1,237 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"foodcourt-backend/internal/apierror"
+	"foodcourt-backend/internal/audit"
+	"foodcourt-backend/internal/config"
+	"foodcourt-backend/internal/models"
+	"foodcourt-backend/pkg/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+type loginBody struct {
+	Token                  string   `json:"token"`
+	TwoFactorRequired      bool     `json:"two_factor_required"`
+	TwoFactorSetupRequired bool     `json:"two_factor_setup_required"`
+	ChallengeToken         string   `json:"challenge_token"`
+	RecoveryCodes          []string `json:"recovery_codes"`
+}
+
+// requireTwoFactorForKios makes kios staff enroll before they can log in
+func requireTwoFactorForKios(cfg *config.Config) {
+	cfg.TwoFactor.RequiredRoles = []string{"kios"}
+}
+
+// startLogin logs in with a password and returns the answer, which may be a challenge
+func (s *testServer) startLogin(username string) loginBody {
+	s.t.Helper()
+
+	w := s.request(http.MethodPost, "/api/v1/auth/login", "", gin.H{
+		"username": username,
+		"password": seedPassword,
+	})
+	expectStatus(s.t, w, http.StatusOK)
+
+	var resp loginBody
+	decode(s.t, w, &resp)
+	return resp
+}
+
+// totpCode returns the code of secret for the time step step
+func totpCode(t *testing.T, secret string, step int64) string {
+	t.Helper()
+
+	code, err := auth.GenerateTOTPCode(secret, step)
+	if err != nil {
+		t.Fatalf("generate code: %v", err)
+	}
+	return code
+}
+
+// enrollDuringLogin completes the required two-factor setup of a user and
+// returns the secret and the login that finished it
+func (s *testServer) enrollDuringLogin(username string, step int64) (string, loginBody) {
+	s.t.Helper()
+
+	challenge := s.startLogin(username)
+	if !challenge.TwoFactorSetupRequired || challenge.Token != "" {
+		s.t.Fatalf("expected a setup challenge, got %+v", challenge)
+	}
+
+	w := s.request(http.MethodPost, "/api/v1/auth/2fa/setup", "", gin.H{"challenge_token": challenge.ChallengeToken})
+	expectStatus(s.t, w, http.StatusOK)
+	var enrollment struct {
+		Data struct {
+			Secret string `json:"secret"`
+		} `json:"data"`
+	}
+	decode(s.t, w, &enrollment)
+
+	w = s.request(http.MethodPost, "/api/v1/auth/2fa/setup/confirm", "", gin.H{
+		"challenge_token": challenge.ChallengeToken,
+		"code":            totpCode(s.t, enrollment.Data.Secret, step),
+	})
+	expectStatus(s.t, w, http.StatusOK)
+	var login loginBody
+	decode(s.t, w, &login)
+	if login.Token == "" || len(login.RecoveryCodes) == 0 {
+		s.t.Fatalf("expected a token and recovery codes, got %+v", login)
+	}
+
+	// The challenge is used up once setup is confirmed
+	w = s.request(http.MethodPost, "/api/v1/auth/2fa/setup", "", gin.H{"challenge_token": challenge.ChallengeToken})
+	expectError(s.t, w, http.StatusUnauthorized, apierror.CodeUnauthenticated)
+
+	return enrollment.Data.Secret, login
+}
+
+func TestTwoFactorSetupDuringLogin(t *testing.T) {
+	s := newTestServer(t, requireTwoFactorForKios)
+	step := auth.TOTPCounter(time.Now())
+
+	secret, login := s.enrollDuringLogin("padang_user", step)
+	w := s.request(http.MethodGet, "/api/v1/me", login.Token, nil)
+	expectStatus(t, w, http.StatusOK)
+
+	// The next login asks for a code, and the code of the setup cannot be replayed
+	challenge := s.startLogin("padang_user")
+	if !challenge.TwoFactorRequired || challenge.Token != "" {
+		t.Fatalf("expected a two-factor challenge, got %+v", challenge)
+	}
+	w = s.request(http.MethodPost, "/api/v1/auth/2fa/verify", "", gin.H{
+		"challenge_token": challenge.ChallengeToken,
+		"code":            totpCode(t, secret, step),
+	})
+	expectError(t, w, http.StatusUnauthorized, "invalid_two_factor_code")
+
+	w = s.request(http.MethodPost, "/api/v1/auth/2fa/verify", "", gin.H{
+		"challenge_token": challenge.ChallengeToken,
+		"code":            totpCode(t, secret, step+1),
+	})
+	expectStatus(t, w, http.StatusOK)
+}
+
+func TestTwoFactorRecoveryCodes(t *testing.T) {
+	s := newTestServer(t, requireTwoFactorForKios)
+	step := auth.TOTPCounter(time.Now())
+	secret, login := s.enrollDuringLogin("padang_user", step)
+
+	verify := func(recoveryCode string) *httptest.ResponseRecorder {
+		challenge := s.startLogin("padang_user")
+		return s.request(http.MethodPost, "/api/v1/auth/2fa/verify", "", gin.H{
+			"challenge_token": challenge.ChallengeToken,
+			"recovery_code":   recoveryCode,
+		})
+	}
+
+	// Recovery codes work once, whatever their case
+	w := verify(strings.ToUpper(login.RecoveryCodes[0]))
+	expectStatus(t, w, http.StatusOK)
+	w = verify(login.RecoveryCodes[0])
+	expectError(t, w, http.StatusUnauthorized, "invalid_two_factor_code")
+
+	// Regenerating them invalidates the old ones
+	w = s.request(http.MethodPost, "/api/v1/me/2fa/recovery-codes", login.Token, gin.H{"code": totpCode(t, secret, step+1)})
+	expectStatus(t, w, http.StatusOK)
+	var regenerated struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+	decode(t, w, &regenerated)
+	if len(regenerated.RecoveryCodes) != len(login.RecoveryCodes) {
+		t.Fatalf("expected %d new codes, got %v", len(login.RecoveryCodes), regenerated.RecoveryCodes)
+	}
+
+	w = verify(login.RecoveryCodes[1])
+	expectError(t, w, http.StatusUnauthorized, "invalid_two_factor_code")
+	w = verify(regenerated.RecoveryCodes[0])
+	expectStatus(t, w, http.StatusOK)
+}
+
+func TestVoluntaryTwoFactorEnrollment(t *testing.T) {
+	s := newTestServer(t)
+	token := s.login("cashier")
+
+	w := s.request(http.MethodPost, "/api/v1/me/2fa/enroll", token, nil)
+	expectStatus(t, w, http.StatusOK)
+	var enrollment struct {
+		Data struct {
+			Secret string `json:"secret"`
+		} `json:"data"`
+	}
+	decode(t, w, &enrollment)
+
+	step := auth.TOTPCounter(time.Now())
+	w = s.request(http.MethodPost, "/api/v1/me/2fa/confirm", token, gin.H{"code": totpCode(t, enrollment.Data.Secret, step)})
+	expectStatus(t, w, http.StatusOK)
+
+	// Enrolling again would replace the secret
+	w = s.request(http.MethodPost, "/api/v1/me/2fa/enroll", token, nil)
+	expectError(t, w, http.StatusConflict, apierror.CodeConflict)
+
+	if challenge := s.startLogin("cashier"); !challenge.TwoFactorRequired {
+		t.Fatalf("expected a two-factor challenge, got %+v", challenge)
+	}
+
+	w = s.request(http.MethodPost, "/api/v1/me/2fa/disable", token, gin.H{
+		"password": seedPassword,
+		"code":     totpCode(t, enrollment.Data.Secret, step+1),
+	})
+	expectStatus(t, w, http.StatusOK)
+	if login := s.startLogin("cashier"); login.Token == "" {
+		t.Fatalf("expected a login without a challenge, got %+v", login)
+	}
+}
+
+func TestAdminResetsTwoFactor(t *testing.T) {
+	s := newTestServer(t, requireTwoFactorForKios)
+	s.enrollDuringLogin("padang_user", auth.TOTPCounter(time.Now()))
+
+	w := s.request(http.MethodDelete, "/api/v1/users/2/2fa", s.login("cashier"), nil)
+	expectStatus(t, w, http.StatusOK)
+
+	// A user who lost their device enrolls again
+	if challenge := s.startLogin("padang_user"); !challenge.TwoFactorSetupRequired {
+		t.Fatalf("expected a setup challenge after the reset, got %+v", challenge)
+	}
+}
+
+func TestTwoFactorChecksOfASessionAreThrottled(t *testing.T) {
+	s := newTestServer(t)
+	token := s.login("cashier")
+
+	w := s.request(http.MethodPost, "/api/v1/me/2fa/enroll", token, nil)
+	expectStatus(t, w, http.StatusOK)
+	var enrollment struct {
+		Data struct {
+			Secret string `json:"secret"`
+		} `json:"data"`
+	}
+	decode(t, w, &enrollment)
+	step := auth.TOTPCounter(time.Now())
+	w = s.request(http.MethodPost, "/api/v1/me/2fa/confirm", token, gin.H{"code": totpCode(t, enrollment.Data.Secret, step)})
+	expectStatus(t, w, http.StatusOK)
+
+	// Wrong codes and passwords count like failed logins, whichever route they are tried on
+	w = s.request(http.MethodPost, "/api/v1/me/2fa/recovery-codes", token, gin.H{"code": "000000"})
+	expectError(t, w, http.StatusUnauthorized, "invalid_two_factor_code")
+	w = s.request(http.MethodPost, "/api/v1/me/2fa/disable", token, gin.H{"password": "wrong-password", "code": "000000"})
+	expectError(t, w, http.StatusUnauthorized, "invalid_credentials")
+	w = s.request(http.MethodPost, "/api/v1/me/2fa/disable", token, gin.H{"password": seedPassword, "code": "000000"})
+	expectError(t, w, http.StatusUnauthorized, "invalid_credentials")
+
+	w = s.request(http.MethodPost, "/api/v1/me/2fa/recovery-codes", token, gin.H{"code": totpCode(t, enrollment.Data.Secret, step+1)})
+	expectStatus(t, w, http.StatusTooManyRequests)
+
+	var failures int64
+	s.db.Model(&models.AuditLog{}).Where("action = ? AND actor_username = ?", audit.ActionLoginFailed, "cashier").Count(&failures)
+	if failures != 3 {
+		t.Fatalf("expected 3 failures recorded, got %d", failures)
+	}
+}
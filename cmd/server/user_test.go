@@ -0,0 +1,52 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestListUsers(t *testing.T) {
+	s := newTestServer(t)
+	token := s.login("cashier")
+
+	type userBody struct {
+		Username string `json:"username"`
+		Role     string `json:"role"`
+		KiosID   *uint  `json:"kios_id"`
+	}
+	list := func(path string) ([]userBody, paginationBody) {
+		t.Helper()
+		w := s.request(http.MethodGet, path, token, nil)
+		expectStatus(t, w, http.StatusOK)
+
+		var resp struct {
+			Data       []userBody     `json:"data"`
+			Pagination paginationBody `json:"pagination"`
+		}
+		decode(t, w, &resp)
+		return resp.Data, resp.Pagination
+	}
+
+	users, page := list("/api/v1/users/?sort=username")
+	if page.Total != 3 || users[0].Username != "cashier" || users[1].Username != "mieayam_user" {
+		t.Fatalf("unexpected users %+v", users)
+	}
+
+	users, page = list("/api/v1/users/?role=kios&kios_id=1")
+	if page.Total != 1 || users[0].Username != "padang_user" {
+		t.Fatalf("expected only the kios user of kios 1, got %+v", users)
+	}
+
+	users, _ = list("/api/v1/users/?q=mie")
+	if len(users) != 1 || users[0].Username != "mieayam_user" {
+		t.Fatalf("expected only mieayam_user, got %+v", users)
+	}
+}
+
+func TestListUsersRequiresCashier(t *testing.T) {
+	s := newTestServer(t)
+	token := s.login("padang_user")
+
+	w := s.request(http.MethodGet, "/api/v1/users/", token, nil)
+	expectStatus(t, w, http.StatusForbidden)
+}
@@ -0,0 +1,132 @@
+// Package apierror writes the error responses of the API. Every error has the
+// same envelope with a stable, machine-readable code, a message for humans,
+// the offending fields of invalid requests and the request ID to quote when
+// reporting a problem:
+//
+//	{"error": {"code": "validation_failed", "message": "...",
+//	           "fields": [{"field": "items[0].quantity", "code": "min", "message": "..."}],
+//	           "request_id": "..."}}
+//
+// Clients must branch on codes, never on messages, which may change and are
+// translated to the locale of the request.
+package apierror
+
+import (
+	"net/http"
+	"strconv"
+
+	"foodcourt-backend/internal/i18n"
+	"foodcourt-backend/internal/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Code identifies the kind of an error. Codes are part of the API and must not
+// change once published.
+type Code string
+
+const (
+	CodeInvalidRequest     Code = "invalid_request"     // The body is not valid JSON or otherwise unusable
+	CodeValidationFailed   Code = "validation_failed"   // The body is well-formed but some fields are invalid
+	CodeInvalidParameter   Code = "invalid_parameter"   // A path or query parameter is invalid
+	CodeUnauthenticated    Code = "unauthenticated"     // Credentials are missing, invalid or expired
+	CodeForbidden          Code = "forbidden"           // The caller may not perform the request
+	CodeNotFound           Code = "not_found"           // The resource or route does not exist
+	CodeMethodNotAllowed   Code = "method_not_allowed"  // The route does not support the method
+	CodeConflict           Code = "conflict"            // The request conflicts with the current state
+	CodePreconditionFailed Code = "precondition_failed" // If-Match names a version the resource is no longer at
+	CodeAlreadyExists      Code = "already_exists"      // A unique value is already taken
+	CodeReferenceViolation Code = "reference_violation" // A referenced record is missing, or the record is still referenced
+	CodePayloadTooLarge    Code = "payload_too_large"   // The request body exceeds the size limit
+	CodeTooManyRequests    Code = "too_many_requests"   // The caller must wait before retrying
+	CodeInternal           Code = "internal_error"      // The server failed; retrying may help
+
+	CodeSessionRevoked         Code = "session_revoked"          // The token was issued before a logout or password change
+	CodePasswordChangeRequired Code = "password_change_required" // The password must be changed before anything else
+	CodeAccountLocked          Code = "account_locked"           // Too many failed logins locked the account
+)
+
+// FieldError describes why a single field of the request is invalid. Code is
+// the failed rule, such as required, min or email.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	args    []any
+}
+
+// Error is the error object of a response. Messages are written in English,
+// formatted with their arguments after being translated by Abort.
+type Error struct {
+	Status     int          `json:"-"`
+	Code       Code         `json:"code"`
+	Message    string       `json:"message"`
+	Fields     []FieldError `json:"fields,omitempty"`
+	RetryAfter int          `json:"retry_after,omitempty"` // Seconds, also sent as the Retry-After header
+	RequestID  string       `json:"request_id,omitempty"`
+	args       []any
+}
+
+func (e *Error) Error() string {
+	return string(e.Code) + ": " + i18n.T(i18n.English, e.Message, e.args...)
+}
+
+func New(status int, code Code, message string, fields ...FieldError) *Error {
+	return &Error{Status: status, Code: code, Message: message, Fields: fields}
+}
+
+// Newf is New with a message formatted like fmt.Sprintf
+func Newf(status int, code Code, format string, args ...any) *Error {
+	return &Error{Status: status, Code: code, Message: format, args: args}
+}
+
+// InvalidParameter reports an invalid path or query parameter
+func InvalidParameter(name, format string, args ...any) *Error {
+	e := Newf(http.StatusBadRequest, CodeInvalidParameter, format, args...)
+	e.Fields = []FieldError{{Field: name, Code: "invalid", Message: format, args: args}}
+	return e
+}
+
+func Unauthenticated(message string) *Error {
+	return New(http.StatusUnauthorized, CodeUnauthenticated, message)
+}
+
+func Forbidden(message string) *Error {
+	return New(http.StatusForbidden, CodeForbidden, message)
+}
+
+// PayloadTooLarge reports a request body larger than limit bytes
+func PayloadTooLarge(limit int64) *Error {
+	return Newf(http.StatusRequestEntityTooLarge, CodePayloadTooLarge, "Request body is too large, the limit is %d bytes", limit)
+}
+
+// TooManyRequests asks the caller to wait retryAfter seconds
+func TooManyRequests(retryAfter int) *Error {
+	e := New(http.StatusTooManyRequests, CodeTooManyRequests, "Too many requests, please try again later")
+	e.RetryAfter = retryAfter
+	return e
+}
+
+func Internal(message string) *Error {
+	return New(http.StatusInternalServerError, CodeInternal, message)
+}
+
+// Abort writes err, translated to the locale of the request, as the response
+// and stops the remaining handlers
+func Abort(c *gin.Context, err *Error) {
+	ctx := c.Request.Context()
+	locale := i18n.FromContext(ctx)
+
+	body := *err
+	body.Message = i18n.T(locale, err.Message, err.args...)
+	body.Fields = make([]FieldError, len(err.Fields))
+	for i, field := range err.Fields {
+		field.Message = i18n.T(locale, field.Message, field.args...)
+		body.Fields[i] = field
+	}
+	body.RequestID = logging.RequestID(ctx)
+	if body.RetryAfter > 0 {
+		c.Header("Retry-After", strconv.Itoa(body.RetryAfter))
+	}
+	c.AbortWithStatusJSON(body.Status, gin.H{"error": &body})
+}
@@ -0,0 +1,229 @@
+package apierror
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"foodcourt-backend/internal/loginguard"
+	"foodcourt-backend/internal/repository"
+	"foodcourt-backend/internal/service"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+// From maps the errors of services, repositories and GORM to their response.
+// It returns nil for unexpected errors, which callers answer with Internal
+// after logging them.
+func From(err error) *Error {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+
+	var blocked *loginguard.BlockedError
+	if errors.As(err, &blocked) {
+		e := New(http.StatusTooManyRequests, CodeTooManyRequests, "Too many failed login attempts, please try again later")
+		if blocked.Locked {
+			e = New(http.StatusTooManyRequests, CodeAccountLocked, "Account temporarily locked due to too many failed login attempts")
+		}
+		e.RetryAfter = blocked.RetryAfterSeconds()
+		return e
+	}
+
+	var serviceErr *service.Error
+	if errors.As(err, &serviceErr) {
+		return fromService(serviceErr)
+	}
+
+	switch {
+	case errors.Is(err, repository.ErrNotFound), errors.Is(err, gorm.ErrRecordNotFound):
+		return New(http.StatusNotFound, CodeNotFound, "Resource not found")
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return New(http.StatusConflict, CodeAlreadyExists, "A record with the same unique value already exists")
+	case errors.Is(err, gorm.ErrForeignKeyViolated):
+		return New(http.StatusConflict, CodeReferenceViolation, "The record references a missing record or is still referenced")
+	}
+	return nil
+}
+
+func fromService(err *service.Error) *Error {
+	var e *Error
+	switch err.Kind {
+	case service.KindInvalid:
+		e = Newf(http.StatusBadRequest, CodeInvalidRequest, err.Message, err.Args...)
+	case service.KindUnauthorized:
+		e = Newf(http.StatusUnauthorized, CodeUnauthenticated, err.Message, err.Args...)
+	case service.KindForbidden:
+		e = Newf(http.StatusForbidden, CodeForbidden, err.Message, err.Args...)
+	case service.KindNotFound:
+		e = Newf(http.StatusNotFound, CodeNotFound, err.Message, err.Args...)
+	case service.KindConflict:
+		e = Newf(http.StatusConflict, CodeConflict, err.Message, err.Args...)
+	case service.KindPreconditionFailed:
+		e = Newf(http.StatusPreconditionFailed, CodePreconditionFailed, err.Message, err.Args...)
+	default:
+		e = Newf(http.StatusInternalServerError, CodeInternal, err.Message, err.Args...)
+	}
+
+	if err.Code != "" {
+		e.Code = Code(err.Code)
+	}
+	if err.Field != "" {
+		field := FieldError{Field: err.Field, Code: string(e.Code), Message: err.Message, args: err.Args}
+		if err.Err != nil {
+			field.Message, field.args = err.Err.Error(), nil
+		}
+		e.Fields = []FieldError{field}
+	}
+	return e
+}
+
+// Binding maps the error of binding a request body. Validation failures list
+// every invalid field by its JSON name.
+func Binding(err error) *Error {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		fields := make([]FieldError, len(validationErrs))
+		for i, fe := range validationErrs {
+			message, args := ruleMessage(fe)
+			fields[i] = FieldError{
+				Field:   fieldPath(fe),
+				Code:    fe.Tag(),
+				Message: message,
+				args:    args,
+			}
+		}
+		return New(http.StatusBadRequest, CodeValidationFailed, "Some fields are invalid", fields...)
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return New(http.StatusBadRequest, CodeValidationFailed, "Some fields are invalid", FieldError{
+			Field:   typeErr.Field,
+			Code:    "type",
+			Message: typeMessage(typeErr.Type),
+		})
+	}
+
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return PayloadTooLarge(tooLarge.Limit)
+	}
+	// Decoders that disallow unknown fields fail with an unexported error
+	if name, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		if unquoted, unquoteErr := strconv.Unquote(name); unquoteErr == nil {
+			name = unquoted
+		}
+		return New(http.StatusBadRequest, CodeValidationFailed, "Some fields are invalid", FieldError{
+			Field:   name,
+			Code:    "unknown",
+			Message: "is not a known field",
+		})
+	}
+
+	var syntaxErr *json.SyntaxError
+	switch {
+	case errors.Is(err, io.EOF):
+		return New(http.StatusBadRequest, CodeInvalidRequest, "Request body is required")
+	case errors.As(err, &syntaxErr), errors.Is(err, io.ErrUnexpectedEOF):
+		return New(http.StatusBadRequest, CodeInvalidRequest, "Request body is not valid JSON")
+	}
+	return New(http.StatusBadRequest, CodeInvalidRequest, "Invalid request format")
+}
+
+var registerFieldNames sync.Once
+
+// UseJSONFieldNames makes validation errors name fields by their JSON name, as
+// clients know them. Call it before binding requests.
+func UseJSONFieldNames() {
+	registerFieldNames.Do(func() {
+		v, ok := binding.Validator.Engine().(*validator.Validate)
+		if !ok {
+			return
+		}
+		v.RegisterTagNameFunc(func(field reflect.StructField) string {
+			name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+			if name == "-" {
+				return ""
+			}
+			if name == "" {
+				return field.Name
+			}
+			return name
+		})
+	})
+}
+
+// fieldPath returns the path of the field below the request struct, such as
+// items[0].quantity
+func fieldPath(fe validator.FieldError) string {
+	path := fe.Namespace()
+	if i := strings.IndexByte(path, '.'); i >= 0 {
+		return path[i+1:]
+	}
+	return path
+}
+
+// ruleMessage returns the message of a failed validation rule and its arguments
+func ruleMessage(fe validator.FieldError) (string, []any) {
+	unit := ""
+	switch fe.Kind() {
+	case reflect.String:
+		unit = " characters"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		unit = " items"
+	}
+	param := []any{fe.Param()}
+
+	switch fe.Tag() {
+	case "required", "required_without", "required_if":
+		return "is required", nil
+	case "min", "gte":
+		return "must be at least %s" + unit, param
+	case "max", "lte":
+		return "must be at most %s" + unit, param
+	case "len":
+		return "must be exactly %s" + unit, param
+	case "gt":
+		return "must be greater than %s", param
+	case "lt":
+		return "must be less than %s", param
+	case "oneof":
+		return "must be one of %s", []any{strings.ReplaceAll(fe.Param(), " ", ", ")}
+	case "email":
+		return "must be a valid e-mail address", nil
+	case "url":
+		return "must be a valid URL", nil
+	case "ip":
+		return "must be a valid IP address", nil
+	case "numeric":
+		return "must contain digits only", nil
+	default:
+		return "is invalid", nil
+	}
+}
+
+func typeMessage(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "must be a string"
+	case reflect.Bool:
+		return "must be a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "must be a number"
+	case reflect.Slice, reflect.Array:
+		return "must be a list"
+	default:
+		return "must be an object"
+	}
+}
@@ -0,0 +1,182 @@
+// Package audit records security relevant, administrative and financial actions
+// in an append-only log. Entries for data changes are written in the same
+// transaction as the change itself.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+
+	"foodcourt-backend/internal/models"
+	"foodcourt-backend/internal/requestinfo"
+)
+
+const (
+	ActionLoginSucceeded = "auth.login_succeeded"
+	ActionLoginFailed    = "auth.login_failed"
+	ActionLoginBlocked   = "auth.login_blocked"
+	ActionLoginUnlocked  = "auth.login_unlocked"
+
+	ActionTwoFactorEnabled         = "auth.2fa_enabled"
+	ActionTwoFactorDisabled        = "auth.2fa_disabled"
+	ActionTwoFactorReset           = "auth.2fa_reset"
+	ActionRecoveryCodesRegenerated = "auth.2fa_recovery_codes_regenerated"
+
+	ActionPasswordChanged        = "auth.password_changed"
+	ActionPasswordResetRequested = "auth.password_reset_requested"
+	ActionPasswordReset          = "auth.password_reset"
+
+	ActionUserRegistered = "user.registered"
+	ActionUserUpdated    = "user.updated"
+
+	ActionKiosCreated = "kios.created"
+	ActionKiosUpdated = "kios.updated"
+	ActionKiosDeleted = "kios.deleted"
+
+	ActionMenuCreated = "menu.created"
+	ActionMenuUpdated = "menu.updated"
+	ActionMenuDeleted = "menu.deleted"
+
+	ActionOrderCreated       = "order.created"
+	ActionOrderStatusUpdated = "order.status_updated"
+
+	ActionDeviceCreated    = "device.created"
+	ActionDeviceUpdated    = "device.updated"
+	ActionDeviceDeleted    = "device.deleted"
+	ActionDeviceKeyCreated = "device_key.created"
+	ActionDeviceKeyRotated = "device_key.rotated"
+	ActionDeviceKeyRevoked = "device_key.revoked"
+)
+
+const (
+	EntityUser      = "user"
+	EntityKios      = "kios"
+	EntityMenu      = "menu"
+	EntityOrder     = "order"
+	EntityDevice    = "device"
+	EntityDeviceKey = "device_key"
+)
+
+// Fields that change on every write and would only add noise to diffs
+var ignoredFields = map[string]bool{
+	"updated_at": true,
+}
+
+type Entry struct {
+	Action     string
+	EntityType string
+	EntityID   *uint
+	Metadata   map[string]interface{}
+
+	// Before and After are snapshots of the entity, usually its response DTO.
+	// Only the fields that differ end up in the log.
+	Before interface{}
+	After  interface{}
+
+	// Actor overrides the authenticated user of the request, e.g. for login attempts
+	ActorID       *uint
+	ActorUsername string
+}
+
+// NewLog builds the log record of an entry, taking the actor and client details
+// from the request info of ctx. Store it in the transaction of the change so the
+// entry is only kept if the change commits.
+func NewLog(ctx context.Context, entry Entry) (*models.AuditLog, error) {
+	info := requestinfo.From(ctx)
+	log := models.AuditLog{
+		ActorID:       entry.ActorID,
+		ActorUsername: entry.ActorUsername,
+		ActorDeviceID: info.DeviceID,
+		Action:        entry.Action,
+		EntityType:    entry.EntityType,
+		EntityID:      entry.EntityID,
+		IPAddress:     info.ClientIP,
+		UserAgent:     info.UserAgent,
+		RequestMethod: info.Method,
+		RequestPath:   info.Path,
+	}
+
+	if log.ActorID == nil {
+		log.ActorID = info.UserID
+	}
+	if log.ActorUsername == "" {
+		log.ActorUsername = info.Username
+	}
+
+	if entry.Before != nil || entry.After != nil {
+		changes, err := Diff(entry.Before, entry.After)
+		if err != nil {
+			return nil, err
+		}
+		if len(changes) > 0 {
+			encoded, err := json.Marshal(changes)
+			if err != nil {
+				return nil, err
+			}
+			log.Changes = string(encoded)
+		}
+	}
+
+	if len(entry.Metadata) > 0 {
+		metadata, err := json.Marshal(entry.Metadata)
+		if err != nil {
+			return nil, err
+		}
+		log.Metadata = string(metadata)
+	}
+
+	return &log, nil
+}
+
+// Change is the before and after value of a single field
+type Change struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// Diff compares the JSON representation of two snapshots. A nil before
+// records a creation, a nil after records a deletion.
+func Diff(before, after interface{}) (map[string]Change, error) {
+	beforeFields, err := toFields(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := toFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]Change)
+	for key, from := range beforeFields {
+		if ignoredFields[key] {
+			continue
+		}
+		if to := afterFields[key]; !reflect.DeepEqual(from, to) {
+			changes[key] = Change{From: from, To: to}
+		}
+	}
+	for key, to := range afterFields {
+		if _, ok := beforeFields[key]; !ok && to != nil && !ignoredFields[key] {
+			changes[key] = Change{To: to}
+		}
+	}
+
+	return changes, nil
+}
+
+func toFields(snapshot interface{}) (map[string]interface{}, error) {
+	fields := make(map[string]interface{})
+	if snapshot == nil || (reflect.ValueOf(snapshot).Kind() == reflect.Ptr && reflect.ValueOf(snapshot).IsNil()) {
+		return fields, nil
+	}
+
+	encoded, err := json.Marshal(snapshot)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(encoded, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
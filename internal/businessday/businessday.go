@@ -0,0 +1,64 @@
+// Package businessday maps instants to the business days of the food court.
+// A business day starts at a cutoff after midnight in the food court's time
+// zone, so orders taken after midnight by stalls still open count towards the
+// evening before.
+package businessday
+
+import "time"
+
+// dateLayout is the layout of dates in query parameters and queue numbers
+const dateLayout = "2006-01-02"
+
+// Calendar splits time into business days. The zero Calendar has days from
+// midnight to midnight UTC.
+type Calendar struct {
+	location *time.Location
+	cutoff   time.Duration
+}
+
+// New returns the calendar of business days starting cutoff after midnight in
+// location. A nil location is UTC.
+func New(location *time.Location, cutoff time.Duration) Calendar {
+	return Calendar{location: location, cutoff: cutoff}
+}
+
+// Location returns the time zone of the business days
+func (c Calendar) Location() *time.Location {
+	if c.location == nil {
+		return time.UTC
+	}
+	return c.location
+}
+
+// Date returns the business day t falls on, as midnight of that date in the
+// calendar's time zone
+func (c Calendar) Date(t time.Time) time.Time {
+	local := t.In(c.Location()).Add(-c.cutoff)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, c.Location())
+}
+
+// Start returns when the business day of t started
+func (c Calendar) Start(t time.Time) time.Time {
+	return c.StartOf(c.Date(t))
+}
+
+// StartOf returns when the business day of the calendar date of date starts
+func (c Calendar) StartOf(date time.Time) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, c.Location()).Add(c.cutoff)
+}
+
+// Bounds returns the start of the business day of t and the start of the next
+func (c Calendar) Bounds(t time.Time) (start, end time.Time) {
+	date := c.Date(t)
+	return c.StartOf(date), c.StartOf(date.AddDate(0, 0, 1))
+}
+
+// ParseDate parses a YYYY-MM-DD date and returns when that business day starts
+// and the next one starts
+func (c Calendar) ParseDate(value string) (start, end time.Time, err error) {
+	date, err := time.ParseInLocation(dateLayout, value, c.Location())
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return c.StartOf(date), c.StartOf(date.AddDate(0, 0, 1)), nil
+}
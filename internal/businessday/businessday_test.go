@@ -0,0 +1,58 @@
+package businessday
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCalendar(t *testing.T) {
+	jakarta, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		t.Skipf("time zone database unavailable: %v", err)
+	}
+	calendar := New(jakarta, 4*time.Hour)
+
+	tests := []struct {
+		name  string
+		at    time.Time
+		date  string
+		start time.Time
+	}{
+		{"evening", time.Date(2026, 3, 16, 21, 0, 0, 0, jakarta), "2026-03-16", time.Date(2026, 3, 16, 4, 0, 0, 0, jakarta)},
+		{"after midnight", time.Date(2026, 3, 17, 1, 30, 0, 0, jakarta), "2026-03-16", time.Date(2026, 3, 16, 4, 0, 0, 0, jakarta)},
+		{"at the cutoff", time.Date(2026, 3, 17, 4, 0, 0, 0, jakarta), "2026-03-17", time.Date(2026, 3, 17, 4, 0, 0, 0, jakarta)},
+		// 18:00 UTC is already 01:00 the next day in Jakarta, still before the cutoff
+		{"other time zone", time.Date(2026, 3, 16, 18, 0, 0, 0, time.UTC), "2026-03-16", time.Date(2026, 3, 16, 4, 0, 0, 0, jakarta)},
+		{"next day in UTC", time.Date(2026, 3, 16, 22, 0, 0, 0, time.UTC), "2026-03-17", time.Date(2026, 3, 17, 4, 0, 0, 0, jakarta)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if date := calendar.Date(tt.at).Format(dateLayout); date != tt.date {
+				t.Errorf("expected business date %s, got %s", tt.date, date)
+			}
+			start, end := calendar.Bounds(tt.at)
+			if !start.Equal(tt.start) || !end.Equal(tt.start.AddDate(0, 0, 1)) {
+				t.Errorf("expected the day from %s, got %s to %s", tt.start, start, end)
+			}
+		})
+	}
+
+	start, end, err := calendar.ParseDate("2026-03-16")
+	if err != nil {
+		t.Fatalf("parse date: %v", err)
+	}
+	if !start.Equal(time.Date(2026, 3, 16, 4, 0, 0, 0, jakarta)) || !end.Equal(time.Date(2026, 3, 17, 4, 0, 0, 0, jakarta)) {
+		t.Fatalf("unexpected business day %s to %s", start, end)
+	}
+	if _, _, err := calendar.ParseDate("16/03/2026"); err == nil {
+		t.Fatal("expected an invalid date to fail")
+	}
+}
+
+func TestZeroCalendarIsUTC(t *testing.T) {
+	var calendar Calendar
+	at := time.Date(2026, 3, 16, 23, 59, 0, 0, time.UTC)
+	if start := calendar.Start(at); !start.Equal(time.Date(2026, 3, 16, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected midnight UTC, got %s", start)
+	}
+}
@@ -0,0 +1,118 @@
+// Package cache keeps rendered responses that are expensive to build and
+// rarely change, such as kios listings and menus. Entries belong to a
+// namespace, and invalidating the namespace drops all of them at once by
+// moving it to a new generation. Failures of the store are logged and treated
+// as misses, so a broken cache only makes requests slower.
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"time"
+
+	"foodcourt-backend/internal/logging"
+)
+
+// Namespaces of the cached responses
+const (
+	Kios      = "kios"       // Kios listings, without their figures
+	KiosStats = "kios-stats" // Figures of the kios on listings, by business day
+	Menus     = "menus"      // Menus of kios
+)
+
+// ErrMiss is returned by stores for keys without a live entry
+var ErrMiss = errors.New("cache miss")
+
+// Store keeps entries and the generations of namespaces. Implementations must
+// be safe for concurrent use.
+type Store interface {
+	// Get returns the value of key or ErrMiss
+	Get(ctx context.Context, key string) ([]byte, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Generation returns the current generation of namespace, 0 until it is first invalidated
+	Generation(ctx context.Context, namespace string) (int64, error)
+	// Invalidate moves namespace to its next generation
+	Invalidate(ctx context.Context, namespace string) error
+}
+
+// Cache stores entries for ttl at most. A nil *Cache is disabled: it misses
+// every lookup and stores nothing.
+type Cache struct {
+	store Store
+	ttl   time.Duration
+}
+
+func New(store Store, ttl time.Duration) *Cache {
+	return &Cache{store: store, ttl: ttl}
+}
+
+// Entry is the place of a key in the generation its namespace had when Get
+// looked it up. Values built after a miss are stored in the entry rather than
+// under the key again: if the namespace is invalidated in between, the value
+// may be stale and goes to a generation that is no longer read.
+type Entry struct {
+	cache     *Cache
+	namespace string
+	storeKey  string // Empty when the generation is unknown
+}
+
+// Get returns the value of key in namespace, if it has one, and its entry
+func (c *Cache) Get(ctx context.Context, namespace, key string) ([]byte, Entry, bool) {
+	if c == nil {
+		return nil, Entry{}, false
+	}
+
+	storeKey, err := c.key(ctx, namespace, key)
+	if err != nil {
+		return nil, Entry{}, false
+	}
+	entry := Entry{cache: c, namespace: namespace, storeKey: storeKey}
+	value, err := c.store.Get(ctx, storeKey)
+	if err != nil {
+		if !errors.Is(err, ErrMiss) {
+			logging.FromContext(ctx).WarnContext(ctx, "Cache lookup failed", "namespace", namespace, "error", err)
+		}
+		return nil, entry, false
+	}
+	return value, entry, true
+}
+
+// Set stores value in the entry
+func (e Entry) Set(ctx context.Context, value []byte) {
+	if e.cache == nil || e.storeKey == "" {
+		return
+	}
+
+	if err := e.cache.store.Set(ctx, e.storeKey, value, e.cache.ttl); err != nil {
+		logging.FromContext(ctx).WarnContext(ctx, "Cache update failed", "namespace", e.namespace, "error", err)
+	}
+}
+
+// Invalidate drops the entries of the namespaces
+func (c *Cache) Invalidate(ctx context.Context, namespaces ...string) {
+	if c == nil {
+		return
+	}
+
+	for _, namespace := range namespaces {
+		if err := c.store.Invalidate(ctx, namespace); err != nil {
+			logging.FromContext(ctx).ErrorContext(ctx, "Cache invalidation failed, entries stay until they expire",
+				"namespace", namespace, "error", err)
+		}
+	}
+}
+
+// key returns the store key of key in the current generation of namespace
+func (c *Cache) key(ctx context.Context, namespace, key string) (string, error) {
+	generation, err := c.store.Generation(ctx, namespace)
+	if err != nil {
+		logging.FromContext(ctx).WarnContext(ctx, "Cache generation lookup failed", "namespace", namespace, "error", err)
+		return "", err
+	}
+
+	sum := sha256.Sum256([]byte(key))
+	return namespace + ":" + strconv.FormatInt(generation, 10) + ":" + hex.EncodeToString(sum[:16]), nil
+}
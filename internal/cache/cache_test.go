@@ -0,0 +1,80 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// set stores value under key after looking it up
+func set(ctx context.Context, c *Cache, namespace, key, value string) {
+	_, entry, _ := c.Get(ctx, namespace, key)
+	entry.Set(ctx, []byte(value))
+}
+
+func TestInvalidateDropsTheEntriesOfNamespaces(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	c := New(store, time.Minute)
+
+	set(ctx, c, Kios, "/api/v1/kios", "kios")
+	set(ctx, c, Menus, "/api/v1/kios/1/menus", "menus")
+
+	if value, _, ok := c.Get(ctx, Kios, "/api/v1/kios"); !ok || string(value) != "kios" {
+		t.Fatalf("expected the kios entry, got %q, %v", value, ok)
+	}
+
+	c.Invalidate(ctx, Kios)
+	if _, _, ok := c.Get(ctx, Kios, "/api/v1/kios"); ok {
+		t.Fatal("expected the kios entry to be invalidated")
+	}
+	if _, _, ok := c.Get(ctx, Menus, "/api/v1/kios/1/menus"); !ok {
+		t.Fatal("expected other namespaces to keep their entries")
+	}
+}
+
+func TestValuesReadBeforeAnInvalidationAreNotServed(t *testing.T) {
+	ctx := context.Background()
+	c := New(NewMemoryStore(), time.Minute)
+
+	// A request misses and reads the menus, then a change invalidates them
+	// before the request stores what it read
+	_, entry, ok := c.Get(ctx, Menus, "/api/v1/kios/1/menus")
+	if ok {
+		t.Fatal("expected a miss")
+	}
+	c.Invalidate(ctx, Menus)
+	entry.Set(ctx, []byte("stale menus"))
+
+	if value, _, ok := c.Get(ctx, Menus, "/api/v1/kios/1/menus"); ok {
+		t.Fatalf("expected the stale value to be dropped, got %q", value)
+	}
+}
+
+func TestEntriesExpire(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	now := time.Date(2026, 3, 16, 12, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+	c := New(store, time.Minute)
+
+	set(ctx, c, Menus, "/api/v1/kios/1/menus", "menus")
+	now = now.Add(59 * time.Second)
+	if _, _, ok := c.Get(ctx, Menus, "/api/v1/kios/1/menus"); !ok {
+		t.Fatal("expected the entry before its TTL")
+	}
+	now = now.Add(time.Second)
+	if _, _, ok := c.Get(ctx, Menus, "/api/v1/kios/1/menus"); ok {
+		t.Fatal("expected the entry to expire after its TTL")
+	}
+}
+
+func TestNilCacheIsDisabled(t *testing.T) {
+	var c *Cache
+	_, entry, ok := c.Get(context.Background(), Kios, "key")
+	if ok {
+		t.Fatal("expected a nil cache to miss")
+	}
+	entry.Set(context.Background(), []byte("value"))
+	c.Invalidate(context.Background(), Kios)
+}
@@ -0,0 +1,49 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const redisKeyPrefix = "cache:"
+
+// RedisStore shares entries between server instances through Redis, so an
+// invalidation by one instance applies to all of them
+type RedisStore struct {
+	client *redis.Client
+}
+
+func NewRedisStore(client *redis.Client) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+func (s *RedisStore) Get(ctx context.Context, key string) ([]byte, error) {
+	value, err := s.client.Get(ctx, redisKeyPrefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrMiss
+	}
+	return value, err
+}
+
+func (s *RedisStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return s.client.Set(ctx, redisKeyPrefix+key, value, ttl).Err()
+}
+
+func (s *RedisStore) Generation(ctx context.Context, namespace string) (int64, error) {
+	generation, err := s.client.Get(ctx, generationKey(namespace)).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	return generation, err
+}
+
+func (s *RedisStore) Invalidate(ctx context.Context, namespace string) error {
+	return s.client.Incr(ctx, generationKey(namespace)).Err()
+}
+
+func generationKey(namespace string) string {
+	return redisKeyPrefix + "generation:" + namespace
+}
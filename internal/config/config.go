@@ -1,109 +1,324 @@
 package config
 
 import (
-	"log"
-	"os"
-	"strconv"
-	"strings"
+	"time"
 
-	"github.com/joho/godotenv"
+	"foodcourt-backend/internal/businessday"
+	"foodcourt-backend/internal/models"
 )
 
+// Config is the effective configuration of the server and the tools. Every
+// setting has a default, which a YAML or TOML file, the environment and -set
+// flags override in that order. The config tag names a setting in files and
+// -set flags, the env tag its environment variable.
 type Config struct {
-	Database DatabaseConfig
-	Server   ServerConfig
-	JWT      JWTConfig
-	Redis    RedisConfig
-	CORS     CORSConfig
-	WebSocket WebSocketConfig
+	Database   DatabaseConfig   `config:"database"`
+	Server     ServerConfig     `config:"server"`
+	JWT        JWTConfig        `config:"jwt"`
+	Redis      RedisConfig      `config:"redis"`
+	CORS       CORSConfig       `config:"cors"`
+	LoginGuard LoginGuardConfig `config:"login_guard"`
+	RateLimit  RateLimitConfig  `config:"rate_limit"`
+	Cache      CacheConfig      `config:"cache"`
+	TwoFactor  TwoFactorConfig  `config:"two_factor"`
+	Password   PasswordConfig   `config:"password"`
+	Notifier   NotifierConfig   `config:"notifier"`
+	Admin      AdminConfig      `config:"admin"`
+	Business   BusinessConfig   `config:"business"`
+	Features   FeatureConfig    `config:"features"`
+	Metrics    MetricsConfig    `config:"metrics"`
+	Log        LogConfig        `config:"log"`
+	Tracing    TracingConfig    `config:"tracing"`
+	I18n       I18nConfig       `config:"i18n"`
 }
 
 type DatabaseConfig struct {
-	Host     string
-	Port     string
-	User     string
-	Password string
-	Name     string
-	SSLMode  string
+	Host     string `config:"host" env:"DB_HOST"`
+	Port     string `config:"port" env:"DB_PORT"`
+	User     string `config:"user" env:"DB_USER"`
+	Password string `config:"password" env:"DB_PASSWORD" secret:"true"`
+	Name     string `config:"name" env:"DB_NAME"`
+	SSLMode  string `config:"sslmode" env:"DB_SSLMODE"`
+
+	// Connection pool, zero means unlimited
+	MaxOpenConns    int           `config:"max_open_conns" env:"DB_MAX_OPEN_CONNS"`
+	MaxIdleConns    int           `config:"max_idle_conns" env:"DB_MAX_IDLE_CONNS"`
+	ConnMaxLifetime time.Duration `config:"conn_max_lifetime" env:"DB_CONN_MAX_LIFETIME"`
+	ConnMaxIdleTime time.Duration `config:"conn_max_idle_time" env:"DB_CONN_MAX_IDLE_TIME"`
 }
 
 type ServerConfig struct {
-	Port    string
-	GinMode string
+	Port    string `config:"port" env:"PORT"`
+	GinMode string `config:"gin_mode" env:"GIN_MODE"`
+
+	ReadTimeout       time.Duration `config:"read_timeout" env:"SERVER_READ_TIMEOUT"`               // Whole request including the body
+	ReadHeaderTimeout time.Duration `config:"read_header_timeout" env:"SERVER_READ_HEADER_TIMEOUT"` // Request headers only
+	WriteTimeout      time.Duration `config:"write_timeout" env:"SERVER_WRITE_TIMEOUT"`
+	IdleTimeout       time.Duration `config:"idle_timeout" env:"SERVER_IDLE_TIMEOUT"`         // Keep-alive connections without requests
+	ShutdownDelay     time.Duration `config:"shutdown_delay" env:"SERVER_SHUTDOWN_DELAY"`     // How long to keep serving with a failing readiness check before shutting down
+	ShutdownTimeout   time.Duration `config:"shutdown_timeout" env:"SERVER_SHUTDOWN_TIMEOUT"` // How long requests in flight may take to finish on shutdown
+	MaxBodyBytes      int           `config:"max_body_bytes" env:"SERVER_MAX_BODY_BYTES"`     // Largest request body accepted
+
+	// Addresses or CIDR ranges of reverse proxies whose X-Forwarded-For header
+	// names the client. Without any, the peer address is the client.
+	TrustedProxies []string `config:"trusted_proxies" env:"SERVER_TRUSTED_PROXIES"`
 }
 
 type JWTConfig struct {
-	Secret    string
-	ExpiresIn string
+	Secret    string        `config:"secret" env:"JWT_SECRET" secret:"true"`
+	ExpiresIn time.Duration `config:"expires_in" env:"JWT_EXPIRES_IN"`
 }
 
 type RedisConfig struct {
-	Host     string
-	Port     string
-	Password string
+	Host     string `config:"host" env:"REDIS_HOST"`
+	Port     string `config:"port" env:"REDIS_PORT"`
+	Password string `config:"password" env:"REDIS_PASSWORD" secret:"true"`
+}
+
+// Enabled reports whether a Redis server has been configured
+func (r RedisConfig) Enabled() bool {
+	return r.Host != ""
+}
+
+func (r RedisConfig) Addr() string {
+	return r.Host + ":" + r.Port
 }
 
 type CORSConfig struct {
-	AllowedOrigins []string
+	AllowedOrigins []string `config:"allowed_origins" env:"CORS_ALLOWED_ORIGINS"`
+}
+
+type LoginGuardConfig struct {
+	MaxAttempts     int           `config:"max_attempts" env:"LOGIN_MAX_ATTEMPTS"`       // Failed attempts per username before lockout
+	IPMaxAttempts   int           `config:"ip_max_attempts" env:"LOGIN_IP_MAX_ATTEMPTS"` // Failed attempts per IP before lockout
+	BackoffAfter    int           `config:"backoff_after" env:"LOGIN_BACKOFF_AFTER"`     // Failed attempts before exponential backoff kicks in
+	BackoffBase     time.Duration `config:"backoff_base" env:"LOGIN_BACKOFF_BASE"`       // First backoff delay, doubled on every further failure
+	LockoutDuration time.Duration `config:"lockout_duration" env:"LOGIN_LOCKOUT_DURATION"`
+	Window          time.Duration `config:"window" env:"LOGIN_ATTEMPT_WINDOW"` // Failure counters reset after this long without failures
+}
+
+// RateLimitConfig sets the token buckets of API clients, identified by user,
+// device or IP. Each policy allows bursts of its number of requests, refilled
+// evenly over its window.
+type RateLimitConfig struct {
+	Enabled       bool          `config:"enabled" env:"RATE_LIMIT_ENABLED"`
+	Requests      int           `config:"requests" env:"RATE_LIMIT_REQUESTS"` // Every API route without a policy of its own
+	Window        time.Duration `config:"window" env:"RATE_LIMIT_WINDOW"`
+	AuthRequests  int           `config:"auth_requests" env:"RATE_LIMIT_AUTH_REQUESTS"` // Login, registration, password reset and two-factor routes, per IP
+	AuthWindow    time.Duration `config:"auth_window" env:"RATE_LIMIT_AUTH_WINDOW"`
+	QueueRequests int           `config:"queue_requests" env:"RATE_LIMIT_QUEUE_REQUESTS"` // Queue displays polling GET /kios/:id/queue
+	QueueWindow   time.Duration `config:"queue_window" env:"RATE_LIMIT_QUEUE_WINDOW"`
+}
+
+// CacheConfig sets the cache of kios listings and menus. Entries are dropped
+// when the kios, menus or orders they show change, and after TTL at the latest.
+type CacheConfig struct {
+	Enabled bool          `config:"enabled" env:"CACHE_ENABLED"`
+	TTL     time.Duration `config:"ttl" env:"CACHE_TTL"`
+}
+
+type TwoFactorConfig struct {
+	Issuer           string        `config:"issuer" env:"TOTP_ISSUER"`                       // Shown in authenticator apps
+	RequiredRoles    []string      `config:"required_roles" env:"TWO_FACTOR_REQUIRED_ROLES"` // Roles that must enroll before they can log in
+	ChallengeExpires time.Duration `config:"challenge_expires_in" env:"TWO_FACTOR_CHALLENGE_EXPIRES_IN"`
+}
+
+func (t TwoFactorConfig) IsRequiredFor(role string) bool {
+	for _, r := range t.RequiredRoles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+type PasswordConfig struct {
+	ResetExpiresIn time.Duration `config:"reset_expires_in" env:"PASSWORD_RESET_EXPIRES_IN"`
+	ResetURL       string        `config:"reset_url" env:"PASSWORD_RESET_URL"`           // Frontend page receiving the token, e.g. http://localhost:3000/reset-password
+	ResetCooldown  time.Duration `config:"reset_cooldown" env:"PASSWORD_RESET_COOLDOWN"` // Least time between two reset messages to an account, zero for none
+}
+
+type NotifierConfig struct {
+	Driver   string `config:"driver" env:"NOTIFIER_DRIVER"` // log or file
+	FilePath string `config:"file_path" env:"NOTIFIER_FILE_PATH"`
+}
+
+// AdminConfig names the cashier account created with a random password on first boot
+type AdminConfig struct {
+	Username string `config:"username" env:"INITIAL_ADMIN_USERNAME"`
+	Email    string `config:"email" env:"INITIAL_ADMIN_EMAIL"`
+}
+
+// BusinessConfig describes how the food court runs its business
+type BusinessConfig struct {
+	Timezone    string        `config:"timezone" env:"BUSINESS_TIMEZONE"`     // IANA name such as Asia/Jakarta, or Local
+	DayCutoff   time.Duration `config:"day_cutoff" env:"BUSINESS_DAY_CUTOFF"` // Time after midnight the business day starts, 4h for 04:00
+	TaxRate     float64       `config:"tax_rate" env:"TAX_RATE"`              // Fraction of the price, 0.11 for 11%
+	TaxIncluded bool          `config:"tax_included" env:"TAX_INCLUDED"`      // Whether menu prices already include the tax
+}
+
+// Tax returns how orders are taxed
+func (b BusinessConfig) Tax() models.Tax {
+	return models.Tax{Rate: b.TaxRate, Included: b.TaxIncluded}
+}
+
+// Location returns the time zone of the business. The timezone is checked on
+// load, an invalid one falls back to the local time zone.
+func (b BusinessConfig) Location() *time.Location {
+	location, err := time.LoadLocation(b.Timezone)
+	if err != nil {
+		return time.Local
+	}
+	return location
 }
 
-type WebSocketConfig struct {
-	ReadBufferSize  int
-	WriteBufferSize int
+// Calendar returns the business days of the food court, which queue numbers,
+// date filters and daily figures follow
+func (b BusinessConfig) Calendar() businessday.Calendar {
+	return businessday.New(b.Location(), b.DayCutoff)
 }
 
-func Load() *Config {
-	// Load .env file
-	if err := godotenv.Load(); err != nil {
-		log.Println("No .env file found, using environment variables")
+// FeatureRegistration opens POST /auth/register, where anybody can create an
+// account. Staff accounts are otherwise seeded or created by cashiers.
+const FeatureRegistration = "registration"
+
+// FeatureConfig lists the optional features that have been switched on
+type FeatureConfig struct {
+	Enabled []string `config:"enabled" env:"FEATURES"`
+}
+
+// IsEnabled reports whether the named feature has been switched on
+func (f FeatureConfig) IsEnabled(name string) bool {
+	for _, feature := range f.Enabled {
+		if feature == name {
+			return true
+		}
 	}
+	return false
+}
+
+// MetricsConfig controls the Prometheus endpoint
+type MetricsConfig struct {
+	Enabled bool   `config:"enabled" env:"METRICS_ENABLED"`
+	Path    string `config:"path" env:"METRICS_PATH"`
+	Token   string `config:"token" env:"METRICS_TOKEN" secret:"true"` // Bearer token scrapers must send, none when empty
+}
+
+// LogConfig controls structured logging. An empty level or format picks the
+// one suited to the Gin mode: debug as text in debug mode, info as JSON otherwise.
+type LogConfig struct {
+	Level              string        `config:"level" env:"LOG_LEVEL"`   // debug, info, warn or error
+	Format             string        `config:"format" env:"LOG_FORMAT"` // json or text
+	SlowQueryThreshold time.Duration `config:"slow_query_threshold" env:"LOG_SLOW_QUERY_THRESHOLD"`
+}
+
+// TracingConfig controls OpenTelemetry tracing. Spans are exported over OTLP
+// HTTP to a collector, such as one listening on localhost:4318, or printed to
+// stdout while developing.
+type TracingConfig struct {
+	Exporter    string  `config:"exporter" env:"TRACING_EXPORTER"` // none, stdout or otlp
+	Endpoint    string  `config:"endpoint" env:"TRACING_ENDPOINT"` // host:port of the OTLP collector
+	Insecure    bool    `config:"insecure" env:"TRACING_INSECURE"` // Send to the collector over plain HTTP
+	ServiceName string  `config:"service_name" env:"TRACING_SERVICE_NAME"`
+	SampleRatio float64 `config:"sample_ratio" env:"TRACING_SAMPLE_RATIO"` // Fraction of new traces recorded
+}
+
+// I18nConfig controls the language of responses. Requests pick one with the
+// Accept-Language header; DefaultLocale serves those that do not.
+type I18nConfig struct {
+	DefaultLocale string `config:"default_locale" env:"DEFAULT_LOCALE"` // en or id
+}
 
-	config := &Config{
+// Default returns the configuration used when nothing overrides it
+func Default() *Config {
+	return &Config{
 		Database: DatabaseConfig{
-			Host:     getEnv("DB_HOST", "localhost"),
-			Port:     getEnv("DB_PORT", "5432"),
-			User:     getEnv("DB_USER", "foodcourt_user"),
-			Password: getEnv("DB_PASSWORD", "foodcourt_password"),
-			Name:     getEnv("DB_NAME", "foodcourt_db"),
-			SSLMode:  getEnv("DB_SSLMODE", "disable"),
+			Host:            "localhost",
+			Port:            "5432",
+			User:            "foodcourt_user",
+			Password:        "foodcourt_password",
+			Name:            "foodcourt_db",
+			SSLMode:         "disable",
+			MaxOpenConns:    25,
+			MaxIdleConns:    5,
+			ConnMaxLifetime: 30 * time.Minute,
+			ConnMaxIdleTime: 5 * time.Minute,
 		},
 		Server: ServerConfig{
-			Port:    getEnv("PORT", "8080"),
-			GinMode: getEnv("GIN_MODE", "debug"),
+			Port:              "8080",
+			GinMode:           "debug",
+			ReadTimeout:       15 * time.Second,
+			ReadHeaderTimeout: 5 * time.Second,
+			WriteTimeout:      30 * time.Second,
+			IdleTimeout:       60 * time.Second,
+			ShutdownTimeout:   20 * time.Second,
+			MaxBodyBytes:      1 << 20,
 		},
 		JWT: JWTConfig{
-			Secret:    getEnv("JWT_SECRET", "default-secret-change-this"),
-			ExpiresIn: getEnv("JWT_EXPIRES_IN", "24h"),
+			ExpiresIn: 24 * time.Hour,
 		},
 		Redis: RedisConfig{
-			Host:     getEnv("REDIS_HOST", "localhost"),
-			Port:     getEnv("REDIS_PORT", "6379"),
-			Password: getEnv("REDIS_PASSWORD", ""),
+			Port: "6379",
 		},
 		CORS: CORSConfig{
-			AllowedOrigins: strings.Split(getEnv("CORS_ALLOWED_ORIGINS", "http://localhost:3000"), ","),
+			AllowedOrigins: []string{"http://localhost:3000"},
 		},
-		WebSocket: WebSocketConfig{
-			ReadBufferSize:  getEnvAsInt("WS_READ_BUFFER_SIZE", 1024),
-			WriteBufferSize: getEnvAsInt("WS_WRITE_BUFFER_SIZE", 1024),
+		LoginGuard: LoginGuardConfig{
+			MaxAttempts:     10,
+			IPMaxAttempts:   50,
+			BackoffAfter:    3,
+			BackoffBase:     time.Second,
+			LockoutDuration: 15 * time.Minute,
+			Window:          15 * time.Minute,
+		},
+		RateLimit: RateLimitConfig{
+			Enabled:       true,
+			Requests:      300,
+			Window:        time.Minute,
+			AuthRequests:  20,
+			AuthWindow:    time.Minute,
+			QueueRequests: 60,
+			QueueWindow:   time.Minute,
+		},
+		Cache: CacheConfig{
+			Enabled: true,
+			TTL:     5 * time.Minute,
+		},
+		TwoFactor: TwoFactorConfig{
+			Issuer:           "Food Court",
+			ChallengeExpires: 5 * time.Minute,
+		},
+		Password: PasswordConfig{
+			ResetExpiresIn: time.Hour,
+			ResetCooldown:  time.Minute,
+		},
+		Notifier: NotifierConfig{
+			Driver:   "log",
+			FilePath: "notifications.log",
+		},
+		Admin: AdminConfig{
+			Username: "admin",
+			Email:    "admin@foodcourt.local",
+		},
+		Business: BusinessConfig{
+			Timezone: "Local",
+		},
+		Metrics: MetricsConfig{
+			Enabled: true,
+			Path:    "/metrics",
+		},
+		Log: LogConfig{
+			SlowQueryThreshold: 200 * time.Millisecond,
+		},
+		Tracing: TracingConfig{
+			Exporter:    "none",
+			Endpoint:    "localhost:4318",
+			Insecure:    true,
+			ServiceName: "foodcourt-backend",
+			SampleRatio: 1,
+		},
+		I18n: I18nConfig{
+			DefaultLocale: "en",
 		},
 	}
-
-	return config
-}
-
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
-	}
-	return defaultValue
-}
-
-func getEnvAsInt(key string, defaultValue int) int {
-	if value := os.Getenv(key); value != "" {
-		if intValue, err := strconv.Atoi(value); err == nil {
-			return intValue
-		}
-	}
-	return defaultValue
 }
@@ -0,0 +1,281 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Options selects the configuration file and the command line overrides
+type Options struct {
+	File      string   // YAML or TOML file, defaults to the CONFIG_FILE environment variable
+	Overrides []string // section.key=value settings, applied after the environment
+}
+
+// RegisterFlags adds the -config and repeatable -set flags to fs
+func (o *Options) RegisterFlags(fs *flag.FlagSet) {
+	fs.StringVar(&o.File, "config", "", "YAML or TOML configuration file (default $CONFIG_FILE)")
+	fs.Func("set", "override a setting as section.key=value, may be repeated", func(value string) error {
+		o.Overrides = append(o.Overrides, value)
+		return nil
+	})
+}
+
+// Error lists every problem found while loading the configuration
+type Error struct {
+	Problems []string
+}
+
+func (e *Error) Error() string {
+	return "invalid configuration:\n  " + strings.Join(e.Problems, "\n  ")
+}
+
+// Load builds the configuration from the defaults, the configuration file,
+// the environment (including a .env file) and the overrides, each layer
+// taking precedence over the ones before it. Malformed values, unknown
+// settings and invalid combinations are all reported in one *Error.
+func Load(opts Options) (*Config, error) {
+	// Load .env file
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using environment variables")
+	}
+
+	cfg := Default()
+	var problems []string
+
+	file := opts.File
+	if file == "" {
+		file = os.Getenv("CONFIG_FILE")
+	}
+	if file != "" {
+		if err := cfg.loadFile(file); err != nil {
+			var cfgErr *Error
+			if !errors.As(err, &cfgErr) {
+				return nil, err
+			}
+			problems = append(problems, cfgErr.Problems...)
+		}
+	}
+
+	for _, s := range cfg.settings() {
+		if s.env == "" {
+			continue
+		}
+		value := os.Getenv(s.env)
+		if value == "" {
+			continue
+		}
+		if err := s.set(value); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", s.env, err))
+		}
+	}
+
+	for _, override := range opts.Overrides {
+		if err := cfg.Set(override); err != nil {
+			problems = append(problems, fmt.Sprintf("-set %s: %v", override, err))
+		}
+	}
+
+	if len(problems) > 0 {
+		return nil, &Error{Problems: problems}
+	}
+	if err := cfg.check(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Set applies a section.key=value setting
+func (c *Config) Set(setting string) error {
+	key, value, ok := strings.Cut(setting, "=")
+	if !ok {
+		return errors.New("expected section.key=value")
+	}
+	s, ok := c.setting(strings.TrimSpace(key))
+	if !ok {
+		return fmt.Errorf("unknown setting %q", key)
+	}
+	return s.set(value)
+}
+
+// loadFile applies the settings of a YAML or TOML file
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var sections map[string]interface{}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &sections)
+		if errors.Is(err, io.EOF) {
+			err = nil
+		}
+	case ".toml":
+		err = toml.NewDecoder(bytes.NewReader(data)).Decode(&sections)
+	default:
+		return fmt.Errorf("unsupported config file %s, expected .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	var problems []string
+	for _, section := range sortedKeys(sections) {
+		values, ok := sections[section].(map[string]interface{})
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s: %s: expected a section of settings", path, section))
+			continue
+		}
+		for _, key := range sortedKeys(values) {
+			name := section + "." + key
+			s, ok := c.setting(name)
+			if !ok {
+				problems = append(problems, fmt.Sprintf("%s: unknown setting %s", path, name))
+				continue
+			}
+			if err := s.setFile(values[key]); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %s: %v", path, name, err))
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return &Error{Problems: problems}
+	}
+	return nil
+}
+
+// setting is a single configurable field
+type setting struct {
+	name   string // section.key
+	env    string
+	secret bool
+	value  reflect.Value
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// settings returns every setting of c in declaration order
+func (c *Config) settings() []setting {
+	var all []setting
+	root := reflect.ValueOf(c).Elem()
+	for i := 0; i < root.NumField(); i++ {
+		section := root.Type().Field(i)
+		fields := root.Field(i)
+		for j := 0; j < fields.NumField(); j++ {
+			field := fields.Type().Field(j)
+			all = append(all, setting{
+				name:   section.Tag.Get("config") + "." + field.Tag.Get("config"),
+				env:    field.Tag.Get("env"),
+				secret: field.Tag.Get("secret") == "true",
+				value:  fields.Field(j),
+			})
+		}
+	}
+	return all
+}
+
+func (c *Config) setting(name string) (setting, bool) {
+	for _, s := range c.settings() {
+		if s.name == name {
+			return s, true
+		}
+	}
+	return setting{}, false
+}
+
+// set parses a value given as text, lists are comma separated
+func (s setting) set(text string) error {
+	text = strings.TrimSpace(text)
+	v := s.value
+
+	switch {
+	case v.Type() == durationType:
+		d, err := time.ParseDuration(text)
+		if err != nil {
+			return fmt.Errorf("expected a duration such as 30s or 15m, got %q", text)
+		}
+		v.SetInt(int64(d))
+	case v.Kind() == reflect.String:
+		v.SetString(text)
+	case v.Kind() == reflect.Int:
+		n, err := strconv.Atoi(text)
+		if err != nil {
+			return fmt.Errorf("expected an integer, got %q", text)
+		}
+		v.SetInt(int64(n))
+	case v.Kind() == reflect.Float64:
+		f, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return fmt.Errorf("expected a number, got %q", text)
+		}
+		v.SetFloat(f)
+	case v.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(text)
+		if err != nil {
+			return fmt.Errorf("expected true or false, got %q", text)
+		}
+		v.SetBool(b)
+	case v.Kind() == reflect.Slice:
+		var values []string
+		for _, value := range strings.Split(text, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+		v.Set(reflect.ValueOf(values))
+	default:
+		return fmt.Errorf("unsupported setting type %s", v.Type())
+	}
+	return nil
+}
+
+// setFile applies a value decoded from a configuration file
+func (s setting) setFile(value interface{}) error {
+	list, isList := value.([]interface{})
+	switch {
+	case s.value.Kind() == reflect.Slice && isList:
+		var values []string
+		for _, item := range list {
+			values = append(values, fmt.Sprint(item))
+		}
+		s.value.Set(reflect.ValueOf(values))
+		return nil
+	case isList:
+		return errors.New("expected a single value, got a list")
+	case value == nil:
+		return errors.New("expected a value")
+	}
+	if _, isSection := value.(map[string]interface{}); isSection {
+		return errors.New("expected a value, got a section")
+	}
+	// A redacted secret from config print keeps the secret of the other layers
+	if s.secret && value == Redacted {
+		return nil
+	}
+	return s.set(fmt.Sprint(value))
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,193 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadLayers(t *testing.T) {
+	file := writeFile(t, "config.yaml", `
+database:
+  host: db.internal
+  max_open_conns: 40
+  conn_max_lifetime: 1h
+server:
+  port: 9000
+cors:
+  allowed_origins: [https://a.example, https://b.example]
+business:
+  timezone: Asia/Jakarta
+  day_cutoff: 4h
+  tax_rate: 0.11
+`)
+	t.Setenv("CONFIG_FILE", file)
+	t.Setenv("DB_MAX_OPEN_CONNS", "50")
+	t.Setenv("TAX_INCLUDED", "true")
+	t.Setenv("TWO_FACTOR_REQUIRED_ROLES", "cashier, kios")
+	t.Setenv("FEATURES", "registration")
+
+	cfg, err := Load(Options{Overrides: []string{"server.port=9100", "jwt.expires_in=2h"}})
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	checks := []struct {
+		name      string
+		got, want interface{}
+	}{
+		{"default", cfg.Database.Name, "foodcourt_db"},
+		{"file", cfg.Database.Host, "db.internal"},
+		{"file duration", cfg.Database.ConnMaxLifetime, time.Hour},
+		{"file list", cfg.CORS.AllowedOrigins, []string{"https://a.example", "https://b.example"}},
+		{"file float", cfg.Business.TaxRate, 0.11},
+		{"file cutoff", cfg.Business.DayCutoff, 4 * time.Hour},
+		{"env over file", cfg.Database.MaxOpenConns, 50},
+		{"env bool", cfg.Business.TaxIncluded, true},
+		{"env list", cfg.TwoFactor.RequiredRoles, []string{"cashier", "kios"}},
+		{"override over file", cfg.Server.Port, "9100"},
+		{"override duration", cfg.JWT.ExpiresIn, 2 * time.Hour},
+	}
+	for _, check := range checks {
+		if !reflect.DeepEqual(check.got, check.want) {
+			t.Errorf("%s: got %v, want %v", check.name, check.got, check.want)
+		}
+	}
+	if !cfg.Features.IsEnabled(FeatureRegistration) || cfg.Features.IsEnabled("loyalty") {
+		t.Errorf("unexpected features %v", cfg.Features.Enabled)
+	}
+	if tax := cfg.Business.Tax(); tax.Rate != 0.11 || !tax.Included {
+		t.Errorf("unexpected tax %+v", tax)
+	}
+	if cfg.Business.Location().String() != "Asia/Jakarta" {
+		t.Errorf("unexpected location %s", cfg.Business.Location())
+	}
+}
+
+func TestLoadTOML(t *testing.T) {
+	file := writeFile(t, "config.toml", `
+[jwt]
+expires_in = "30m"
+
+[two_factor]
+required_roles = ["cashier"]
+`)
+
+	cfg, err := Load(Options{File: file})
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.JWT.ExpiresIn != 30*time.Minute || !cfg.TwoFactor.IsRequiredFor("cashier") {
+		t.Fatalf("unexpected configuration %+v %+v", cfg.JWT, cfg.TwoFactor)
+	}
+}
+
+func TestLoadReportsEveryProblem(t *testing.T) {
+	file := writeFile(t, "config.yaml", `
+database:
+  pool_size: 10
+business:
+  tax_rate: eleven
+`)
+	t.Setenv("DB_MAX_OPEN_CONNS", "ten")
+	t.Setenv("LOGIN_BACKOFF_BASE", "5")
+
+	_, err := Load(Options{File: file, Overrides: []string{"server.gin_mode"}})
+	var cfgErr *Error
+	if !errors.As(err, &cfgErr) {
+		t.Fatalf("expected a configuration error, got %v", err)
+	}
+	for _, problem := range []string{
+		"unknown setting database.pool_size",
+		"business.tax_rate: expected a number",
+		`DB_MAX_OPEN_CONNS: expected an integer, got "ten"`,
+		"LOGIN_BACKOFF_BASE: expected a duration",
+		"-set server.gin_mode: expected section.key=value",
+	} {
+		if !strings.Contains(err.Error(), problem) {
+			t.Errorf("expected %q in\n%v", problem, err)
+		}
+	}
+}
+
+func TestLoadChecksValues(t *testing.T) {
+	tests := []struct {
+		override string
+		problem  string
+	}{
+		{"server.gin_mode=production", "server.gin_mode must be one of"},
+		{"database.port=0", "database.port must be a port number"},
+		{"database.sslmode=on", "database.sslmode must be one of"},
+		{"database.max_idle_conns=100", "must not exceed database.max_open_conns"},
+		{"jwt.expires_in=0s", "jwt.expires_in must be a positive duration"},
+		{"server.trusted_proxies=proxy.internal", "server.trusted_proxies must list IP addresses"},
+		{"login_guard.max_attempts=0", "login_guard.max_attempts must be at least 1"},
+		{"two_factor.required_roles=manager", "two_factor.required_roles must be one of"},
+		{"features.enabled=loyalty", "features.enabled must be one of"},
+		{"notifier.driver=smtp", "notifier.driver must be one of"},
+		{"business.timezone=Mars/Olympus", "not a known time zone"},
+		{"business.day_cutoff=25h", "business.day_cutoff must be between 0 and 24h"},
+		{"business.tax_rate=11", "business.tax_rate must be a fraction"},
+		{"tracing.exporter=jaeger", "tracing.exporter must be one of"},
+		{"tracing.sample_ratio=2", "tracing.sample_ratio must be between 0 and 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.override, func(t *testing.T) {
+			_, err := Load(Options{Overrides: []string{tt.override}})
+			if err == nil || !strings.Contains(err.Error(), tt.problem) {
+				t.Fatalf("expected an error about %q, got %v", tt.problem, err)
+			}
+		})
+	}
+
+	if _, err := Load(Options{}); err != nil {
+		t.Fatalf("expected the defaults to be valid, got %v", err)
+	}
+}
+
+func TestPrintRedactsSecrets(t *testing.T) {
+	cfg := Default()
+	cfg.JWT.Secret = "x7Gq2mV9pL4sT8wZ1cN6bR3yH5kD0fJa"
+	cfg.Database.Password = "a-real-database-password"
+	cfg.Business.TaxRate = 0.11
+	cfg.TwoFactor.RequiredRoles = []string{"cashier"}
+
+	var out bytes.Buffer
+	if err := cfg.Print(&out); err != nil {
+		t.Fatalf("print: %v", err)
+	}
+	printed := out.String()
+	if strings.Contains(printed, cfg.JWT.Secret) || strings.Contains(printed, cfg.Database.Password) {
+		t.Fatalf("secrets printed:\n%s", printed)
+	}
+	if !strings.Contains(printed, "secret: '"+Redacted+"'") {
+		t.Fatalf("expected the JWT secret redacted:\n%s", printed)
+	}
+
+	// The printed configuration loads back, with the secrets from the environment
+	t.Setenv("JWT_SECRET", cfg.JWT.Secret)
+	t.Setenv("DB_PASSWORD", cfg.Database.Password)
+	reloaded, err := Load(Options{File: writeFile(t, "printed.yaml", printed)})
+	if err != nil {
+		t.Fatalf("load printed configuration: %v", err)
+	}
+	if !reflect.DeepEqual(reloaded, cfg) {
+		t.Fatalf("printed configuration loads as\n%+v\nwant\n%+v", reloaded, cfg)
+	}
+}
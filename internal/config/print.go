@@ -0,0 +1,68 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Redacted replaces secrets that are set in the output of Print
+const Redacted = "[redacted]"
+
+// Print writes the configuration as YAML in the layout of a configuration
+// file, with secrets redacted
+func (c *Config) Print(w io.Writer) error {
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	var section *yaml.Node
+	var current string
+	for _, s := range c.settings() {
+		sectionKey, key, _ := strings.Cut(s.name, ".")
+		if section == nil || sectionKey != current {
+			section = &yaml.Node{Kind: yaml.MappingNode}
+			current = sectionKey
+			root.Content = append(root.Content, scalar(sectionKey), section)
+		}
+
+		// The environment variable of each setting follows as a comment
+		value := s.node()
+		value.LineComment = s.env
+		section.Content = append(section.Content, scalar(key), value)
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(root); err != nil {
+		return fmt.Errorf("failed to print configuration: %w", err)
+	}
+	return encoder.Close()
+}
+
+// node returns the YAML value of the setting
+func (s setting) node() *yaml.Node {
+	v := s.value
+	switch {
+	case s.secret && v.String() != "":
+		return scalar(Redacted)
+	case v.Type() == durationType:
+		return scalar(v.Interface().(fmt.Stringer).String())
+	case v.Kind() == reflect.Slice:
+		list := &yaml.Node{Kind: yaml.SequenceNode, Style: yaml.FlowStyle}
+		for i := 0; i < v.Len(); i++ {
+			list.Content = append(list.Content, scalar(v.Index(i).String()))
+		}
+		return list
+	case v.Kind() == reflect.String:
+		return scalar(v.String())
+	default:
+		node := &yaml.Node{}
+		node.Encode(v.Interface())
+		return node
+	}
+}
+
+func scalar(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
@@ -0,0 +1,233 @@
+package config
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MinJWTSecretLength is the shortest JWT secret accepted in release mode
+const MinJWTSecretLength = 32
+
+// Secrets from examples and earlier defaults, which must never be used in release mode
+var weakSecrets = map[string]bool{
+	"default-secret-change-this": true,
+	"secret":                     true,
+	"changeme":                   true,
+	"change-me":                  true,
+	"your-secret-key":            true,
+	"jwt-secret":                 true,
+	"foodcourt_password":         true,
+	"password":                   true,
+}
+
+// check reports settings that are out of range or do not fit together. Load
+// runs it on every configuration.
+func (c *Config) check() error {
+	var problems []string
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+	oneOf := func(name, value string, allowed ...string) {
+		for _, a := range allowed {
+			if value == a {
+				return
+			}
+		}
+		add("%s must be one of %s, got %q", name, strings.Join(allowed, ", "), value)
+	}
+	port := func(name, value string) {
+		if n, err := strconv.Atoi(value); err != nil || n < 1 || n > 65535 {
+			add("%s must be a port number, got %q", name, value)
+		}
+	}
+	positive := func(name string, d time.Duration) {
+		if d <= 0 {
+			add("%s must be a positive duration, got %s", name, d)
+		}
+	}
+	atLeast := func(name string, n, min int) {
+		if n < min {
+			add("%s must be at least %d, got %d", name, min, n)
+		}
+	}
+
+	db := c.Database
+	if db.Host == "" {
+		add("database.host is required")
+	}
+	port("database.port", db.Port)
+	if db.Name == "" {
+		add("database.name is required")
+	}
+	oneOf("database.sslmode", db.SSLMode, "disable", "allow", "prefer", "require", "verify-ca", "verify-full")
+	atLeast("database.max_open_conns", db.MaxOpenConns, 0)
+	atLeast("database.max_idle_conns", db.MaxIdleConns, 0)
+	if db.MaxOpenConns > 0 && db.MaxIdleConns > db.MaxOpenConns {
+		add("database.max_idle_conns (%d) must not exceed database.max_open_conns (%d)", db.MaxIdleConns, db.MaxOpenConns)
+	}
+	if db.ConnMaxLifetime < 0 {
+		add("database.conn_max_lifetime must not be negative")
+	}
+	if db.ConnMaxIdleTime < 0 {
+		add("database.conn_max_idle_time must not be negative")
+	}
+
+	port("server.port", c.Server.Port)
+	oneOf("server.gin_mode", c.Server.GinMode, gin.DebugMode, gin.ReleaseMode, gin.TestMode)
+	positive("server.read_timeout", c.Server.ReadTimeout)
+	positive("server.read_header_timeout", c.Server.ReadHeaderTimeout)
+	positive("server.write_timeout", c.Server.WriteTimeout)
+	positive("server.idle_timeout", c.Server.IdleTimeout)
+	if c.Server.ShutdownDelay < 0 {
+		add("server.shutdown_delay must not be negative")
+	}
+	positive("server.shutdown_timeout", c.Server.ShutdownTimeout)
+	atLeast("server.max_body_bytes", c.Server.MaxBodyBytes, 1)
+	for _, proxy := range c.Server.TrustedProxies {
+		if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+			add("server.trusted_proxies must list IP addresses or CIDR ranges, got %q", proxy)
+		}
+	}
+
+	positive("jwt.expires_in", c.JWT.ExpiresIn)
+
+	if c.Redis.Enabled() {
+		port("redis.port", c.Redis.Port)
+	}
+
+	if c.RateLimit.Enabled {
+		atLeast("rate_limit.requests", c.RateLimit.Requests, 1)
+		positive("rate_limit.window", c.RateLimit.Window)
+		atLeast("rate_limit.auth_requests", c.RateLimit.AuthRequests, 1)
+		positive("rate_limit.auth_window", c.RateLimit.AuthWindow)
+		atLeast("rate_limit.queue_requests", c.RateLimit.QueueRequests, 1)
+		positive("rate_limit.queue_window", c.RateLimit.QueueWindow)
+	}
+
+	if c.Cache.Enabled {
+		positive("cache.ttl", c.Cache.TTL)
+	}
+
+	atLeast("login_guard.max_attempts", c.LoginGuard.MaxAttempts, 1)
+	atLeast("login_guard.ip_max_attempts", c.LoginGuard.IPMaxAttempts, 1)
+	atLeast("login_guard.backoff_after", c.LoginGuard.BackoffAfter, 0)
+	positive("login_guard.backoff_base", c.LoginGuard.BackoffBase)
+	positive("login_guard.lockout_duration", c.LoginGuard.LockoutDuration)
+	positive("login_guard.window", c.LoginGuard.Window)
+
+	for _, role := range c.TwoFactor.RequiredRoles {
+		oneOf("two_factor.required_roles", role, "cashier", "kios")
+	}
+	positive("two_factor.challenge_expires_in", c.TwoFactor.ChallengeExpires)
+
+	positive("password.reset_expires_in", c.Password.ResetExpiresIn)
+	if c.Password.ResetCooldown < 0 {
+		add("password.reset_cooldown must not be negative")
+	}
+
+	for _, feature := range c.Features.Enabled {
+		oneOf("features.enabled", feature, FeatureRegistration)
+	}
+
+	oneOf("notifier.driver", c.Notifier.Driver, "log", "file")
+	if c.Notifier.Driver == "file" && c.Notifier.FilePath == "" {
+		add("notifier.file_path is required by the file driver")
+	}
+
+	if c.Admin.Username == "" {
+		add("admin.username is required")
+	}
+
+	if _, err := time.LoadLocation(c.Business.Timezone); err != nil {
+		add("business.timezone %q is not a known time zone", c.Business.Timezone)
+	}
+	if c.Business.DayCutoff < 0 || c.Business.DayCutoff >= 24*time.Hour {
+		add("business.day_cutoff must be between 0 and 24h, got %s", c.Business.DayCutoff)
+	}
+	if c.Business.TaxRate < 0 || c.Business.TaxRate >= 1 {
+		add("business.tax_rate must be a fraction between 0 and 1, got %v", c.Business.TaxRate)
+	}
+
+	if c.Log.Level != "" {
+		oneOf("log.level", c.Log.Level, "debug", "info", "warn", "error")
+	}
+	if c.Log.Format != "" {
+		oneOf("log.format", c.Log.Format, "json", "text")
+	}
+	if c.Log.SlowQueryThreshold < 0 {
+		add("log.slow_query_threshold must not be negative")
+	}
+
+	if c.Metrics.Enabled && !strings.HasPrefix(c.Metrics.Path, "/") {
+		add("metrics.path must start with /, got %q", c.Metrics.Path)
+	}
+
+	oneOf("tracing.exporter", c.Tracing.Exporter, "none", "stdout", "otlp")
+	if c.Tracing.Exporter == "otlp" && c.Tracing.Endpoint == "" {
+		add("tracing.endpoint is required with the otlp exporter")
+	}
+	if c.Tracing.Exporter != "none" && c.Tracing.ServiceName == "" {
+		add("tracing.service_name is required")
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		add("tracing.sample_ratio must be between 0 and 1, got %v", c.Tracing.SampleRatio)
+	}
+
+	oneOf("i18n.default_locale", c.I18n.DefaultLocale, "en", "id")
+
+	if len(problems) > 0 {
+		return &Error{Problems: problems}
+	}
+	return nil
+}
+
+// Validate reports settings that are unsafe to run the server with. In
+// release mode missing, default and weak secrets are refused.
+func (c *Config) Validate() error {
+	release := c.Server.GinMode == gin.ReleaseMode
+	var problems []string
+
+	switch {
+	case c.JWT.Secret == "" && release:
+		problems = append(problems, "JWT_SECRET is required")
+	case release && weakSecrets[strings.ToLower(c.JWT.Secret)]:
+		problems = append(problems, "JWT_SECRET is a known default, generate a random one")
+	case release && len(c.JWT.Secret) < MinJWTSecretLength:
+		problems = append(problems, fmt.Sprintf("JWT_SECRET must be at least %d characters long", MinJWTSecretLength))
+	}
+
+	if release {
+		if c.Database.Password == "" || weakSecrets[strings.ToLower(c.Database.Password)] {
+			problems = append(problems, "DB_PASSWORD must be set to a non-default password")
+		}
+		for _, origin := range c.CORS.AllowedOrigins {
+			if origin == "*" {
+				problems = append(problems, "CORS_ALLOWED_ORIGINS must list origins instead of *")
+			}
+		}
+		if c.Notifier.Driver == "log" {
+			problems = append(problems, "NOTIFIER_DRIVER=log writes password reset tokens to the log, choose another driver")
+		}
+	}
+
+	if len(problems) > 0 {
+		return &Error{Problems: problems}
+	}
+	return nil
+}
+
+// GenerateSecret returns a random secret for development setups without one
+func GenerateSecret() string {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		panic(fmt.Sprintf("failed to generate random secret: %v", err))
+	}
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
@@ -0,0 +1,79 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	valid := func(mode string) *Config {
+		return &Config{
+			Server:   ServerConfig{GinMode: mode},
+			JWT:      JWTConfig{Secret: "x7Gq2mV9pL4sT8wZ1cN6bR3yH5kD0fJa"},
+			Database: DatabaseConfig{Password: "a-real-database-password"},
+			CORS:     CORSConfig{AllowedOrigins: []string{"https://foodcourt.example"}},
+			Notifier: NotifierConfig{Driver: "file"},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		config  func() *Config
+		problem string // Empty for a valid configuration
+	}{
+		{"valid release", func() *Config { return valid("release") }, ""},
+		{"debug without secret", func() *Config {
+			c := valid("debug")
+			c.JWT.Secret = ""
+			c.Database.Password = "foodcourt_password"
+			return c
+		}, ""},
+		{"release without secret", func() *Config {
+			c := valid("release")
+			c.JWT.Secret = ""
+			return c
+		}, "JWT_SECRET is required"},
+		{"release with old default secret", func() *Config {
+			c := valid("release")
+			c.JWT.Secret = "default-secret-change-this"
+			return c
+		}, "known default"},
+		{"release with short secret", func() *Config {
+			c := valid("release")
+			c.JWT.Secret = "too-short"
+			return c
+		}, "at least 32 characters"},
+		{"release with default database password", func() *Config {
+			c := valid("release")
+			c.Database.Password = "foodcourt_password"
+			return c
+		}, "DB_PASSWORD"},
+		{"release with any origin", func() *Config {
+			c := valid("release")
+			c.CORS.AllowedOrigins = []string{"*"}
+			return c
+		}, "CORS_ALLOWED_ORIGINS"},
+		{"release with log notifier", func() *Config {
+			c := valid("release")
+			c.Notifier.Driver = "log"
+			return c
+		}, "NOTIFIER_DRIVER"},
+		{"debug with log notifier", func() *Config {
+			c := valid("debug")
+			c.Notifier.Driver = "log"
+			return c
+		}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config().Validate()
+			switch {
+			case tt.problem == "" && err != nil:
+				t.Fatalf("expected a valid configuration, got %v", err)
+			case tt.problem != "" && (err == nil || !strings.Contains(err.Error(), tt.problem)):
+				t.Fatalf("expected an error about %q, got %v", tt.problem, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,409 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"foodcourt-backend/internal/database/migrations"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrPendingMigrations is returned by Check when migrations are not applied yet
+	ErrPendingMigrations = errors.New("pending migrations")
+	// ErrDirtySchema is returned when a migration failed part way and the schema
+	// has to be repaired by hand
+	ErrDirtySchema = errors.New("dirty schema")
+)
+
+// noTransaction marks a migration file whose statements cannot run in a
+// transaction, such as CREATE INDEX CONCURRENTLY. It must be the first line.
+const noTransaction = "-- migrate:no-transaction"
+
+// migrationLock identifies the PostgreSQL advisory lock held while migrations run
+const migrationLock int64 = 0x666f6f64636f7572
+
+var migrationFile = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migration is a versioned schema change with the SQL applying and reverting it
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus is a migration together with its state in the database
+type MigrationStatus struct {
+	Migration
+	Applied   bool
+	Dirty     bool
+	AppliedAt *time.Time
+}
+
+// schemaMigration is a row of the schema_migrations table
+type schemaMigration struct {
+	Version   int64
+	Name      string
+	Dirty     bool
+	AppliedAt time.Time
+}
+
+// LoadMigrations reads the NNNNNN_name.up.sql and NNNNNN_name.down.sql files of
+// fsys, ordered by version
+func LoadMigrations(fsys fs.FS) ([]Migration, error) {
+	files, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, file := range files {
+		match := migrationFile.FindStringSubmatch(path.Base(file))
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name %s", file)
+		}
+
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version %s: %w", file, err)
+		}
+
+		content, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has files named %s and %s", version, migration.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	result := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if strings.TrimSpace(migration.Up) == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up script", migration.Version, migration.Name)
+		}
+		result = append(result, *migration)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Version < result[j].Version
+	})
+
+	return result, nil
+}
+
+// Migrator applies and reverts migrations, recording the applied versions in
+// the schema_migrations table
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+func NewMigrator(db *gorm.DB, migrations []Migration) *Migrator {
+	return &Migrator{db: db, migrations: migrations}
+}
+
+// Migrator returns a migrator for the migrations embedded in the binary
+func (d *Database) Migrator() (*Migrator, error) {
+	list, err := LoadMigrations(migrations.FS)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load migrations: %w", err)
+	}
+	return NewMigrator(d.DB, list), nil
+}
+
+// ensureTable creates schema_migrations. applied_at holds UTC times in a plain
+// timestamp, which both PostgreSQL and SQLite read back as a time.
+func (m *Migrator) ensureTable(ctx context.Context) error {
+	return m.db.WithContext(ctx).Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version bigint PRIMARY KEY,
+		name text NOT NULL,
+		dirty boolean NOT NULL DEFAULT false,
+		applied_at timestamp NOT NULL
+	)`).Error
+}
+
+func (m *Migrator) applied(ctx context.Context) (map[int64]schemaMigration, error) {
+	if err := m.ensureTable(ctx); err != nil {
+		return nil, fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	var rows []schemaMigration
+	if err := m.db.WithContext(ctx).Table("schema_migrations").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+
+	applied := make(map[int64]schemaMigration, len(rows))
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+	return applied, nil
+}
+
+// Status lists every known migration with its state. Versions recorded in the
+// database without a migration file are included with empty scripts.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := MigrationStatus{Migration: migration}
+		if row, ok := applied[migration.Version]; ok {
+			status.Applied = true
+			status.Dirty = row.Dirty
+			status.AppliedAt = &row.AppliedAt
+			delete(applied, migration.Version)
+		}
+		statuses = append(statuses, status)
+	}
+
+	for _, row := range applied {
+		appliedAt := row.AppliedAt
+		statuses = append(statuses, MigrationStatus{
+			Migration: Migration{Version: row.Version, Name: row.Name},
+			Applied:   true,
+			Dirty:     row.Dirty,
+			AppliedAt: &appliedAt,
+		})
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+
+	return statuses, nil
+}
+
+// Check returns ErrDirtySchema or ErrPendingMigrations when the database schema
+// does not match the migrations
+func (m *Migrator) Check(ctx context.Context) error {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	var pending []string
+	for _, status := range statuses {
+		if status.Dirty {
+			return fmt.Errorf("%w: migration %d_%s did not complete", ErrDirtySchema, status.Version, status.Name)
+		}
+		if !status.Applied {
+			pending = append(pending, fmt.Sprintf("%d_%s", status.Version, status.Name))
+		}
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%w: %s", ErrPendingMigrations, strings.Join(pending, ", "))
+	}
+	return nil
+}
+
+// PlanUp returns the migrations Up would apply, in order
+func (m *Migrator) PlanUp(ctx context.Context) ([]Migration, error) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var plan []Migration
+	for _, status := range statuses {
+		if status.Dirty {
+			return nil, fmt.Errorf("%w: migration %d_%s did not complete", ErrDirtySchema, status.Version, status.Name)
+		}
+		if !status.Applied {
+			plan = append(plan, status.Migration)
+		}
+	}
+	return plan, nil
+}
+
+// PlanDown returns the last n applied migrations Down would revert, newest first
+func (m *Migrator) PlanDown(ctx context.Context, n int) ([]Migration, error) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var plan []Migration
+	for i := len(statuses) - 1; i >= 0 && len(plan) < n; i-- {
+		status := statuses[i]
+		if status.Dirty {
+			return nil, fmt.Errorf("%w: migration %d_%s did not complete", ErrDirtySchema, status.Version, status.Name)
+		}
+		if !status.Applied {
+			continue
+		}
+		if status.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s is applied but has no migration file", status.Version, status.Name)
+		}
+		if strings.TrimSpace(status.Down) == "" {
+			return nil, fmt.Errorf("migration %d_%s cannot be reverted, it has no down script", status.Version, status.Name)
+		}
+		plan = append(plan, status.Migration)
+	}
+	return plan, nil
+}
+
+// Up applies all pending migrations and returns them
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+	err := m.withLock(ctx, func() error {
+		plan, err := m.PlanUp(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range plan {
+			if err := m.run(ctx, migration, true); err != nil {
+				return err
+			}
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down reverts the last n applied migrations and returns them
+func (m *Migrator) Down(ctx context.Context, n int) ([]Migration, error) {
+	var reverted []Migration
+	err := m.withLock(ctx, func() error {
+		plan, err := m.PlanDown(ctx, n)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range plan {
+			if err := m.run(ctx, migration, false); err != nil {
+				return err
+			}
+			reverted = append(reverted, migration)
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// withLock runs fn holding the migration lock, so that instances migrating at
+// the same time wait for each other instead of applying a version twice. The
+// plan must be made inside fn, once the other instance is done. Other databases,
+// such as the SQLite of the tests, have no advisory locks and are not locked.
+func (m *Migrator) withLock(ctx context.Context, fn func() error) error {
+	if m.db.Dialector.Name() != "postgres" {
+		return fn()
+	}
+
+	sqlDB, err := m.db.DB()
+	if err != nil {
+		return err
+	}
+	// Advisory locks belong to a session, so lock and unlock on one connection
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to lock migrations: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLock); err != nil {
+		return fmt.Errorf("failed to lock migrations: %w", err)
+	}
+	defer conn.ExecContext(context.WithoutCancel(ctx), "SELECT pg_advisory_unlock($1)", migrationLock)
+
+	return fn()
+}
+
+// Force records version as applied or not and clears its dirty flag. It is used
+// after repairing the schema by hand following a failed migration.
+func (m *Migrator) Force(ctx context.Context, version int64, applied bool) error {
+	if _, err := m.applied(ctx); err != nil {
+		return err
+	}
+
+	db := m.db.WithContext(ctx)
+	if !applied {
+		return db.Exec("DELETE FROM schema_migrations WHERE version = ?", version).Error
+	}
+
+	name := ""
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			name = migration.Name
+		}
+	}
+	if name == "" {
+		return fmt.Errorf("unknown migration version %d", version)
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM schema_migrations WHERE version = ?", version).Error; err != nil {
+			return err
+		}
+		return tx.Exec("INSERT INTO schema_migrations (version, name, dirty, applied_at) VALUES (?, ?, ?, ?)",
+			version, name, false, time.Now().UTC()).Error
+	})
+}
+
+// run applies or reverts a single migration. Its statements and the record in
+// schema_migrations share a transaction, so a failure leaves nothing behind.
+// Migrations that cannot run in a transaction are marked dirty until they
+// complete, so that a failure part way is detected.
+func (m *Migrator) run(ctx context.Context, migration Migration, up bool) error {
+	script, direction := migration.Up, "up"
+	if !up {
+		script, direction = migration.Down, "down"
+	}
+
+	record := func(tx *gorm.DB, dirty bool) error {
+		if err := tx.Exec("DELETE FROM schema_migrations WHERE version = ?", migration.Version).Error; err != nil {
+			return err
+		}
+		if !up && !dirty {
+			return nil
+		}
+		return tx.Exec("INSERT INTO schema_migrations (version, name, dirty, applied_at) VALUES (?, ?, ?, ?)",
+			migration.Version, migration.Name, dirty, time.Now().UTC()).Error
+	}
+
+	db := m.db.WithContext(ctx)
+	var err error
+	if strings.HasPrefix(strings.TrimSpace(script), noTransaction) {
+		if err = record(db, true); err == nil {
+			if err = db.Exec(script).Error; err == nil {
+				err = record(db, false)
+			}
+		}
+	} else {
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec(script).Error; err != nil {
+				return err
+			}
+			return record(tx, false)
+		})
+	}
+	if err != nil {
+		return fmt.Errorf("migration %d_%s %s failed: %w", migration.Version, migration.Name, direction, err)
+	}
+	return nil
+}
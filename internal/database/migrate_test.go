@@ -0,0 +1,344 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"foodcourt-backend/internal/database/migrations"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+)
+
+var testMigrations = fstest.MapFS{
+	"000001_create_notes.up.sql":     {Data: []byte("CREATE TABLE notes (id integer PRIMARY KEY, body text);")},
+	"000001_create_notes.down.sql":   {Data: []byte("DROP TABLE notes;")},
+	"000002_add_note_title.up.sql":   {Data: []byte("ALTER TABLE notes ADD COLUMN title text;\nCREATE INDEX idx_notes_title ON notes (title);")},
+	"000002_add_note_title.down.sql": {Data: []byte("DROP INDEX idx_notes_title;\nALTER TABLE notes DROP COLUMN title;")},
+}
+
+var testDatabases int
+
+func newTestMigrator(t *testing.T, fsys fstest.MapFS) (*Migrator, *gorm.DB) {
+	t.Helper()
+
+	testDatabases++
+	db, err := gorm.Open(sqlite.Open(fmt.Sprintf("file:migrate%d?mode=memory&cache=shared", testDatabases)), &gorm.Config{
+		Logger: logger.Discard,
+	})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("database instance: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	list, err := LoadMigrations(fsys)
+	if err != nil {
+		t.Fatalf("load migrations: %v", err)
+	}
+	return NewMigrator(db, list), db
+}
+
+func TestMigrateUpAndDown(t *testing.T) {
+	ctx := context.Background()
+	m, db := newTestMigrator(t, testMigrations)
+
+	if err := m.Check(ctx); !errors.Is(err, ErrPendingMigrations) {
+		t.Fatalf("expected pending migrations, got %v", err)
+	}
+
+	applied, err := m.Up(ctx)
+	if err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	if len(applied) != 2 || applied[0].Version != 1 || applied[1].Version != 2 {
+		t.Fatalf("expected versions 1 and 2 applied in order, got %+v", applied)
+	}
+	if err := m.Check(ctx); err != nil {
+		t.Fatalf("expected an up to date schema, got %v", err)
+	}
+	if err := db.Exec("INSERT INTO notes (body, title) VALUES ('a', 'b')").Error; err != nil {
+		t.Fatalf("expected the migrated table: %v", err)
+	}
+
+	// Nothing is left to apply
+	if applied, err := m.Up(ctx); err != nil || len(applied) != 0 {
+		t.Fatalf("expected no pending migrations, got %d, %v", len(applied), err)
+	}
+
+	reverted, err := m.Down(ctx, 1)
+	if err != nil {
+		t.Fatalf("down: %v", err)
+	}
+	if len(reverted) != 1 || reverted[0].Version != 2 {
+		t.Fatalf("expected version 2 reverted, got %+v", reverted)
+	}
+	if err := db.Exec("SELECT body FROM notes").Error; err != nil {
+		t.Fatalf("expected the table kept: %v", err)
+	}
+	if err := db.Exec("SELECT title FROM notes").Error; err == nil {
+		t.Fatal("expected the title column removed")
+	}
+
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		t.Fatalf("status: %v", err)
+	}
+	if !statuses[0].Applied || statuses[1].Applied {
+		t.Fatalf("expected only version 1 applied, got %+v", statuses)
+	}
+
+	if _, err := m.Down(ctx, 5); err != nil {
+		t.Fatalf("down: %v", err)
+	}
+	if db.Migrator().HasTable("notes") {
+		t.Fatal("expected the table dropped")
+	}
+}
+
+func TestMigrateFailureRollsBack(t *testing.T) {
+	ctx := context.Background()
+	fsys := fstest.MapFS{
+		"000001_create_notes.up.sql":   testMigrations["000001_create_notes.up.sql"],
+		"000001_create_notes.down.sql": testMigrations["000001_create_notes.down.sql"],
+		"000002_broken.up.sql":         {Data: []byte("ALTER TABLE notes ADD COLUMN title text;\nALTER TABLE missing ADD COLUMN x text;")},
+		"000002_broken.down.sql":       {Data: []byte("ALTER TABLE notes DROP COLUMN title;")},
+	}
+	m, db := newTestMigrator(t, fsys)
+
+	applied, err := m.Up(ctx)
+	if err == nil {
+		t.Fatal("expected the broken migration to fail")
+	}
+	if len(applied) != 1 || applied[0].Version != 1 {
+		t.Fatalf("expected only version 1 applied, got %+v", applied)
+	}
+
+	// The failed migration left neither changes nor a record behind
+	if err := db.Exec("SELECT title FROM notes").Error; err == nil {
+		t.Fatal("expected the partial migration rolled back")
+	}
+	if err := m.Check(ctx); !errors.Is(err, ErrPendingMigrations) {
+		t.Fatalf("expected version 2 still pending, got %v", err)
+	}
+}
+
+func TestMigrateNoTransactionFailureMarksDirty(t *testing.T) {
+	ctx := context.Background()
+	fsys := fstest.MapFS{
+		"000001_broken.up.sql":   {Data: []byte(noTransaction + "\nCREATE TABLE notes (id integer PRIMARY KEY);\nALTER TABLE missing ADD COLUMN x text;")},
+		"000001_broken.down.sql": {Data: []byte("DROP TABLE notes;")},
+	}
+	m, _ := newTestMigrator(t, fsys)
+
+	if _, err := m.Up(ctx); err == nil {
+		t.Fatal("expected the broken migration to fail")
+	}
+	if err := m.Check(ctx); !errors.Is(err, ErrDirtySchema) {
+		t.Fatalf("expected a dirty schema, got %v", err)
+	}
+	if _, err := m.Up(ctx); !errors.Is(err, ErrDirtySchema) {
+		t.Fatalf("expected up to refuse a dirty schema, got %v", err)
+	}
+
+	// After repairing by hand the version is recorded as applied
+	if err := m.Force(ctx, 1, true); err != nil {
+		t.Fatalf("force: %v", err)
+	}
+	if err := m.Check(ctx); err != nil {
+		t.Fatalf("expected an up to date schema, got %v", err)
+	}
+}
+
+func TestLoadMigrationsRejectsInvalidFiles(t *testing.T) {
+	tests := map[string]fstest.MapFS{
+		"bad name":       {"create_notes.up.sql": {Data: []byte("SELECT 1;")}},
+		"missing up":     {"000001_create_notes.down.sql": {Data: []byte("SELECT 1;")}},
+		"mismatch names": {"000001_a.up.sql": {Data: []byte("SELECT 1;")}, "000001_b.down.sql": {Data: []byte("SELECT 1;")}},
+	}
+
+	for name, fsys := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := LoadMigrations(fsys); err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
+
+// TestMigrationsCoverModels guards against model fields without a migration
+func TestMigrationsCoverModels(t *testing.T) {
+	list, err := LoadMigrations(migrations.FS)
+	if err != nil {
+		t.Fatalf("load migrations: %v", err)
+	}
+
+	var scripts strings.Builder
+	for _, migration := range list {
+		scripts.WriteString(migration.Up)
+	}
+	sql := scripts.String()
+
+	for _, model := range schemaModels {
+		s, err := schema.Parse(model, &sync.Map{}, schema.NamingStrategy{})
+		if err != nil {
+			t.Fatalf("parse %T: %v", model, err)
+		}
+		if !strings.Contains(sql, `TABLE IF NOT EXISTS "`+s.Table+`"`) && !strings.Contains(sql, `TABLE "`+s.Table+`"`) {
+			t.Errorf("no migration creates table %s", s.Table)
+			continue
+		}
+		for _, column := range s.DBNames {
+			if !strings.Contains(sql, `"`+column+`"`) {
+				t.Errorf("no migration adds column %s.%s", s.Table, column)
+			}
+		}
+	}
+}
+
+// The models of the first release, whose tables AutoMigrate created before
+// there were versioned migrations
+type (
+	baselineKios struct {
+		ID          uint   `gorm:"primaryKey"`
+		Name        string `gorm:"not null"`
+		Description string
+		Location    string
+		IsActive    bool `gorm:"default:true"`
+		CreatedAt   time.Time
+		UpdatedAt   time.Time
+		DeletedAt   gorm.DeletedAt `gorm:"index"`
+	}
+	baselineUser struct {
+		ID        uint   `gorm:"primaryKey"`
+		Username  string `gorm:"uniqueIndex;not null"`
+		Email     string `gorm:"uniqueIndex;not null"`
+		Password  string `gorm:"not null"`
+		FullName  string `gorm:"not null"`
+		Role      string `gorm:"not null"`
+		IsActive  bool   `gorm:"default:true"`
+		KiosID    *uint  `gorm:"index"`
+		CreatedAt time.Time
+		UpdatedAt time.Time
+		DeletedAt gorm.DeletedAt `gorm:"index"`
+	}
+	baselineMenu struct {
+		ID          uint   `gorm:"primaryKey"`
+		KiosID      uint   `gorm:"not null;index"`
+		Name        string `gorm:"not null"`
+		Description string
+		Price       float64 `gorm:"not null"`
+		Category    string  `gorm:"not null"`
+		ImageURL    string
+		IsAvailable bool `gorm:"default:true"`
+		CreatedAt   time.Time
+		UpdatedAt   time.Time
+		DeletedAt   gorm.DeletedAt `gorm:"index"`
+	}
+	baselineOrder struct {
+		ID            uint   `gorm:"primaryKey"`
+		QueueNumber   string `gorm:"uniqueIndex;not null"`
+		KiosID        uint   `gorm:"not null;index"`
+		CustomerName  string
+		Status        string  `gorm:"default:pending"`
+		TotalAmount   float64 `gorm:"not null"`
+		PaymentMethod *string
+		PaidAt        *time.Time
+		PreparedAt    *time.Time
+		ReadyAt       *time.Time
+		CompletedAt   *time.Time
+		Notes         string
+		CreatedBy     uint `gorm:"not null"`
+		CreatedAt     time.Time
+		UpdatedAt     time.Time
+		DeletedAt     gorm.DeletedAt `gorm:"index"`
+	}
+	baselineOrderItem struct {
+		ID       uint    `gorm:"primaryKey"`
+		OrderID  uint    `gorm:"not null;index"`
+		MenuID   uint    `gorm:"not null;index"`
+		Quantity int     `gorm:"not null"`
+		Price    float64 `gorm:"not null"`
+		Subtotal float64 `gorm:"not null"`
+		Notes    string
+	}
+)
+
+func (baselineKios) TableName() string      { return "kios" }
+func (baselineUser) TableName() string      { return "users" }
+func (baselineMenu) TableName() string      { return "menus" }
+func (baselineOrder) TableName() string     { return "orders" }
+func (baselineOrderItem) TableName() string { return "order_items" }
+
+var addColumnIfMissing = regexp.MustCompile(`^ALTER TABLE "(\w+)" ADD COLUMN IF NOT EXISTS "(\w+)"`)
+
+// execOnSQLite runs the statements of a migration written for PostgreSQL.
+// SQLite has no ADD COLUMN IF NOT EXISTS, so such columns are only added
+// when they are missing, as PostgreSQL would.
+func execOnSQLite(t *testing.T, db *gorm.DB, script string) {
+	t.Helper()
+
+	for _, statement := range strings.Split(script, ";") {
+		var lines []string
+		for _, line := range strings.Split(statement, "\n") {
+			if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "--") {
+				lines = append(lines, line)
+			}
+		}
+		statement = strings.Join(lines, "\n")
+		if statement == "" {
+			continue
+		}
+
+		if match := addColumnIfMissing.FindStringSubmatch(statement); match != nil {
+			if db.Migrator().HasColumn(match[1], match[2]) {
+				continue
+			}
+			statement = strings.Replace(statement, " IF NOT EXISTS", "", 1)
+		}
+		if err := db.Exec(statement).Error; err != nil {
+			t.Fatalf("%s: %v", statement, err)
+		}
+	}
+}
+
+func TestMigrationsUpgradeTheFirstRelease(t *testing.T) {
+	_, db := newTestMigrator(t, testMigrations)
+	if err := db.AutoMigrate(&baselineKios{}, &baselineUser{}, &baselineMenu{}, &baselineOrder{}, &baselineOrderItem{}); err != nil {
+		t.Fatalf("create the first release schema: %v", err)
+	}
+
+	list, err := LoadMigrations(migrations.FS)
+	if err != nil {
+		t.Fatalf("load migrations: %v", err)
+	}
+	for _, migration := range list {
+		execOnSQLite(t, db, migration.Up)
+	}
+
+	// Every column the models read exists after the upgrade
+	for _, model := range schemaModels {
+		s, err := schema.Parse(model, &sync.Map{}, schema.NamingStrategy{})
+		if err != nil {
+			t.Fatalf("parse %T: %v", model, err)
+		}
+		for _, column := range s.DBNames {
+			if !db.Migrator().HasColumn(s.Table, column) {
+				t.Errorf("upgraded database has no column %s.%s", s.Table, column)
+			}
+		}
+	}
+}
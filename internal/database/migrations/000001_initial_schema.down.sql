@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS "password_reset_tokens";
+DROP TABLE IF EXISTS "recovery_codes";
+DROP TABLE IF EXISTS "audit_logs";
+DROP TABLE IF EXISTS "device_api_keys";
+DROP TABLE IF EXISTS "devices";
+DROP TABLE IF EXISTS "order_items";
+DROP TABLE IF EXISTS "orders";
+DROP TABLE IF EXISTS "menus";
+DROP TABLE IF EXISTS "users";
+DROP TABLE IF EXISTS "kios";
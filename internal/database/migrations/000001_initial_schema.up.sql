@@ -0,0 +1,186 @@
+-- Schema as previously created by GORM AutoMigrate. Statements are idempotent so
+-- that databases created before versioned migrations adopt it, including those
+-- created by the first release, whose tables lack the columns added since.
+
+CREATE TABLE IF NOT EXISTS "kios" (
+    "id" bigserial,
+    "name" text NOT NULL,
+    "description" text,
+    "location" text,
+    "is_active" boolean DEFAULT true,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_kios_deleted_at" ON "kios" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "users" (
+    "id" bigserial,
+    "username" text NOT NULL,
+    "email" text NOT NULL,
+    "password" text NOT NULL,
+    "full_name" text NOT NULL,
+    "role" text NOT NULL,
+    "is_active" boolean DEFAULT true,
+    "kios_id" bigint,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "totp_secret" text,
+    "totp_enabled" boolean DEFAULT false,
+    "totp_last_counter" bigint,
+    "token_version" bigint NOT NULL DEFAULT 0,
+    "password_changed_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_kios_users" FOREIGN KEY ("kios_id") REFERENCES "kios"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_users_deleted_at" ON "users" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_users_kios_id" ON "users" ("kios_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_users_email" ON "users" ("email");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_users_username" ON "users" ("username");
+-- Columns added to users since the first release, missing from its tables
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "totp_secret" text;
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "totp_enabled" boolean DEFAULT false;
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "totp_last_counter" bigint;
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "token_version" bigint NOT NULL DEFAULT 0;
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "password_changed_at" timestamptz;
+
+CREATE TABLE IF NOT EXISTS "menus" (
+    "id" bigserial,
+    "kios_id" bigint NOT NULL,
+    "name" text NOT NULL,
+    "description" text,
+    "price" decimal NOT NULL,
+    "category" text NOT NULL,
+    "image_url" text,
+    "is_available" boolean DEFAULT true,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_kios_menus" FOREIGN KEY ("kios_id") REFERENCES "kios"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_menus_deleted_at" ON "menus" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_menus_kios_id" ON "menus" ("kios_id");
+
+CREATE TABLE IF NOT EXISTS "orders" (
+    "id" bigserial,
+    "queue_number" text NOT NULL,
+    "kios_id" bigint NOT NULL,
+    "customer_name" text,
+    "status" text DEFAULT 'pending',
+    "total_amount" decimal NOT NULL,
+    "payment_method" text,
+    "paid_at" timestamptz,
+    "prepared_at" timestamptz,
+    "ready_at" timestamptz,
+    "completed_at" timestamptz,
+    "notes" text,
+    "created_by" bigint NOT NULL,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_orders_creator" FOREIGN KEY ("created_by") REFERENCES "users"("id"),
+    CONSTRAINT "fk_kios_orders" FOREIGN KEY ("kios_id") REFERENCES "kios"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_orders_deleted_at" ON "orders" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_orders_kios_status" ON "orders" ("kios_id", "status");
+CREATE INDEX IF NOT EXISTS "idx_orders_kios_created" ON "orders" ("kios_id", "created_at");
+CREATE INDEX IF NOT EXISTS "idx_orders_kios_id" ON "orders" ("kios_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_orders_queue_number" ON "orders" ("queue_number");
+
+CREATE TABLE IF NOT EXISTS "order_items" (
+    "id" bigserial,
+    "order_id" bigint NOT NULL,
+    "menu_id" bigint NOT NULL,
+    "quantity" bigint NOT NULL,
+    "price" decimal NOT NULL,
+    "subtotal" decimal NOT NULL,
+    "notes" text,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_menus_order_items" FOREIGN KEY ("menu_id") REFERENCES "menus"("id"),
+    CONSTRAINT "fk_orders_order_items" FOREIGN KEY ("order_id") REFERENCES "orders"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_order_items_menu_id" ON "order_items" ("menu_id");
+CREATE INDEX IF NOT EXISTS "idx_order_items_order_id" ON "order_items" ("order_id");
+
+CREATE TABLE IF NOT EXISTS "devices" (
+    "id" bigserial,
+    "kios_id" bigint NOT NULL,
+    "name" text NOT NULL,
+    "type" text NOT NULL,
+    "is_active" boolean DEFAULT true,
+    "last_seen_at" timestamptz,
+    "last_seen_ip" text,
+    "created_by" bigint NOT NULL,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_devices_kios" FOREIGN KEY ("kios_id") REFERENCES "kios"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_devices_deleted_at" ON "devices" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_devices_kios_id" ON "devices" ("kios_id");
+
+CREATE TABLE IF NOT EXISTS "device_api_keys" (
+    "id" bigserial,
+    "device_id" bigint NOT NULL,
+    "prefix" text NOT NULL,
+    "key_hash" text NOT NULL,
+    "scopes" text NOT NULL,
+    "last_used_at" timestamptz,
+    "expires_at" timestamptz,
+    "revoked_at" timestamptz,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_devices_api_keys" FOREIGN KEY ("device_id") REFERENCES "devices"("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_device_api_keys_prefix" ON "device_api_keys" ("prefix");
+CREATE INDEX IF NOT EXISTS "idx_device_api_keys_device_id" ON "device_api_keys" ("device_id");
+
+CREATE TABLE IF NOT EXISTS "audit_logs" (
+    "id" bigserial,
+    "actor_id" bigint,
+    "actor_username" text,
+    "actor_device_id" bigint,
+    "action" text NOT NULL,
+    "entity_type" text,
+    "entity_id" bigint,
+    "changes" text,
+    "metadata" text,
+    "ip_address" text,
+    "user_agent" text,
+    "request_method" text,
+    "request_path" text,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_audit_logs_created_at" ON "audit_logs" ("created_at");
+CREATE INDEX IF NOT EXISTS "idx_audit_entity" ON "audit_logs" ("entity_type", "entity_id");
+CREATE INDEX IF NOT EXISTS "idx_audit_logs_action" ON "audit_logs" ("action");
+CREATE INDEX IF NOT EXISTS "idx_audit_logs_actor_id" ON "audit_logs" ("actor_id");
+
+CREATE TABLE IF NOT EXISTS "recovery_codes" (
+    "id" bigserial,
+    "user_id" bigint NOT NULL,
+    "code_hash" text NOT NULL,
+    "used_at" timestamptz,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_recovery_codes_user_id" ON "recovery_codes" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "password_reset_tokens" (
+    "id" bigserial,
+    "user_id" bigint NOT NULL,
+    "token_hash" text NOT NULL,
+    "expires_at" timestamptz NOT NULL,
+    "used_at" timestamptz,
+    "requested_ip" text,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_password_reset_tokens_token_hash" ON "password_reset_tokens" ("token_hash");
+CREATE INDEX IF NOT EXISTS "idx_password_reset_tokens_user_id" ON "password_reset_tokens" ("user_id");
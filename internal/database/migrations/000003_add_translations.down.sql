@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "menu_translations";
+DROP TABLE IF EXISTS "kios_translations";
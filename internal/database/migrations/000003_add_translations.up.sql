@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS "kios_translations" (
+    "id" bigserial,
+    "kios_id" bigint NOT NULL,
+    "locale" text NOT NULL,
+    "name" text NOT NULL,
+    "description" text,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_kios_translations" FOREIGN KEY ("kios_id") REFERENCES "kios"("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_kios_translations_kios_locale" ON "kios_translations" ("kios_id", "locale");
+
+CREATE TABLE IF NOT EXISTS "menu_translations" (
+    "id" bigserial,
+    "menu_id" bigint NOT NULL,
+    "locale" text NOT NULL,
+    "name" text NOT NULL,
+    "description" text,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_menus_translations" FOREIGN KEY ("menu_id") REFERENCES "menus"("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_menu_translations_menu_locale" ON "menu_translations" ("menu_id", "locale");
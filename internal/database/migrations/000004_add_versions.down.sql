@@ -0,0 +1,3 @@
+ALTER TABLE "orders" DROP COLUMN IF EXISTS "version";
+ALTER TABLE "menus" DROP COLUMN IF EXISTS "version";
+ALTER TABLE "kios" DROP COLUMN IF EXISTS "version";
@@ -0,0 +1,3 @@
+ALTER TABLE "kios" ADD COLUMN IF NOT EXISTS "version" bigint NOT NULL DEFAULT 1;
+ALTER TABLE "menus" ADD COLUMN IF NOT EXISTS "version" bigint NOT NULL DEFAULT 1;
+ALTER TABLE "orders" ADD COLUMN IF NOT EXISTS "version" bigint NOT NULL DEFAULT 1;
@@ -0,0 +1 @@
+ALTER TABLE "orders" ADD COLUMN IF NOT EXISTS "tax_amount" decimal NOT NULL DEFAULT 0;
@@ -0,0 +1,9 @@
+// Package migrations holds the versioned SQL migrations of the database schema.
+// Each version has a NNNNNN_name.up.sql file applying it and a matching
+// .down.sql file reverting it. Create new ones with `go run ./cmd/migrate create NAME`.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
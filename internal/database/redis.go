@@ -0,0 +1,36 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"foodcourt-backend/internal/config"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// NewRedis connects to the configured Redis server. It returns nil when Redis is not configured.
+func NewRedis(cfg *config.Config) (*redis.Client, error) {
+	if !cfg.Redis.Enabled() {
+		return nil, nil
+	}
+
+	client := redis.NewClient(&redis.Options{
+		Addr:     cfg.Redis.Addr(),
+		Password: cfg.Redis.Password,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to ping redis: %w", err)
+	}
+
+	slog.Info("Successfully connected to redis")
+
+	return client, nil
+}
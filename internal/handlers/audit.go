@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"foodcourt-backend/internal/businessday"
+	"foodcourt-backend/internal/models"
+	"foodcourt-backend/internal/repository"
+	"foodcourt-backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultAuditLimit = 50
+	maxAuditLimit     = 200
+)
+
+type AuditHandler struct {
+	auditService service.AuditService
+	calendar     businessday.Calendar
+}
+
+// NewAuditHandler creates the audit log handler. Date filters are business days of calendar.
+func NewAuditHandler(auditService service.AuditService, calendar businessday.Calendar) *AuditHandler {
+	return &AuditHandler{auditService: auditService, calendar: calendar}
+}
+
+func (h *AuditHandler) GetAll(c *gin.Context) {
+	opts, ok := listOptions(c, repository.AuditLogSortFields, defaultAuditLimit, maxAuditLimit)
+	if !ok {
+		return
+	}
+
+	// Filter by actor, action and entity if provided
+	filter := repository.AuditLogFilter{
+		ActorID:    c.Query("actor_id"),
+		Action:     c.Query("action"),
+		EntityType: c.Query("entity_type"),
+		EntityID:   c.Query("entity_id"),
+	}
+
+	// Filter by time range if provided
+	if filter.From, filter.To, ok = timeRange(c, h.calendar); !ok {
+		return
+	}
+
+	logs, total, err := h.auditService.List(requestContext(c), filter, opts)
+	if err != nil {
+		respondError(c, err, "Failed to fetch audit logs")
+		return
+	}
+
+	responses := make([]*models.AuditLogResponse, len(logs))
+	for i, log := range logs {
+		responses[i] = log.ToResponse()
+	}
+
+	respondPage(c, responses, opts, total)
+}
@@ -4,137 +4,109 @@ import (
 	"net/http"
 	"strings"
 
+	"foodcourt-backend/internal/apierror"
 	"foodcourt-backend/internal/models"
-	"foodcourt-backend/pkg/auth"
+	"foodcourt-backend/internal/service"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 )
 
 type AuthHandler struct {
-	db         *gorm.DB
-	jwtService *auth.JWTService
+	authService service.AuthService
 }
 
-func NewAuthHandler(db *gorm.DB, jwtService *auth.JWTService) *AuthHandler {
-	return &AuthHandler{
-		db:         db,
-		jwtService: jwtService,
-	}
+func NewAuthHandler(authService service.AuthService) *AuthHandler {
+	return &AuthHandler{authService: authService}
 }
 
 type LoginResponse struct {
-	Token string               `json:"token"`
-	User  *models.UserResponse `json:"user"`
+	Token string               `json:"token,omitempty"`
+	User  *models.UserResponse `json:"user,omitempty"`
+
+	// Set instead of Token when a second factor is needed to finish the login
+	TwoFactorRequired      bool   `json:"two_factor_required,omitempty"`
+	TwoFactorSetupRequired bool   `json:"two_factor_setup_required,omitempty"`
+	ChallengeToken         string `json:"challenge_token,omitempty"`
+
+	// Only returned once, right after two-factor enrollment
+	RecoveryCodes []string `json:"recovery_codes,omitempty"`
+
+	// The token only allows changing the password until it is changed
+	PasswordChangeRequired bool `json:"password_change_required,omitempty"`
+}
+
+func newLoginResponse(result *service.LoginResult) LoginResponse {
+	response := LoginResponse{
+		Token:                  result.Token,
+		TwoFactorRequired:      result.TwoFactorRequired,
+		TwoFactorSetupRequired: result.TwoFactorSetupRequired,
+		ChallengeToken:         result.ChallengeToken,
+		RecoveryCodes:          result.RecoveryCodes,
+	}
+	if result.User != nil {
+		response.User = result.User.ToResponse()
+		response.PasswordChangeRequired = result.Token != "" && result.User.MustChangePassword
+	}
+	return response
 }
 
 func (h *AuthHandler) Login(c *gin.Context) {
 	var req models.LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request format",
-			"details": err.Error(),
-		})
+		respondInvalidBody(c, err)
 		return
 	}
 
-	// Find user by username
-	var user models.User
-	if err := h.db.Preload("Kios").Where("username = ? AND is_active = ?", req.Username, true).First(&user).Error; err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "Invalid credentials",
-		})
+	result, err := h.authService.Login(requestContext(c), req.Username, req.Password)
+	if err != nil {
+		respondError(c, err, "Failed to log in")
 		return
 	}
 
-	// Check password
-	if err := auth.CheckPassword(user.Password, req.Password); err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "Invalid credentials",
-		})
+	c.JSON(http.StatusOK, newLoginResponse(result))
+}
+
+// Unlock clears failed login counters of a username and/or IP address
+func (h *AuthHandler) Unlock(c *gin.Context) {
+	var req models.UnlockLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondInvalidBody(c, err)
 		return
 	}
 
-	// Generate JWT token
-	token, err := h.jwtService.GenerateToken(&user)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to generate token",
-		})
+	if err := h.authService.Unlock(requestContext(c), req); err != nil {
+		respondError(c, err, "Failed to unlock login")
 		return
 	}
 
-	c.JSON(http.StatusOK, LoginResponse{
-		Token: token,
-		User:  user.ToResponse(),
+	c.JSON(http.StatusOK, gin.H{
+		"message": translate(c, "Login unlocked successfully"),
 	})
 }
 
 func (h *AuthHandler) Register(c *gin.Context) {
 	var req models.RegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request format",
-			"details": err.Error(),
-		})
-		return
-	}
-
-	// Check if username or email already exists
-	var existingUser models.User
-	if err := h.db.Where("username = ? OR email = ?", req.Username, req.Email).First(&existingUser).Error; err == nil {
-		c.JSON(http.StatusConflict, gin.H{
-			"error": "Username or email already exists",
-		})
+		respondInvalidBody(c, err)
 		return
 	}
 
-	// Hash password
-	hashedPassword, err := auth.HashPassword(req.Password)
+	user, err := h.authService.Register(requestContext(c), req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to hash password",
-		})
+		respondError(c, err, "Failed to create user")
 		return
 	}
 
-	// Create user
-	user := models.User{
-		Username: req.Username,
-		Email:    req.Email,
-		Password: hashedPassword,
-		FullName: req.FullName,
-		Role:     req.Role,
-		KiosID:   req.KiosID,
-		IsActive: true,
-	}
-
-	if err := h.db.Create(&user).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to create user",
-		})
-		return
-	}
-
-	// Load kios if exists
-	if user.KiosID != nil {
-		h.db.Preload("Kios").First(&user, user.ID)
-	}
-
 	c.JSON(http.StatusCreated, gin.H{
-		"message": "User created successfully",
+		"message": translate(c, "User created successfully"),
 		"user":    user.ToResponse(),
 	})
 }
 
 func (h *AuthHandler) Me(c *gin.Context) {
-	userID, _ := c.Get("user_id")
-
-	var user models.User
-	if err := h.db.Preload("Kios").First(&user, userID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": "User not found",
-		})
+	user, err := h.authService.Me(requestContext(c), currentUserID(c))
+	if err != nil {
+		respondError(c, err, "Failed to fetch user")
 		return
 	}
 
@@ -146,26 +118,20 @@ func (h *AuthHandler) Me(c *gin.Context) {
 func (h *AuthHandler) RefreshToken(c *gin.Context) {
 	authHeader := c.GetHeader("Authorization")
 	if authHeader == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Authorization header required",
-		})
+		apierror.Abort(c, apierror.New(http.StatusBadRequest, apierror.CodeInvalidRequest, "Authorization header required"))
 		return
 	}
 
 	// Extract token
 	tokenParts := strings.Split(authHeader, " ")
 	if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid authorization header format",
-		})
+		apierror.Abort(c, apierror.New(http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid authorization header format"))
 		return
 	}
 
-	newToken, err := h.jwtService.RefreshToken(tokenParts[1])
+	newToken, err := h.authService.RefreshToken(requestContext(c), tokenParts[1])
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "Failed to refresh token",
-		})
+		respondError(c, err, "Failed to generate token")
 		return
 	}
 
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"foodcourt-backend/internal/cache"
+	"foodcourt-backend/internal/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+// cacheKey identifies the response of a GET request by its path, query and
+// locale. Cached responses must not depend on who asks.
+func cacheKey(c *gin.Context) string {
+	return c.Request.URL.Path + "?" + c.Request.URL.Query().Encode() + "#" + locale(c)
+}
+
+// serveCached answers the request with its entry in namespace, if there is
+// one. Otherwise the response must be stored in the returned entry, which was
+// looked up before the data of the response is read.
+func serveCached(c *gin.Context, responses *cache.Cache, namespace string) (cache.Entry, bool) {
+	body, entry, ok := responses.Get(requestContext(c), namespace, cacheKey(c))
+	if !ok {
+		return entry, false
+	}
+	respondWithETag(c, body)
+	return entry, true
+}
+
+// respondCached writes body as JSON with an ETag and stores it in entry
+func respondCached(c *gin.Context, entry cache.Entry, body interface{}) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		respondError(c, err, "Failed to encode response")
+		return
+	}
+
+	entry.Set(requestContext(c), data)
+	respondWithETag(c, data)
+}
+
+// respondTagged writes body as JSON with an ETag, without caching it
+func respondTagged(c *gin.Context, body interface{}) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		respondError(c, err, "Failed to encode response")
+		return
+	}
+	respondWithETag(c, data)
+}
+
+// lookupCached decodes the value of key in namespace into v, if there is one.
+// Otherwise the value must be stored in the returned entry with storeCached,
+// once it has been read.
+func lookupCached(c *gin.Context, responses *cache.Cache, namespace, key string, v interface{}) (cache.Entry, bool) {
+	data, entry, ok := responses.Get(requestContext(c), namespace, key)
+	if !ok {
+		return entry, false
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		logging.FromContext(requestContext(c)).WarnContext(requestContext(c), "Cached value is unreadable",
+			"namespace", namespace, "error", err)
+		return entry, false
+	}
+	return entry, true
+}
+
+// storeCached stores v as JSON in entry
+func storeCached(c *gin.Context, entry cache.Entry, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		logging.FromContext(requestContext(c)).WarnContext(requestContext(c), "Cached value cannot be encoded", "error", err)
+		return
+	}
+	entry.Set(requestContext(c), data)
+}
+
+// respondWithETag writes a JSON body tagged with its hash, or 304 Not Modified
+// without a body when If-None-Match names the same tag. Clients must
+// revalidate before reusing their copy.
+func respondWithETag(c *gin.Context, body []byte) {
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "private, no-cache")
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", body)
+}
+
+// etagMatches reports whether an If-None-Match header names etag. Weak tags
+// match their strong counterparts, as RFC 9110 asks for GET requests.
+func etagMatches(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
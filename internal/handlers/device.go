@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"foodcourt-backend/internal/models"
+	"foodcourt-backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DeviceHandler struct {
+	deviceService service.DeviceService
+}
+
+func NewDeviceHandler(deviceService service.DeviceService) *DeviceHandler {
+	return &DeviceHandler{deviceService: deviceService}
+}
+
+func (h *DeviceHandler) GetAll(c *gin.Context) {
+	// Filter by kios if provided
+	kiosID, ok := uintQuery(c, "kios_id", "Invalid kios ID")
+	if !ok {
+		return
+	}
+
+	devices, err := h.deviceService.List(requestContext(c), kiosID)
+	if err != nil {
+		respondError(c, err, "Failed to fetch devices")
+		return
+	}
+
+	responses := make([]*models.DeviceResponse, len(devices))
+	for i, device := range devices {
+		responses[i] = device.ToResponse()
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": responses,
+	})
+}
+
+func (h *DeviceHandler) GetByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		respondInvalidParam(c, "id", "Invalid device ID")
+		return
+	}
+
+	device, err := h.deviceService.Get(requestContext(c), uint(id))
+	if err != nil {
+		respondError(c, err, "Failed to fetch device")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": device.ToResponse(),
+	})
+}
+
+// Me returns the device identified by the device key of the current request
+func (h *DeviceHandler) Me(c *gin.Context) {
+	deviceID, _ := c.Get("device_id")
+
+	device, err := h.deviceService.Get(requestContext(c), deviceID.(uint))
+	if err != nil {
+		respondError(c, err, "Failed to fetch device")
+		return
+	}
+
+	// Devices do not get to see the keys of the device
+	device.APIKeys = nil
+	scopes, _ := c.Get("device_scopes")
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":   device.ToResponse(),
+		"scopes": scopes,
+	})
+}
+
+func (h *DeviceHandler) Create(c *gin.Context) {
+	var req models.CreateDeviceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondInvalidBody(c, err)
+		return
+	}
+
+	device, err := h.deviceService.Create(requestContext(c), req)
+	if err != nil {
+		respondError(c, err, "Failed to create device")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": translate(c, "Device created successfully"),
+		"data":    device.ToResponse(),
+	})
+}
+
+func (h *DeviceHandler) Update(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		respondInvalidParam(c, "id", "Invalid device ID")
+		return
+	}
+
+	var req models.UpdateDeviceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondInvalidBody(c, err)
+		return
+	}
+
+	device, err := h.deviceService.Update(requestContext(c), uint(id), req)
+	if err != nil {
+		respondError(c, err, "Failed to update device")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": translate(c, "Device updated successfully"),
+		"data":    device.ToResponse(),
+	})
+}
+
+func (h *DeviceHandler) Delete(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		respondInvalidParam(c, "id", "Invalid device ID")
+		return
+	}
+
+	if err := h.deviceService.Delete(requestContext(c), uint(id)); err != nil {
+		respondError(c, err, "Failed to delete device")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": translate(c, "Device deleted successfully"),
+	})
+}
+
+func (h *DeviceHandler) CreateKey(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		respondInvalidParam(c, "id", "Invalid device ID")
+		return
+	}
+
+	var req models.CreateDeviceKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondInvalidBody(c, err)
+		return
+	}
+
+	issued, err := h.deviceService.CreateKey(requestContext(c), uint(id), req)
+	if err != nil {
+		respondError(c, err, "Failed to create device key")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": translate(c, "Device key created successfully, store it now as it will not be shown again"),
+		"data":    issuedKeyResponse(issued),
+	})
+}
+
+// RotateKey revokes an existing key and issues a replacement with the same scopes and expiry
+func (h *DeviceHandler) RotateKey(c *gin.Context) {
+	deviceID, keyID, ok := keyParams(c)
+	if !ok {
+		return
+	}
+
+	issued, err := h.deviceService.RotateKey(requestContext(c), deviceID, keyID)
+	if err != nil {
+		respondError(c, err, "Failed to rotate device key")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": translate(c, "Device key rotated successfully, store it now as it will not be shown again"),
+		"data":    issuedKeyResponse(issued),
+	})
+}
+
+func (h *DeviceHandler) RevokeKey(c *gin.Context) {
+	deviceID, keyID, ok := keyParams(c)
+	if !ok {
+		return
+	}
+
+	apiKey, err := h.deviceService.RevokeKey(requestContext(c), deviceID, keyID)
+	if err != nil {
+		respondError(c, err, "Failed to revoke device key")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": translate(c, "Device key revoked successfully"),
+		"data":    apiKey.ToResponse(),
+	})
+}
+
+func keyParams(c *gin.Context) (uint, uint, bool) {
+	deviceID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		respondInvalidParam(c, "id", "Invalid device ID")
+		return 0, 0, false
+	}
+
+	keyID, err := strconv.ParseUint(c.Param("key_id"), 10, 32)
+	if err != nil {
+		respondInvalidParam(c, "key_id", "Invalid key ID")
+		return 0, 0, false
+	}
+
+	return uint(deviceID), uint(keyID), true
+}
+
+func issuedKeyResponse(issued *service.IssuedDeviceKey) models.IssuedDeviceKeyResponse {
+	return models.IssuedDeviceKeyResponse{
+		DeviceAPIKeyResponse: issued.Key.ToResponse(),
+		Key:                  issued.PlainKey,
+	}
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"context"
+
+	"foodcourt-backend/internal/apierror"
+	"foodcourt-backend/internal/i18n"
+	"foodcourt-backend/internal/logging"
+	"foodcourt-backend/internal/models"
+	"foodcourt-backend/internal/requestinfo"
+
+	"github.com/gin-gonic/gin"
+)
+
+// requestContext returns the request context carrying the caller set by the auth middleware
+func requestContext(c *gin.Context) context.Context {
+	info := requestinfo.Info{
+		Username:  c.GetString("username"),
+		ClientIP:  c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		Method:    c.Request.Method,
+		Path:      c.Request.URL.Path,
+	}
+
+	if userID, ok := c.Get("user_id"); ok {
+		id := userID.(uint)
+		info.UserID = &id
+	}
+	if role, ok := c.Get("role"); ok {
+		info.Role = role.(models.UserRole)
+	}
+	if kiosID, ok := c.Get("kios_id"); ok {
+		info.KiosID = kiosID.(*uint)
+	}
+	if deviceID, ok := c.Get("device_id"); ok {
+		id := deviceID.(uint)
+		info.DeviceID = &id
+	}
+
+	return requestinfo.With(c.Request.Context(), info)
+}
+
+// currentUserID returns the ID of the authenticated user
+func currentUserID(c *gin.Context) uint {
+	userID, _ := c.Get("user_id")
+	id, _ := userID.(uint)
+	return id
+}
+
+// respondError writes the response for an error returned by a service. Errors
+// without a mapping are logged and answered with a generic message.
+func respondError(c *gin.Context, err error, message string) {
+	if apiErr := apierror.From(err); apiErr != nil {
+		apierror.Abort(c, apiErr)
+		return
+	}
+
+	logging.FromContext(c.Request.Context()).Error(message, "error", err)
+	apierror.Abort(c, apierror.Internal(message))
+}
+
+// respondInvalidBody writes the response for a request body that failed to bind
+func respondInvalidBody(c *gin.Context, err error) {
+	apierror.Abort(c, apierror.Binding(err))
+}
+
+// respondInvalidParam writes the response for an invalid path or query parameter
+func respondInvalidParam(c *gin.Context, name, format string, args ...any) {
+	apierror.Abort(c, apierror.InvalidParameter(name, format, args...))
+}
+
+// translate translates a status message to the locale of the request
+func translate(c *gin.Context, message string) string {
+	return i18n.T(locale(c), message)
+}
+
+// locale returns the locale negotiated for the request
+func locale(c *gin.Context) string {
+	return i18n.FromContext(c.Request.Context())
+}
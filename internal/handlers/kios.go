@@ -4,93 +4,136 @@ import (
 	"net/http"
 	"strconv"
 
+	"foodcourt-backend/internal/cache"
 	"foodcourt-backend/internal/models"
+	"foodcourt-backend/internal/repository"
+	"foodcourt-backend/internal/service"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 )
 
 type KiosHandler struct {
-	db *gorm.DB
+	kiosService service.KiosService
+	responses   *cache.Cache
 }
 
-func NewKiosHandler(db *gorm.DB) *KiosHandler {
-	return &KiosHandler{db: db}
+// NewKiosHandler creates the kios handler. Listings are cached in responses,
+// which may be nil.
+func NewKiosHandler(kiosService service.KiosService, responses *cache.Cache) *KiosHandler {
+	return &KiosHandler{kiosService: kiosService, responses: responses}
+}
+
+// kiosPage is a cached page of a kios listing. The figures of the kios are
+// cached apart, by business day, as every order changes them.
+type kiosPage struct {
+	Kios  []*models.KiosResponse `json:"kios"`
+	Total int64                  `json:"total"`
 }
 
 func (h *KiosHandler) GetAll(c *gin.Context) {
-	var kios []models.Kios
-	if err := h.db.Preload("Menus").Preload("Orders").Find(&kios).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to fetch kios",
-		})
+	opts, ok := listOptions(c, repository.KiosSortFields, defaultPageLimit, maxPageLimit)
+	if !ok {
+		return
+	}
+
+	// Filter by status and name if provided
+	filter := repository.KiosFilter{
+		Search: c.Query("q"),
+	}
+	if filter.IsActive, ok = boolQuery(c, "active"); !ok {
 		return
 	}
 
-	responses := make([]*models.KiosResponse, len(kios))
+	var page kiosPage
+	entry, cached := lookupCached(c, h.responses, cache.Kios, cacheKey(c), &page)
+	if !cached {
+		kios, total, err := h.kiosService.List(requestContext(c), filter, opts)
+		if err != nil {
+			respondError(c, err, "Failed to fetch kios")
+			return
+		}
+
+		lang := locale(c)
+		page = kiosPage{Kios: make([]*models.KiosResponse, len(kios)), Total: total}
+		for i, k := range kios {
+			page.Kios[i] = k.ToLocalizedResponse(lang)
+		}
+		storeCached(c, entry, page)
+	}
+
+	stats, ok := h.stats(c, page.Kios)
+	if !ok {
+		return
+	}
+	for _, k := range page.Kios {
+		k.SetStats(stats[k.ID])
+	}
+
+	respondTagged(c, pageBody(page.Kios, opts, page.Total))
+}
+
+// stats returns the figures of the kios on a page as of the business day in
+// progress, from the cache if they are in it
+func (h *KiosHandler) stats(c *gin.Context, kios []*models.KiosResponse) (map[uint]models.KiosStats, bool) {
+	day := h.kiosService.Today()
+	ids := make([]uint, len(kios))
+	key := day.Format("2006-01-02") + "#"
 	for i, k := range kios {
-		responses[i] = k.ToResponse()
+		ids[i] = k.ID
+		key += strconv.FormatUint(uint64(k.ID), 10) + ","
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"data": responses,
-	})
+	var stats map[uint]models.KiosStats
+	entry, cached := lookupCached(c, h.responses, cache.KiosStats, key, &stats)
+	if cached {
+		return stats, true
+	}
+
+	stats, err := h.kiosService.Stats(requestContext(c), ids, day)
+	if err != nil {
+		respondError(c, err, "Failed to fetch kios")
+		return nil, false
+	}
+	storeCached(c, entry, stats)
+	return stats, true
 }
 
 func (h *KiosHandler) GetByID(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid kios ID",
-		})
+		respondInvalidParam(c, "id", "Invalid kios ID")
 		return
 	}
 
-	var kios models.Kios
-	if err := h.db.Preload("Menus").Preload("Orders").First(&kios, uint(id)).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{
-				"error": "Kios not found",
-			})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to fetch kios",
-		})
+	kios, err := h.kiosService.Get(requestContext(c), uint(id))
+	if err != nil {
+		respondError(c, err, "Failed to fetch kios")
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"data": kios.ToResponse(),
+	setVersion(c, kios.Version)
+	respondTagged(c, gin.H{
+		"data": kios.ToLocalizedResponse(locale(c)),
 	})
 }
 
 func (h *KiosHandler) Create(c *gin.Context) {
 	var req models.CreateKiosRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid request format",
-			"details": err.Error(),
-		})
+		respondInvalidBody(c, err)
 		return
 	}
 
-	kios := models.Kios{
-		Name:        req.Name,
-		Description: req.Description,
-		Location:    req.Location,
-		IsActive:    true,
-	}
-
-	if err := h.db.Create(&kios).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to create kios",
-		})
+	kios, err := h.kiosService.Create(requestContext(c), req)
+	if err != nil {
+		respondError(c, err, "Failed to create kios")
 		return
 	}
+	h.responses.Invalidate(requestContext(c), cache.Kios)
 
+	setVersion(c, kios.Version)
 	c.JSON(http.StatusCreated, gin.H{
-		"message": "Kios created successfully",
+		"message": translate(c, "Kios created successfully"),
 		"data":    kios.ToResponse(),
 	})
 }
@@ -98,58 +141,50 @@ func (h *KiosHandler) Create(c *gin.Context) {
 func (h *KiosHandler) Update(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid kios ID",
-		})
+		respondInvalidParam(c, "id", "Invalid kios ID")
 		return
 	}
 
 	var req models.UpdateKiosRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid request format",
-			"details": err.Error(),
-		})
+		respondInvalidBody(c, err)
 		return
 	}
 
-	var kios models.Kios
-	if err := h.db.First(&kios, uint(id)).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{
-				"error": "Kios not found",
-			})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to fetch kios",
-		})
+	kios, err := h.kiosService.Update(requestContext(c), uint(id), req, precondition(c))
+	if err != nil {
+		respondError(c, err, "Failed to update kios")
 		return
 	}
+	// Menus show the name of their kios
+	h.responses.Invalidate(requestContext(c), cache.Kios, cache.Menus)
 
-	// Update fields
-	if req.Name != "" {
-		kios.Name = req.Name
-	}
-	if req.Description != "" {
-		kios.Description = req.Description
-	}
-	if req.Location != "" {
-		kios.Location = req.Location
-	}
-	if req.IsActive != nil {
-		kios.IsActive = *req.IsActive
+	setVersion(c, kios.Version)
+	c.JSON(http.StatusOK, gin.H{
+		"message": translate(c, "Kios updated successfully"),
+		"data":    kios.ToResponse(),
+	})
+}
+
+// Patch applies a JSON merge patch of models.KiosPatch to a kios
+func (h *KiosHandler) Patch(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		respondInvalidParam(c, "id", "Invalid kios ID")
+		return
 	}
 
-	if err := h.db.Save(&kios).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to update kios",
-		})
+	apply := func(patch *models.KiosPatch) error { return bindMergePatch(c, patch) }
+	kios, err := h.kiosService.Patch(requestContext(c), uint(id), apply, precondition(c))
+	if err != nil {
+		respondError(c, err, "Failed to update kios")
 		return
 	}
+	h.responses.Invalidate(requestContext(c), cache.Kios, cache.Menus)
 
+	setVersion(c, kios.Version)
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Kios updated successfully",
+		"message": translate(c, "Kios updated successfully"),
 		"data":    kios.ToResponse(),
 	})
 }
@@ -157,20 +192,17 @@ func (h *KiosHandler) Update(c *gin.Context) {
 func (h *KiosHandler) Delete(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid kios ID",
-		})
+		respondInvalidParam(c, "id", "Invalid kios ID")
 		return
 	}
 
-	if err := h.db.Delete(&models.Kios{}, uint(id)).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to delete kios",
-		})
+	if err := h.kiosService.Delete(requestContext(c), uint(id), precondition(c)); err != nil {
+		respondError(c, err, "Failed to delete kios")
 		return
 	}
+	h.responses.Invalidate(requestContext(c), cache.Kios, cache.Menus)
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Kios deleted successfully",
+		"message": translate(c, "Kios deleted successfully"),
 	})
 }
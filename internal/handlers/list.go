@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"foodcourt-backend/internal/businessday"
+	"foodcourt-backend/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+// listOptions reads the page, limit and sort query parameters shared by list
+// endpoints. sort is one of sortFields, prefixed with "-" for descending order.
+// It responds with 400 and returns false for invalid values.
+func listOptions(c *gin.Context, sortFields []string, defaultLimit, maxLimit int) (repository.ListOptions, bool) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		respondInvalidParam(c, "page", "Invalid page")
+		return repository.ListOptions{}, false
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultLimit)))
+	if err != nil || limit < 1 || limit > maxLimit {
+		respondInvalidParam(c, "limit", "Invalid limit, must be between 1 and %d", maxLimit)
+		return repository.ListOptions{}, false
+	}
+
+	opts := repository.ListOptions{
+		Offset: (page - 1) * limit,
+		Limit:  limit,
+	}
+
+	if sort := c.Query("sort"); sort != "" {
+		opts.Sort = strings.TrimPrefix(sort, "-")
+		opts.Desc = strings.HasPrefix(sort, "-")
+		if !containsString(sortFields, opts.Sort) {
+			respondInvalidParam(c, "sort", "Invalid sort, must be one of %s", strings.Join(sortFields, ", "))
+			return repository.ListOptions{}, false
+		}
+	}
+
+	return opts, true
+}
+
+// respondPage writes a page of a listing together with its pagination details
+func respondPage(c *gin.Context, data interface{}, opts repository.ListOptions, total int64) {
+	c.JSON(http.StatusOK, pageBody(data, opts, total))
+}
+
+// pageBody is the response body of a page of a listing
+func pageBody(data interface{}, opts repository.ListOptions, total int64) gin.H {
+	totalPages := (total + int64(opts.Limit) - 1) / int64(opts.Limit)
+
+	return gin.H{
+		"data": data,
+		"pagination": gin.H{
+			"page":        opts.Offset/opts.Limit + 1,
+			"limit":       opts.Limit,
+			"total":       total,
+			"total_pages": totalPages,
+		},
+	}
+}
+
+// timeRange reads the from and to query parameters as RFC 3339 timestamps or
+// YYYY-MM-DD dates. Dates are business days of calendar, and a date as to
+// includes that whole day.
+func timeRange(c *gin.Context, calendar businessday.Calendar) (from, to *time.Time, ok bool) {
+	if value := c.Query("from"); value != "" {
+		t, err := parseTime(calendar, value, false)
+		if err != nil {
+			respondInvalidParam(c, "from", "Invalid from, expected RFC 3339 timestamp or YYYY-MM-DD date")
+			return nil, nil, false
+		}
+		from = &t
+	}
+
+	if value := c.Query("to"); value != "" {
+		t, err := parseTime(calendar, value, true)
+		if err != nil {
+			respondInvalidParam(c, "to", "Invalid to, expected RFC 3339 timestamp or YYYY-MM-DD date")
+			return nil, nil, false
+		}
+		to = &t
+	}
+
+	return from, to, true
+}
+
+func parseTime(calendar businessday.Calendar, value string, endOfDay bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	start, end, err := calendar.ParseDate(value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfDay {
+		return end, nil
+	}
+	return start, nil
+}
+
+// boolQuery reads an optional true/false query parameter
+func boolQuery(c *gin.Context, name string) (*bool, bool) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, true
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		respondInvalidParam(c, name, "Invalid %s, expected true or false", name)
+		return nil, false
+	}
+	return &b, true
+}
+
+// uintQuery reads an optional ID query parameter
+func uintQuery(c *gin.Context, name, message string) (*uint, bool) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, true
+	}
+
+	id, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		respondInvalidParam(c, name, message)
+		return nil, false
+	}
+	result := uint(id)
+	return &result, true
+}
+
+// queryList reads a query parameter that may be repeated or comma separated
+func queryList(c *gin.Context, name string) []string {
+	var values []string
+	for _, param := range c.QueryArray(name) {
+		for _, value := range strings.Split(param, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+	}
+	return values
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
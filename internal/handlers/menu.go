@@ -4,88 +4,83 @@ import (
 	"net/http"
 	"strconv"
 
+	"foodcourt-backend/internal/cache"
 	"foodcourt-backend/internal/models"
+	"foodcourt-backend/internal/repository"
+	"foodcourt-backend/internal/service"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 )
 
 type MenuHandler struct {
-	db *gorm.DB
+	menuService service.MenuService
+	responses   *cache.Cache
 }
 
-func NewMenuHandler(db *gorm.DB) *MenuHandler {
-	return &MenuHandler{db: db}
+// NewMenuHandler creates the menu handler. Menus of kios are cached in
+// responses, which may be nil.
+func NewMenuHandler(menuService service.MenuService, responses *cache.Cache) *MenuHandler {
+	return &MenuHandler{menuService: menuService, responses: responses}
 }
 
 func (h *MenuHandler) GetByKios(c *gin.Context) {
+	entry, served := serveCached(c, h.responses, cache.Menus)
+	if served {
+		return
+	}
+
 	kiosID, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid kios ID",
-		})
+		respondInvalidParam(c, "id", "Invalid kios ID")
 		return
 	}
 
-	var menus []models.Menu
-	query := h.db.Preload("Kios").Where("kios_id = ?", uint(kiosID))
-
-	// Filter by category if provided
-	if category := c.Query("category"); category != "" {
-		query = query.Where("category = ?", category)
+	opts, ok := listOptions(c, repository.MenuSortFields, defaultPageLimit, maxPageLimit)
+	if !ok {
+		return
 	}
 
-	// Filter by availability if provided
-	if available := c.Query("available"); available != "" {
-		if available == "true" {
-			query = query.Where("is_available = ?", true)
-		} else if available == "false" {
-			query = query.Where("is_available = ?", false)
-		}
+	// Filter by category, availability and name if provided
+	filter := repository.MenuFilter{
+		Category: c.Query("category"),
+		Search:   c.Query("q"),
+	}
+	if filter.IsAvailable, ok = boolQuery(c, "available"); !ok {
+		return
 	}
 
-	if err := query.Find(&menus).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to fetch menus",
-		})
+	menus, total, err := h.menuService.ListByKios(requestContext(c), uint(kiosID), filter, opts)
+	if err != nil {
+		respondError(c, err, "Failed to fetch menus")
 		return
 	}
 
+	// Names and descriptions are returned in the requested language
+	lang := locale(c)
 	responses := make([]*models.MenuResponse, len(menus))
 	for i, menu := range menus {
-		responses[i] = menu.ToResponse()
+		responses[i] = menu.ToLocalizedResponse(lang)
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"data": responses,
-	})
+	respondCached(c, entry, pageBody(responses, opts, total))
 }
 
 func (h *MenuHandler) GetByID(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid menu ID",
-		})
+		respondInvalidParam(c, "id", "Invalid menu ID")
 		return
 	}
 
-	var menu models.Menu
-	if err := h.db.Preload("Kios").First(&menu, uint(id)).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{
-				"error": "Menu not found",
-			})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to fetch menu",
-		})
+	menu, err := h.menuService.Get(requestContext(c), uint(id))
+	if err != nil {
+		respondError(c, err, "Failed to fetch menu")
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"data": menu.ToResponse(),
+	setVersion(c, menu.Version)
+	respondTagged(c, gin.H{
+		"data": menu.ToLocalizedResponse(locale(c)),
 	})
 }
 
@@ -93,60 +88,27 @@ func (h *MenuHandler) Create(c *gin.Context) {
 	// Get kios ID from URL parameter
 	kiosID, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid kios ID",
-		})
+		respondInvalidParam(c, "id", "Invalid kios ID")
 		return
 	}
 
 	var req models.CreateMenuRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request format",
-			"details": err.Error(),
-		})
+		respondInvalidBody(c, err)
 		return
 	}
 
-	// Override kios ID from URL parameter
-	req.KiosID = uint(kiosID)
-
-	// Verify kios exists
-	var kios models.Kios
-	if err := h.db.First(&kios, req.KiosID).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Kios not found",
-		})
-		return
-	}
-
-	isAvailable := true
-	if req.IsAvailable != nil {
-		isAvailable = *req.IsAvailable
-	}
-
-	menu := models.Menu{
-		KiosID:      req.KiosID,
-		Name:        req.Name,
-		Description: req.Description,
-		Price:       req.Price,
-		Category:    req.Category,
-		ImageURL:    req.ImageURL,
-		IsAvailable: isAvailable,
-	}
-
-	if err := h.db.Create(&menu).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to create menu",
-		})
+	menu, err := h.menuService.Create(requestContext(c), uint(kiosID), req)
+	if err != nil {
+		respondError(c, err, "Failed to create menu")
 		return
 	}
+	// Kios listings count the menus
+	h.responses.Invalidate(requestContext(c), cache.Menus, cache.KiosStats)
 
-	// Load kios data
-	h.db.Preload("Kios").First(&menu, menu.ID)
-
+	setVersion(c, menu.Version)
 	c.JSON(http.StatusCreated, gin.H{
-		"message": "Menu created successfully",
+		"message": translate(c, "Menu created successfully"),
 		"data":    menu.ToResponse(),
 	})
 }
@@ -154,64 +116,50 @@ func (h *MenuHandler) Create(c *gin.Context) {
 func (h *MenuHandler) Update(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid menu ID",
-		})
+		respondInvalidParam(c, "id", "Invalid menu ID")
 		return
 	}
 
 	var req models.UpdateMenuRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request format",
-			"details": err.Error(),
-		})
+		respondInvalidBody(c, err)
 		return
 	}
 
-	var menu models.Menu
-	if err := h.db.Preload("Kios").First(&menu, uint(id)).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{
-				"error": "Menu not found",
-			})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to fetch menu",
-		})
+	menu, err := h.menuService.Update(requestContext(c), uint(id), req, precondition(c))
+	if err != nil {
+		respondError(c, err, "Failed to update menu")
 		return
 	}
+	// Kios listings count the menus
+	h.responses.Invalidate(requestContext(c), cache.Menus, cache.KiosStats)
 
-	// Update fields
-	if req.Name != "" {
-		menu.Name = req.Name
-	}
-	if req.Description != "" {
-		menu.Description = req.Description
-	}
-	if req.Price != nil {
-		menu.Price = *req.Price
-	}
-	if req.Category != "" {
-		menu.Category = req.Category
-	}
-	if req.ImageURL != "" {
-		menu.ImageURL = req.ImageURL
-	}
-	if req.IsAvailable != nil {
-		menu.IsAvailable = *req.IsAvailable
+	setVersion(c, menu.Version)
+	c.JSON(http.StatusOK, gin.H{
+		"message": translate(c, "Menu updated successfully"),
+		"data":    menu.ToResponse(),
+	})
+}
+
+// Patch applies a JSON merge patch of models.MenuPatch to a menu
+func (h *MenuHandler) Patch(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		respondInvalidParam(c, "id", "Invalid menu ID")
+		return
 	}
 
-	if err := h.db.Save(&menu).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to update menu",
-		})
+	apply := func(patch *models.MenuPatch) error { return bindMergePatch(c, patch) }
+	menu, err := h.menuService.Patch(requestContext(c), uint(id), apply, precondition(c))
+	if err != nil {
+		respondError(c, err, "Failed to update menu")
 		return
 	}
+	h.responses.Invalidate(requestContext(c), cache.Menus, cache.KiosStats)
 
+	setVersion(c, menu.Version)
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Menu updated successfully",
+		"message": translate(c, "Menu updated successfully"),
 		"data":    menu.ToResponse(),
 	})
 }
@@ -219,20 +167,18 @@ func (h *MenuHandler) Update(c *gin.Context) {
 func (h *MenuHandler) Delete(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid menu ID",
-		})
+		respondInvalidParam(c, "id", "Invalid menu ID")
 		return
 	}
 
-	if err := h.db.Delete(&models.Menu{}, uint(id)).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to delete menu",
-		})
+	if err := h.menuService.Delete(requestContext(c), uint(id), precondition(c)); err != nil {
+		respondError(c, err, "Failed to delete menu")
 		return
 	}
+	// Kios listings count the menus
+	h.responses.Invalidate(requestContext(c), cache.Menus, cache.KiosStats)
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Menu deleted successfully",
+		"message": translate(c, "Menu deleted successfully"),
 	})
 }
@@ -1,160 +1,55 @@
 package handlers
 
 import (
-	"fmt"
 	"net/http"
 	"strconv"
-	"time"
 
+	"foodcourt-backend/internal/businessday"
+	"foodcourt-backend/internal/cache"
 	"foodcourt-backend/internal/models"
+	"foodcourt-backend/internal/repository"
+	"foodcourt-backend/internal/service"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 )
 
 type OrderHandler struct {
-	db *gorm.DB
+	orderService service.OrderService
+	calendar     businessday.Calendar
+	responses    *cache.Cache
 }
 
-func NewOrderHandler(db *gorm.DB) *OrderHandler {
-	return &OrderHandler{db: db}
-}
-
-func (h *OrderHandler) generateQueueNumber(kiosID uint) (string, error) {
-	// Get today's date
-	today := time.Now().Format("20060102")
-
-	// Count orders for this kios today
-	var count int64
-	h.db.Model(&models.Order{}).
-		Where("kios_id = ? AND DATE(created_at) = ?", kiosID, time.Now().Format("2006-01-02")).
-		Count(&count)
-
-	// Generate queue number: KIOS{kiosID}-{date}-{sequence}
-	return fmt.Sprintf("K%d-%s-%03d", kiosID, today, count+1), nil
+// NewOrderHandler creates the order handler. Date filters are business days of
+// calendar. Orders change the figures of the kios listings cached in
+// responses, which may be nil.
+func NewOrderHandler(orderService service.OrderService, calendar businessday.Calendar, responses *cache.Cache) *OrderHandler {
+	return &OrderHandler{orderService: orderService, calendar: calendar, responses: responses}
 }
 
 func (h *OrderHandler) Create(c *gin.Context) {
 	// Get kios ID from URL parameter
 	kiosID, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid kios ID",
-		})
+		respondInvalidParam(c, "id", "Invalid kios ID")
 		return
 	}
 
 	var req models.CreateOrderRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request format",
-			"details": err.Error(),
-		})
-		return
-	}
-
-	// Override kios ID from URL parameter
-	req.KiosID = uint(kiosID)
-
-	userID, _ := c.Get("user_id")
-
-	// Verify kios exists
-	var kios models.Kios
-	if err := h.db.First(&kios, req.KiosID).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Kios not found",
-		})
+		respondInvalidBody(c, err)
 		return
 	}
 
-	// Generate queue number
-	queueNumber, err := h.generateQueueNumber(req.KiosID)
+	order, err := h.orderService.Create(requestContext(c), uint(kiosID), req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to generate queue number",
-		})
+		respondError(c, err, "Failed to create order")
 		return
 	}
+	h.responses.Invalidate(requestContext(c), cache.KiosStats)
 
-	// Start transaction
-	tx := h.db.Begin()
-
-	// Create order
-	order := models.Order{
-		QueueNumber:  queueNumber,
-		KiosID:       req.KiosID,
-		CustomerName: req.CustomerName,
-		Status:       models.StatusPending,
-		Notes:        req.Notes,
-		CreatedBy:    userID.(uint),
-	}
-
-	if err := tx.Create(&order).Error; err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to create order",
-		})
-		return
-	}
-
-	// Create order items and calculate total
-	var totalAmount float64
-	for _, item := range req.Items {
-		var menu models.Menu
-		if err := tx.First(&menu, item.MenuID).Error; err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error": fmt.Sprintf("Menu with ID %d not found", item.MenuID),
-			})
-			return
-		}
-
-		if !menu.IsAvailable {
-			tx.Rollback()
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error": fmt.Sprintf("Menu '%s' is not available", menu.Name),
-			})
-			return
-		}
-
-		subtotal := menu.Price * float64(item.Quantity)
-		totalAmount += subtotal
-
-		orderItem := models.OrderItem{
-			OrderID:  order.ID,
-			MenuID:   item.MenuID,
-			Quantity: item.Quantity,
-			Price:    menu.Price,
-			Subtotal: subtotal,
-			Notes:    item.Notes,
-		}
-
-		if err := tx.Create(&orderItem).Error; err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": "Failed to create order item",
-			})
-			return
-		}
-	}
-
-	// Update order total
-	order.TotalAmount = totalAmount
-	if err := tx.Save(&order).Error; err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to update order total",
-		})
-		return
-	}
-
-	tx.Commit()
-
-	// Load complete order data
-	h.db.Preload("Kios").Preload("OrderItems.Menu").Preload("Creator").First(&order, order.ID)
-
+	setVersion(c, order.Version)
 	c.JSON(http.StatusCreated, gin.H{
-		"message": "Order created successfully",
+		"message": translate(c, "Order created successfully"),
 		"data":    order.ToResponse(),
 	})
 }
@@ -162,67 +57,50 @@ func (h *OrderHandler) Create(c *gin.Context) {
 func (h *OrderHandler) GetByKios(c *gin.Context) {
 	kiosID, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid kios ID",
-		})
+		respondInvalidParam(c, "id", "Invalid kios ID")
 		return
 	}
 
-	var orders []models.Order
-	query := h.db.Preload("Kios").Preload("OrderItems.Menu").Preload("Creator").
-		Where("kios_id = ?", uint(kiosID))
-
-	// Filter by status if provided
-	if status := c.Query("status"); status != "" {
-		query = query.Where("status = ?", status)
-	}
+	id := uint(kiosID)
+	h.respondList(c, &id)
+}
 
-	// Filter by date if provided
-	if date := c.Query("date"); date != "" {
-		query = query.Where("DATE(created_at) = ?", date)
+func (h *OrderHandler) GetAll(c *gin.Context) {
+	kiosID, ok := uintQuery(c, "kios_id", "Invalid kios ID")
+	if !ok {
+		return
 	}
 
-	// Order by created_at desc
-	query = query.Order("created_at DESC")
+	h.respondList(c, kiosID)
+}
 
-	if err := query.Find(&orders).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to fetch orders",
-		})
+// respondList writes a page of orders filtered by the status, from and to query parameters
+func (h *OrderHandler) respondList(c *gin.Context, kiosID *uint) {
+	opts, ok := listOptions(c, repository.OrderSortFields, defaultPageLimit, maxPageLimit)
+	if !ok {
 		return
 	}
 
-	responses := make([]*models.OrderResponse, len(orders))
-	for i, order := range orders {
-		responses[i] = order.ToResponse()
-	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"data": responses,
-	})
-}
-
-func (h *OrderHandler) GetAll(c *gin.Context) {
-	var orders []models.Order
-	query := h.db.Preload("Kios").Preload("OrderItems.Menu").Preload("Creator")
+	filter := repository.OrderFilter{KiosID: kiosID}
 
-	// Filter by status if provided
-	if status := c.Query("status"); status != "" {
-		query = query.Where("status = ?", status)
+	// Filter by one or more statuses, e.g. status=paid,preparing
+	for _, value := range queryList(c, "status") {
+		status := models.OrderStatus(value)
+		if !status.IsValid() {
+			respondInvalidParam(c, "status", "Invalid status %s", value)
+			return
+		}
+		filter.Statuses = append(filter.Statuses, status)
 	}
 
-	// Filter by date if provided
-	if date := c.Query("date"); date != "" {
-		query = query.Where("DATE(created_at) = ?", date)
+	// Filter by creation time
+	if filter.From, filter.To, ok = timeRange(c, h.calendar); !ok {
+		return
 	}
 
-	// Order by created_at desc
-	query = query.Order("created_at DESC")
-
-	if err := query.Find(&orders).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to fetch orders",
-		})
+	orders, total, err := h.orderService.List(requestContext(c), filter, opts)
+	if err != nil {
+		respondError(c, err, "Failed to fetch orders")
 		return
 	}
 
@@ -231,36 +109,24 @@ func (h *OrderHandler) GetAll(c *gin.Context) {
 		responses[i] = order.ToResponse()
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"data": responses,
-	})
+	respondPage(c, responses, opts, total)
 }
 
 func (h *OrderHandler) GetByID(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid order ID",
-		})
+		respondInvalidParam(c, "id", "Invalid order ID")
 		return
 	}
 
-	var order models.Order
-	if err := h.db.Preload("Kios").Preload("OrderItems.Menu").Preload("Creator").
-		First(&order, uint(id)).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{
-				"error": "Order not found",
-			})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to fetch order",
-		})
+	order, err := h.orderService.Get(requestContext(c), uint(id))
+	if err != nil {
+		respondError(c, err, "Failed to fetch order")
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	setVersion(c, order.Version)
+	respondTagged(c, gin.H{
 		"data": order.ToResponse(),
 	})
 }
@@ -268,63 +134,26 @@ func (h *OrderHandler) GetByID(c *gin.Context) {
 func (h *OrderHandler) UpdateStatus(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid order ID",
-		})
+		respondInvalidParam(c, "id", "Invalid order ID")
 		return
 	}
 
 	var req models.UpdateOrderStatusRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request format",
-			"details": err.Error(),
-		})
-		return
-	}
-
-	var order models.Order
-	if err := h.db.First(&order, uint(id)).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{
-				"error": "Order not found",
-			})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to fetch order",
-		})
+		respondInvalidBody(c, err)
 		return
 	}
 
-	// Update status and timestamps
-	now := time.Now()
-	order.Status = req.Status
-
-	switch req.Status {
-	case models.StatusPaid:
-		order.PaidAt = &now
-		order.PaymentMethod = req.PaymentMethod
-	case models.StatusPreparing:
-		order.PreparedAt = &now
-	case models.StatusReady:
-		order.ReadyAt = &now
-	case models.StatusCompleted:
-		order.CompletedAt = &now
-	}
-
-	if err := h.db.Save(&order).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to update order status",
-		})
+	order, err := h.orderService.UpdateStatus(requestContext(c), uint(id), req, precondition(c))
+	if err != nil {
+		respondError(c, err, "Failed to update order status")
 		return
 	}
+	h.responses.Invalidate(requestContext(c), cache.KiosStats)
 
-	// Load complete order data
-	h.db.Preload("Kios").Preload("OrderItems.Menu").Preload("Creator").First(&order, order.ID)
-
+	setVersion(c, order.Version)
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Order status updated successfully",
+		"message": translate(c, "Order status updated successfully"),
 		"data":    order.ToResponse(),
 	})
 }
@@ -332,25 +161,14 @@ func (h *OrderHandler) UpdateStatus(c *gin.Context) {
 func (h *OrderHandler) GetQueue(c *gin.Context) {
 	kiosID, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid kios ID",
-		})
+		respondInvalidParam(c, "id", "Invalid kios ID")
 		return
 	}
 
 	// Get orders that are paid, preparing, or ready (active queue)
-	var orders []models.Order
-	if err := h.db.Preload("Kios").Preload("OrderItems.Menu").Preload("Creator").
-		Where("kios_id = ? AND status IN ?", uint(kiosID), []models.OrderStatus{
-			models.StatusPaid,
-			models.StatusPreparing,
-			models.StatusReady,
-		}).
-		Order("created_at ASC").
-		Find(&orders).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to fetch queue",
-		})
+	orders, err := h.orderService.Queue(requestContext(c), uint(kiosID))
+	if err != nil {
+		respondError(c, err, "Failed to fetch queue")
 		return
 	}
 
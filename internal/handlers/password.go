@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"net/http"
+
+	"foodcourt-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ChangePassword changes the password of the current user and revokes all other sessions
+func (h *AuthHandler) ChangePassword(c *gin.Context) {
+	var req models.ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondInvalidBody(c, err)
+		return
+	}
+
+	token, err := h.authService.ChangePassword(requestContext(c), currentUserID(c), req.CurrentPassword, req.NewPassword)
+	if err != nil {
+		respondError(c, err, "Failed to update password")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": translate(c, "Password changed successfully, other sessions have been signed out"),
+		"token":   token,
+	})
+}
+
+// ForgotPassword sends a single-use reset token. The response never reveals whether the account exists.
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+	var req models.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondInvalidBody(c, err)
+		return
+	}
+
+	if err := h.authService.ForgotPassword(requestContext(c), req.Identifier); err != nil {
+		respondError(c, err, "Failed to create reset token")
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": translate(c, "If the account exists, password reset instructions have been sent"),
+	})
+}
+
+// ResetPassword sets a new password using a reset token and revokes all sessions
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var req models.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondInvalidBody(c, err)
+		return
+	}
+
+	if err := h.authService.ResetPassword(requestContext(c), req.Token, req.NewPassword); err != nil {
+		respondError(c, err, "Failed to update password")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": translate(c, "Password reset successfully, please log in with your new password"),
+	})
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"foodcourt-backend/internal/apierror"
+	"foodcourt-backend/internal/mergepatch"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// bindMergePatch applies the JSON merge patch in the request body to doc, the
+// current values of the fields a PATCH request changes, and validates the
+// result as a whole. Fields doc does not have are rejected. Errors are
+// *apierror.Error, ready for respondError.
+func bindMergePatch[T any](c *gin.Context, doc *T) error {
+	patch, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return apierror.Binding(err)
+	}
+	patch = bytes.TrimSpace(patch)
+	if len(patch) == 0 {
+		return apierror.Binding(io.EOF)
+	}
+	// Patches other than objects would replace the whole document
+	if patch[0] != '{' {
+		return apierror.New(http.StatusBadRequest, apierror.CodeInvalidRequest, "Request body must be a JSON object")
+	}
+
+	// The merge drops members set to null, unknown ones included, so those are
+	// looked for in the patch itself
+	if err := decodeStrict(patch, new(T)); err != nil {
+		return apierror.Binding(err)
+	}
+
+	current, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	merged, err := mergepatch.Apply(current, patch)
+	if err != nil {
+		return apierror.Binding(err)
+	}
+
+	var result T
+	if err := decodeStrict(merged, &result); err != nil {
+		return apierror.Binding(err)
+	}
+	if err := binding.Validator.ValidateStruct(&result); err != nil {
+		return apierror.Binding(err)
+	}
+
+	*doc = result
+	return nil
+}
+
+// decodeStrict decodes JSON into v, rejecting fields v does not have
+func decodeStrict(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(v)
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+
+	"foodcourt-backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// versionHeader names the stored version of the resource a response shows
+const versionHeader = "X-Resource-Version"
+
+// setVersion names the version of the resource in the response, for clients
+// to send back quoted in If-Match. It has its own header because the version
+// is the same in every language and whatever else the response shows, so it
+// cannot serve as the ETag of the representation.
+func setVersion(c *gin.Context, version uint) {
+	c.Header(versionHeader, strconv.FormatUint(uint64(version), 10))
+}
+
+// precondition reads the If-Match header of a request changing a resource.
+// Only strong tags of versions can match; weak tags and tags of other forms
+// never do, as RFC 9110 asks for If-Match.
+func precondition(c *gin.Context) service.Precondition {
+	header := c.GetHeader("If-Match")
+	if header == "" {
+		return service.Precondition{}
+	}
+
+	match := service.Precondition{Conditional: true}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			// Any version of a resource that exists
+			return service.Precondition{}
+		}
+		if len(candidate) < 2 || candidate[0] != '"' || candidate[len(candidate)-1] != '"' {
+			continue
+		}
+		if version, err := strconv.ParseUint(candidate[1:len(candidate)-1], 10, 32); err == nil {
+			match.Versions = append(match.Versions, uint(version))
+		}
+	}
+	return match
+}
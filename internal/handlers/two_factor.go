@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"foodcourt-backend/internal/models"
+	"foodcourt-backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VerifyTwoFactor completes a login with a TOTP code or a recovery code
+func (h *AuthHandler) VerifyTwoFactor(c *gin.Context) {
+	var req models.VerifyTwoFactorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondInvalidBody(c, err)
+		return
+	}
+
+	result, err := h.authService.VerifyTwoFactor(requestContext(c), req)
+	if err != nil {
+		respondError(c, err, "Failed to verify two-factor code")
+		return
+	}
+
+	c.JSON(http.StatusOK, newLoginResponse(result))
+}
+
+// StartTwoFactorSetup enrolls a user whose role requires two-factor authentication during login
+func (h *AuthHandler) StartTwoFactorSetup(c *gin.Context) {
+	var req models.TwoFactorSetupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondInvalidBody(c, err)
+		return
+	}
+
+	enrollment, err := h.authService.StartTwoFactorSetup(requestContext(c), req.ChallengeToken)
+	if err != nil {
+		respondError(c, err, "Failed to start two-factor enrollment")
+		return
+	}
+
+	respondEnrollment(c, enrollment)
+}
+
+// ConfirmTwoFactorSetup enables two-factor authentication with a first code and finishes the login
+func (h *AuthHandler) ConfirmTwoFactorSetup(c *gin.Context) {
+	var req models.TwoFactorSetupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondInvalidBody(c, err)
+		return
+	}
+
+	result, err := h.authService.ConfirmTwoFactorSetup(requestContext(c), req.ChallengeToken, req.Code)
+	if err != nil {
+		respondError(c, err, "Failed to enable two-factor authentication")
+		return
+	}
+
+	c.JSON(http.StatusOK, newLoginResponse(result))
+}
+
+// EnrollTwoFactor starts voluntary two-factor enrollment for the current user
+func (h *AuthHandler) EnrollTwoFactor(c *gin.Context) {
+	enrollment, err := h.authService.EnrollTwoFactor(requestContext(c), currentUserID(c))
+	if err != nil {
+		respondError(c, err, "Failed to start two-factor enrollment")
+		return
+	}
+
+	respondEnrollment(c, enrollment)
+}
+
+func (h *AuthHandler) ConfirmTwoFactor(c *gin.Context) {
+	var req models.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondInvalidBody(c, err)
+		return
+	}
+
+	recoveryCodes, err := h.authService.ConfirmTwoFactor(requestContext(c), currentUserID(c), req.Code)
+	if err != nil {
+		respondError(c, err, "Failed to enable two-factor authentication")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        translate(c, "Two-factor authentication enabled successfully"),
+		"recovery_codes": recoveryCodes,
+	})
+}
+
+func (h *AuthHandler) DisableTwoFactor(c *gin.Context) {
+	var req models.DisableTwoFactorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondInvalidBody(c, err)
+		return
+	}
+
+	if err := h.authService.DisableTwoFactor(requestContext(c), currentUserID(c), req.Password, req.Code); err != nil {
+		respondError(c, err, "Failed to disable two-factor authentication")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": translate(c, "Two-factor authentication disabled successfully"),
+	})
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes of the current user
+func (h *AuthHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	var req models.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondInvalidBody(c, err)
+		return
+	}
+
+	recoveryCodes, err := h.authService.RegenerateRecoveryCodes(requestContext(c), currentUserID(c), req.Code)
+	if err != nil {
+		respondError(c, err, "Failed to generate recovery codes")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        translate(c, "Recovery codes regenerated successfully"),
+		"recovery_codes": recoveryCodes,
+	})
+}
+
+// ResetTwoFactor lets an administrator remove two-factor authentication from a user who lost their device
+func (h *AuthHandler) ResetTwoFactor(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		respondInvalidParam(c, "id", "Invalid user ID")
+		return
+	}
+
+	if err := h.authService.ResetTwoFactor(requestContext(c), uint(id)); err != nil {
+		respondError(c, err, "Failed to reset two-factor authentication")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": translate(c, "Two-factor authentication reset successfully"),
+	})
+}
+
+func respondEnrollment(c *gin.Context, enrollment *service.TwoFactorEnrollment) {
+	c.JSON(http.StatusOK, gin.H{
+		"message": translate(c, "Scan the provisioning URI with an authenticator app, then confirm with a code"),
+		"data": models.TwoFactorEnrollmentResponse{
+			Secret:          enrollment.Secret,
+			ProvisioningURI: enrollment.ProvisioningURI,
+		},
+	})
+}
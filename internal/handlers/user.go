@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"foodcourt-backend/internal/models"
+	"foodcourt-backend/internal/repository"
+	"foodcourt-backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type UserHandler struct {
+	userService service.UserService
+}
+
+func NewUserHandler(userService service.UserService) *UserHandler {
+	return &UserHandler{userService: userService}
+}
+
+func (h *UserHandler) GetAll(c *gin.Context) {
+	opts, ok := listOptions(c, repository.UserSortFields, defaultPageLimit, maxPageLimit)
+	if !ok {
+		return
+	}
+
+	// Filter by role, kios, status and name if provided
+	filter := repository.UserFilter{
+		Role:   models.UserRole(c.Query("role")),
+		Search: c.Query("q"),
+	}
+	if filter.Role != "" && filter.Role != models.RoleCashier && filter.Role != models.RoleKios {
+		respondInvalidParam(c, "role", "Invalid role")
+		return
+	}
+	if filter.KiosID, ok = uintQuery(c, "kios_id", "Invalid kios ID"); !ok {
+		return
+	}
+	if filter.IsActive, ok = boolQuery(c, "active"); !ok {
+		return
+	}
+
+	users, total, err := h.userService.List(requestContext(c), filter, opts)
+	if err != nil {
+		respondError(c, err, "Failed to fetch users")
+		return
+	}
+
+	responses := make([]*models.UserResponse, len(users))
+	for i, user := range users {
+		responses[i] = user.ToResponse()
+	}
+
+	respondPage(c, responses, opts, total)
+}
+
+// Patch applies a JSON merge patch of models.UserPatch to a user
+func (h *UserHandler) Patch(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		respondInvalidParam(c, "id", "Invalid user ID")
+		return
+	}
+
+	apply := func(patch *models.UserPatch) error { return bindMergePatch(c, patch) }
+	user, err := h.userService.Patch(requestContext(c), uint(id), apply)
+	if err != nil {
+		respondError(c, err, "Failed to update user")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": translate(c, "User updated successfully"),
+		"data":    user.ToResponse(),
+	})
+}
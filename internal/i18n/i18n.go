@@ -0,0 +1,85 @@
+// Package i18n negotiates the language of a request and translates the
+// messages of the API. Messages are written in English and looked up in the
+// catalog of the requested locale, falling back to English when missing.
+package i18n
+
+import (
+	"context"
+	"fmt"
+
+	"golang.org/x/text/language"
+)
+
+const (
+	English    = "en"
+	Indonesian = "id"
+)
+
+// Supported lists the locales of the API, the first being used when a request
+// names none of them
+var Supported = []string{English, Indonesian}
+
+var (
+	tags    = []language.Tag{language.English, language.Indonesian}
+	matcher = language.NewMatcher(tags)
+)
+
+// catalogs maps the English messages to their translation, by locale
+var catalogs = map[string]map[string]string{
+	Indonesian: indonesian,
+}
+
+// IsSupported reports whether locale is one of Supported
+func IsSupported(locale string) bool {
+	for _, supported := range Supported {
+		if locale == supported {
+			return true
+		}
+	}
+	return false
+}
+
+// Negotiate picks the supported locale preferred by an Accept-Language header,
+// such as "id-ID,id;q=0.9,en;q=0.8". It returns fallback when the header is
+// empty, invalid or names no supported language.
+func Negotiate(acceptLanguage, fallback string) string {
+	if acceptLanguage == "" {
+		return fallback
+	}
+	preferred, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(preferred) == 0 {
+		return fallback
+	}
+	_, index, confidence := matcher.Match(preferred...)
+	if confidence == language.No {
+		return fallback
+	}
+	return Supported[index]
+}
+
+type localeKey struct{}
+
+// WithLocale returns a copy of ctx carrying the locale of the request
+func WithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, localeKey{}, locale)
+}
+
+// FromContext returns the locale of ctx, or English
+func FromContext(ctx context.Context) string {
+	if locale, ok := ctx.Value(localeKey{}).(string); ok {
+		return locale
+	}
+	return English
+}
+
+// T translates the message to locale and formats it with args, like
+// fmt.Sprintf. Messages missing from the catalog are kept in English.
+func T(locale, message string, args ...any) string {
+	if translated, ok := catalogs[locale][message]; ok {
+		message = translated
+	}
+	if len(args) == 0 {
+		return message
+	}
+	return fmt.Sprintf(message, args...)
+}
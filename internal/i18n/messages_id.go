@@ -0,0 +1,187 @@
+package i18n
+
+// indonesian translates the messages of the API to Bahasa Indonesia. Keys must
+// match the English messages exactly, including their format verbs.
+var indonesian = map[string]string{
+	// Errors of the API
+	"Resource not found":    "Data tidak ditemukan",
+	"Route not found":       "Rute tidak ditemukan",
+	"Method not allowed":    "Metode tidak diizinkan",
+	"Internal server error": "Terjadi kesalahan pada server",
+	"A record with the same unique value already exists":               "Data dengan nilai unik yang sama sudah ada",
+	"The record references a missing record or is still referenced":    "Data merujuk ke data yang tidak ada atau masih dirujuk data lain",
+	"Too many failed login attempts, please try again later":           "Terlalu banyak percobaan login yang gagal, silakan coba lagi nanti",
+	"Too many requests, please try again later":                        "Terlalu banyak permintaan, silakan coba lagi nanti",
+	"Account temporarily locked due to too many failed login attempts": "Akun dikunci sementara karena terlalu banyak percobaan login yang gagal",
+	"Some fields are invalid":                                          "Beberapa isian tidak valid",
+	"Request body is required":                                         "Isi permintaan wajib diisi",
+	"Request body must be a JSON object":                               "Isi permintaan harus berupa objek JSON",
+	"Request body is not valid JSON":                                   "Isi permintaan bukan JSON yang valid",
+	"Request body is too large, the limit is %d bytes":                 "Isi permintaan terlalu besar, batasnya %d byte",
+	"Invalid request format":                                           "Format permintaan tidak valid",
+	"Authorization header required":                                    "Header Authorization wajib diisi",
+	"Invalid authorization header format":                              "Format header Authorization tidak valid",
+	"Invalid or expired token":                                         "Token tidak valid atau sudah kedaluwarsa",
+	"Invalid token":                                                    "Token tidak valid",
+	"Session has been revoked, please log in again":                    "Sesi telah dicabut, silakan login kembali",
+	"User role not found in context":                                   "Peran pengguna tidak ditemukan",
+	"Insufficient permissions":                                         "Hak akses tidak mencukupi",
+	"Kios user must be assigned to a kios":                             "Pengguna kios harus terdaftar pada sebuah kios",
+	"Access denied to this kios":                                       "Akses ke kios ini ditolak",
+	"Device key required":                                              "Kunci perangkat wajib diisi",
+	"Invalid device key":                                               "Kunci perangkat tidak valid",
+	"Invalid or revoked device key":                                    "Kunci perangkat tidak valid atau sudah dicabut",
+	"Device key lacks required scope":                                  "Kunci perangkat tidak memiliki izin yang diperlukan",
+	"Password change required, change it with POST /api/v1/me/password before using other endpoints": "Kata sandi harus diganti, ganti dengan POST /api/v1/me/password sebelum menggunakan endpoint lain",
+
+	// Invalid parameters
+	"Invalid page": "Halaman tidak valid",
+	"Invalid limit, must be between 1 and %d":                      "Batas tidak valid, harus antara 1 dan %d",
+	"Invalid sort, must be one of %s":                              "Urutan tidak valid, harus salah satu dari %s",
+	"Invalid from, expected RFC 3339 timestamp or YYYY-MM-DD date": "Nilai from tidak valid, gunakan waktu RFC 3339 atau tanggal YYYY-MM-DD",
+	"Invalid to, expected RFC 3339 timestamp or YYYY-MM-DD date":   "Nilai to tidak valid, gunakan waktu RFC 3339 atau tanggal YYYY-MM-DD",
+	"Invalid %s, expected true or false":                           "Nilai %s tidak valid, gunakan true atau false",
+	"Invalid status %s":                                            "Status %s tidak valid",
+	"Invalid role":                                                 "Peran tidak valid",
+	"Invalid kios ID":                                              "ID kios tidak valid",
+	"Invalid menu ID":                                              "ID menu tidak valid",
+	"Invalid order ID":                                             "ID pesanan tidak valid",
+	"Invalid device ID":                                            "ID perangkat tidak valid",
+	"Invalid key ID":                                               "ID kunci tidak valid",
+	"Invalid user ID":                                              "ID pengguna tidak valid",
+
+	// Invalid fields
+	"is required":                                      "wajib diisi",
+	"is not a known field":                             "bukan field yang dikenal",
+	"is invalid":                                       "tidak valid",
+	"must be at least %s":                              "minimal %s",
+	"must be at least %s characters":                   "minimal %s karakter",
+	"must be at least %s items":                        "minimal %s item",
+	"must be at most %s":                               "maksimal %s",
+	"must be at most %s characters":                    "maksimal %s karakter",
+	"must be at most %s items":                         "maksimal %s item",
+	"must be exactly %s":                               "harus tepat %s",
+	"must be exactly %s characters":                    "harus tepat %s karakter",
+	"must be exactly %s items":                         "harus tepat %s item",
+	"must be greater than %s":                          "harus lebih besar dari %s",
+	"must be less than %s":                             "harus lebih kecil dari %s",
+	"must be one of %s":                                "harus salah satu dari %s",
+	"must be a valid e-mail address":                   "harus berupa alamat e-mail yang valid",
+	"must be a valid URL":                              "harus berupa URL yang valid",
+	"must be a valid IP address":                       "harus berupa alamat IP yang valid",
+	"must contain digits only":                         "hanya boleh berisi angka",
+	"must be a string":                                 "harus berupa teks",
+	"must be a number":                                 "harus berupa angka",
+	"must be a boolean":                                "harus berupa true atau false",
+	"must be a list":                                   "harus berupa daftar",
+	"must be an object":                                "harus berupa objek",
+	"password must be at least 8 characters long":      "kata sandi minimal 8 karakter",
+	"password must be at most 72 characters long":      "kata sandi maksimal 72 karakter",
+	"password must contain both letters and digits":    "kata sandi harus berisi huruf dan angka",
+	"password is too common":                           "kata sandi terlalu umum",
+	"password must not contain your username or email": "kata sandi tidak boleh berisi username atau e-mail Anda",
+
+	// Errors of the services
+	"Access denied to this order":                                     "Akses ke pesanan ini ditolak",
+	"Current password is incorrect":                                   "Kata sandi saat ini salah",
+	"Device key not found":                                            "Kunci perangkat tidak ditemukan",
+	"Device not found":                                                "Perangkat tidak ditemukan",
+	"Devices can only mark orders as ready":                           "Perangkat hanya dapat menandai pesanan sebagai siap",
+	"Expiry must be in the future":                                    "Masa berlaku harus di masa depan",
+	"Failed to refresh token":                                         "Gagal memperbarui token",
+	"Invalid credentials":                                             "Username atau kata sandi salah",
+	"Invalid or expired challenge token":                              "Token tantangan tidak valid atau sudah kedaluwarsa",
+	"Invalid or expired reset token":                                  "Token reset tidak valid atau sudah kedaluwarsa",
+	"Invalid password or two-factor code":                             "Kata sandi atau kode dua faktor salah",
+	"Invalid two-factor code":                                         "Kode dua faktor salah",
+	"Kios has been changed by someone else, reload it and try again":  "Kios telah diubah oleh orang lain, muat ulang lalu coba lagi",
+	"Kios not found":                                                  "Kios tidak ditemukan",
+	"Menu has been changed by someone else, reload it and try again":  "Menu telah diubah oleh orang lain, muat ulang lalu coba lagi",
+	"Menu not found":                                                  "Menu tidak ditemukan",
+	"Menu with ID %d not found":                                       "Menu dengan ID %d tidak ditemukan",
+	"Menu '%s' is not available":                                      "Menu '%s' sedang tidak tersedia",
+	"New password must differ from the current password":              "Kata sandi baru harus berbeda dari kata sandi saat ini",
+	"Only active keys can be rotated":                                 "Hanya kunci aktif yang dapat diganti",
+	"Order has been changed by someone else, reload it and try again": "Pesanan telah diubah oleh orang lain, muat ulang lalu coba lagi",
+	"Order not found":                                                 "Pesanan tidak ditemukan",
+	"Password does not meet requirements":                             "Kata sandi tidak memenuhi persyaratan",
+	"Two-factor authentication is already enabled":                    "Autentikasi dua faktor sudah aktif",
+	"Two-factor authentication is not enabled":                        "Autentikasi dua faktor belum aktif",
+	"Two-factor authentication is required for your role":             "Autentikasi dua faktor wajib untuk peran Anda",
+	"Two-factor code required":                                        "Kode dua faktor wajib diisi",
+	"Two-factor enrollment has not been started":                      "Pendaftaran dua faktor belum dimulai",
+	"User not found":                                                  "Pengguna tidak ditemukan",
+	"User required to create orders":                                  "Pesanan hanya dapat dibuat oleh pengguna",
+	"User required to register devices":                               "Perangkat hanya dapat didaftarkan oleh pengguna",
+	"You cannot change the role of or deactivate your own account":    "Anda tidak dapat mengubah peran atau menonaktifkan akun Anda sendiri",
+	"Username or email already exists":                                "Username atau e-mail sudah digunakan",
+
+	// Unexpected failures
+	"Failed to create device":                     "Gagal membuat perangkat",
+	"Failed to create device key":                 "Gagal membuat kunci perangkat",
+	"Failed to create kios":                       "Gagal membuat kios",
+	"Failed to create menu":                       "Gagal membuat menu",
+	"Failed to create order":                      "Gagal membuat pesanan",
+	"Failed to create reset token":                "Gagal membuat token reset",
+	"Failed to create user":                       "Gagal membuat pengguna",
+	"Failed to delete device":                     "Gagal menghapus perangkat",
+	"Failed to delete kios":                       "Gagal menghapus kios",
+	"Failed to delete menu":                       "Gagal menghapus menu",
+	"Failed to disable two-factor authentication": "Gagal menonaktifkan autentikasi dua faktor",
+	"Failed to enable two-factor authentication":  "Gagal mengaktifkan autentikasi dua faktor",
+	"Failed to encode response":                   "Gagal menyusun respons",
+	"Failed to fetch audit logs":                  "Gagal mengambil log audit",
+	"Failed to fetch device":                      "Gagal mengambil perangkat",
+	"Failed to fetch devices":                     "Gagal mengambil daftar perangkat",
+	"Failed to fetch kios":                        "Gagal mengambil kios",
+	"Failed to fetch menu":                        "Gagal mengambil menu",
+	"Failed to fetch menus":                       "Gagal mengambil daftar menu",
+	"Failed to fetch order":                       "Gagal mengambil pesanan",
+	"Failed to fetch orders":                      "Gagal mengambil daftar pesanan",
+	"Failed to fetch queue":                       "Gagal mengambil antrean",
+	"Failed to fetch user":                        "Gagal mengambil pengguna",
+	"Failed to fetch users":                       "Gagal mengambil daftar pengguna",
+	"Failed to generate recovery codes":           "Gagal membuat kode pemulihan",
+	"Failed to generate token":                    "Gagal membuat token",
+	"Failed to log in":                            "Gagal login",
+	"Failed to reset two-factor authentication":   "Gagal mereset autentikasi dua faktor",
+	"Failed to revoke device key":                 "Gagal mencabut kunci perangkat",
+	"Failed to rotate device key":                 "Gagal mengganti kunci perangkat",
+	"Failed to start two-factor enrollment":       "Gagal memulai pendaftaran dua faktor",
+	"Failed to unlock login":                      "Gagal membuka kunci login",
+	"Failed to update device":                     "Gagal memperbarui perangkat",
+	"Failed to update kios":                       "Gagal memperbarui kios",
+	"Failed to update menu":                       "Gagal memperbarui menu",
+	"Failed to update order status":               "Gagal memperbarui status pesanan",
+	"Failed to update password":                   "Gagal memperbarui kata sandi",
+	"Failed to update user":                       "Gagal memperbarui pengguna",
+	"Failed to verify two-factor code":            "Gagal memverifikasi kode dua faktor",
+
+	// Status messages
+	"Food Court API is running":   "Food Court API berjalan",
+	"Device created successfully": "Perangkat berhasil dibuat",
+	"Device deleted successfully": "Perangkat berhasil dihapus",
+	"Device updated successfully": "Perangkat berhasil diperbarui",
+	"Device key created successfully, store it now as it will not be shown again":   "Kunci perangkat berhasil dibuat, simpan sekarang karena tidak akan ditampilkan lagi",
+	"Device key rotated successfully, store it now as it will not be shown again":   "Kunci perangkat berhasil diganti, simpan sekarang karena tidak akan ditampilkan lagi",
+	"Device key revoked successfully":                                               "Kunci perangkat berhasil dicabut",
+	"If the account exists, password reset instructions have been sent":             "Jika akun terdaftar, petunjuk reset kata sandi telah dikirim",
+	"Kios created successfully":                                                     "Kios berhasil dibuat",
+	"Kios deleted successfully":                                                     "Kios berhasil dihapus",
+	"Kios updated successfully":                                                     "Kios berhasil diperbarui",
+	"Login unlocked successfully":                                                   "Kunci login berhasil dibuka",
+	"Menu created successfully":                                                     "Menu berhasil dibuat",
+	"Menu deleted successfully":                                                     "Menu berhasil dihapus",
+	"Menu updated successfully":                                                     "Menu berhasil diperbarui",
+	"Order created successfully":                                                    "Pesanan berhasil dibuat",
+	"Order status updated successfully":                                             "Status pesanan berhasil diperbarui",
+	"Password changed successfully, other sessions have been signed out":            "Kata sandi berhasil diganti, sesi lain telah dikeluarkan",
+	"Password reset successfully, please log in with your new password":             "Kata sandi berhasil direset, silakan login dengan kata sandi baru",
+	"Recovery codes regenerated successfully":                                       "Kode pemulihan berhasil dibuat ulang",
+	"Scan the provisioning URI with an authenticator app, then confirm with a code": "Pindai URI dengan aplikasi autentikator, lalu konfirmasi dengan kode",
+	"Two-factor authentication disabled successfully":                               "Autentikasi dua faktor berhasil dinonaktifkan",
+	"Two-factor authentication enabled successfully":                                "Autentikasi dua faktor berhasil diaktifkan",
+	"Two-factor authentication reset successfully":                                  "Autentikasi dua faktor berhasil direset",
+	"User created successfully":                                                     "Pengguna berhasil dibuat",
+	"User updated successfully":                                                     "Pengguna berhasil diperbarui",
+}
@@ -0,0 +1,72 @@
+// Package logging sets up structured logging with log/slog and carries the
+// logger of a request, annotated with its request ID and caller, through
+// context.Context.
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+
+	"foodcourt-backend/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// New creates the logger described by the configuration. Without a level or
+// format, debug mode logs everything as text and other modes log info and
+// above as JSON.
+func New(cfg config.LogConfig, ginMode string, w io.Writer) *slog.Logger {
+	level := slog.LevelInfo
+	if cfg.Level == "" && ginMode == gin.DebugMode {
+		level = slog.LevelDebug
+	}
+	if cfg.Level != "" {
+		// The level is checked when the configuration is loaded
+		_ = level.UnmarshalText([]byte(cfg.Level))
+	}
+
+	format := cfg.Format
+	if format == "" {
+		format = "json"
+		if ginMode == gin.DebugMode {
+			format = "text"
+		}
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+	if strings.EqualFold(format, "text") {
+		return slog.New(slog.NewTextHandler(w, opts))
+	}
+	return slog.New(slog.NewJSONHandler(w, opts))
+}
+
+type loggerKey struct{}
+type requestIDKey struct{}
+
+// FromContext returns the logger of ctx, or the default logger
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// With returns a copy of ctx whose logger adds the given attributes
+func With(ctx context.Context, args ...any) context.Context {
+	return context.WithValue(ctx, loggerKey{}, FromContext(ctx).With(args...))
+}
+
+// WithRequestID returns a copy of ctx carrying the request ID, which its
+// logger adds to every record
+func WithRequestID(ctx context.Context, id string) context.Context {
+	ctx = context.WithValue(ctx, requestIDKey{}, id)
+	return With(ctx, "request_id", id)
+}
+
+// RequestID returns the request ID of ctx, if any
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
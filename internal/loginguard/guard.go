@@ -0,0 +1,248 @@
+// Package loginguard throttles failed logins per username and per client IP.
+package loginguard
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"foodcourt-backend/internal/config"
+)
+
+// State is the throttling state of a single username or IP
+type State struct {
+	Failures     int
+	BlockedUntil time.Time
+}
+
+// Policy sets how many attempts a key may make and how long each attempt
+// blocks the next ones once it fails
+type Policy struct {
+	MaxAttempts     int
+	BackoffAfter    int
+	BackoffBase     time.Duration
+	LockoutDuration time.Duration
+	Window          time.Duration // Counters reset after this long without attempts
+}
+
+// Store persists attempt counters. Implementations must be safe for concurrent use.
+type Store interface {
+	Get(ctx context.Context, key string) (State, error)
+	// Attempt counts an attempt against key unless it is blocked at now, and
+	// blocks key for the delay policy gives the new count, in one atomic step.
+	// It returns the resulting state and whether the attempt was counted.
+	Attempt(ctx context.Context, key string, policy Policy, now time.Time) (State, bool, error)
+	// Release takes back a counted attempt and lifts the block it set, unless
+	// another attempt has replaced that block since
+	Release(ctx context.Context, key string, blockedUntil time.Time, now time.Time) error
+	Reset(ctx context.Context, key string) error
+}
+
+// BlockedError is returned when a login attempt is rejected before checking credentials
+type BlockedError struct {
+	RetryAfter time.Duration
+	Locked     bool // true for a full lockout, false for a backoff delay
+}
+
+// RetryAfterSeconds rounds the remaining block up to whole seconds, as used by the Retry-After header
+func (e *BlockedError) RetryAfterSeconds() int {
+	return int(math.Ceil(e.RetryAfter.Seconds()))
+}
+
+func (e *BlockedError) Error() string {
+	if e.Locked {
+		return fmt.Sprintf("account temporarily locked, retry after %s", e.RetryAfter)
+	}
+	return fmt.Sprintf("too many failed attempts, retry after %s", e.RetryAfter)
+}
+
+type Guard struct {
+	store Store
+	cfg   config.LoginGuardConfig
+	now   func() time.Time
+}
+
+func New(store Store, cfg config.LoginGuardConfig) *Guard {
+	return &Guard{
+		store: store,
+		cfg:   cfg,
+		now:   time.Now,
+	}
+}
+
+func userKey(username string) string {
+	return "user:" + strings.ToLower(strings.TrimSpace(username))
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
+
+// Check returns a *BlockedError when the username or IP may not attempt a login right now
+func (g *Guard) Check(ctx context.Context, username, ip string) error {
+	now := g.now()
+	var blocked *BlockedError
+
+	for _, key := range []string{userKey(username), ipKey(ip)} {
+		state, err := g.store.Get(ctx, key)
+		if err != nil {
+			return err
+		}
+		if state.BlockedUntil.After(now) {
+			blocked = g.longer(blocked, key, state, now)
+		}
+	}
+
+	if blocked != nil {
+		return blocked
+	}
+	return nil
+}
+
+// Attempt counts a login attempt against the username and IP before the
+// credentials are checked, so that parallel attempts cannot get past the
+// limits. Until the attempt is known to be valid it is treated as failed:
+// it blocks further attempts for the backoff or lockout its failure earns.
+//
+// It returns a *BlockedError, and counts nothing, when the username or IP may
+// not attempt a login right now. On other errors the returned attempt holds
+// whatever was counted so far.
+func (g *Guard) Attempt(ctx context.Context, username, ip string) (*Attempt, error) {
+	now := g.now()
+	attempt := &Attempt{guard: g}
+	var blocked *BlockedError
+
+	for _, key := range []string{userKey(username), ipKey(ip)} {
+		policy := g.policy(key)
+		state, counted, err := g.store.Attempt(ctx, key, policy, now)
+		if err != nil {
+			return attempt, err
+		}
+		if !counted {
+			blocked = g.longer(blocked, key, state, now)
+			continue
+		}
+
+		attempt.counted = append(attempt.counted, countedAttempt{key: key, blockedUntil: state.BlockedUntil})
+		if delay := policy.delay(state.Failures); delay > 0 && (attempt.penalty == nil || delay > attempt.penalty.RetryAfter) {
+			attempt.penalty = &BlockedError{RetryAfter: delay, Locked: policy.locked(state.Failures)}
+		}
+	}
+
+	if blocked != nil {
+		// A rejected attempt does not count against the other key either
+		if err := attempt.release(ctx); err != nil {
+			return attempt, err
+		}
+		return nil, blocked
+	}
+	return attempt, nil
+}
+
+// Succeed clears the failure counter of the username. The IP counter is kept so that
+// one valid account cannot be used to reset throttling for guesses against others.
+func (g *Guard) Succeed(ctx context.Context, username string) error {
+	return g.store.Reset(ctx, userKey(username))
+}
+
+// Unlock clears the counters of a username and, if given, an IP address
+func (g *Guard) Unlock(ctx context.Context, username, ip string) error {
+	if username != "" {
+		if err := g.store.Reset(ctx, userKey(username)); err != nil {
+			return err
+		}
+	}
+	if ip != "" {
+		if err := g.store.Reset(ctx, ipKey(ip)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// longer returns the longer of blocked and the block of key in state
+func (g *Guard) longer(blocked *BlockedError, key string, state State, now time.Time) *BlockedError {
+	retryAfter := state.BlockedUntil.Sub(now)
+	if blocked != nil && blocked.RetryAfter >= retryAfter {
+		return blocked
+	}
+	return &BlockedError{
+		RetryAfter: retryAfter,
+		Locked:     g.policy(key).locked(state.Failures),
+	}
+}
+
+func (g *Guard) policy(key string) Policy {
+	maxAttempts := g.cfg.MaxAttempts
+	if strings.HasPrefix(key, "ip:") {
+		maxAttempts = g.cfg.IPMaxAttempts
+	}
+	return Policy{
+		MaxAttempts:     maxAttempts,
+		BackoffAfter:    g.cfg.BackoffAfter,
+		BackoffBase:     g.cfg.BackoffBase,
+		LockoutDuration: g.cfg.LockoutDuration,
+		Window:          g.cfg.Window,
+	}
+}
+
+// Attempt is a login attempt counted by Guard.Attempt
+type Attempt struct {
+	guard   *Guard
+	counted []countedAttempt
+	penalty *BlockedError
+}
+
+type countedAttempt struct {
+	key          string
+	blockedUntil time.Time // The block set by the attempt, zero if none
+}
+
+// Failed returns the backoff or lockout the failed attempt has earned, if any.
+// It was applied when the attempt was counted, so there is nothing to store.
+func (a *Attempt) Failed() *BlockedError {
+	return a.penalty
+}
+
+// Verified takes the attempt back once the credentials turned out valid, so
+// that it counts neither as a failure of the username nor of the IP
+func (a *Attempt) Verified(ctx context.Context) error {
+	return a.release(ctx)
+}
+
+func (a *Attempt) release(ctx context.Context) error {
+	now := a.guard.now()
+	for _, c := range a.counted {
+		if err := a.guard.store.Release(ctx, c.key, c.blockedUntil, now); err != nil {
+			return err
+		}
+	}
+	a.counted = nil
+	a.penalty = nil
+	return nil
+}
+
+func (p Policy) locked(failures int) bool {
+	return failures >= p.MaxAttempts
+}
+
+// delay returns how long a key is blocked after failures failed attempts
+func (p Policy) delay(failures int) time.Duration {
+	if p.locked(failures) {
+		return p.LockoutDuration
+	}
+	if failures < p.BackoffAfter {
+		return 0
+	}
+
+	delay := p.BackoffBase
+	for i := p.BackoffAfter; i < failures && delay < p.LockoutDuration; i++ {
+		delay *= 2
+	}
+	if delay > p.LockoutDuration {
+		delay = p.LockoutDuration
+	}
+	return delay
+}
@@ -0,0 +1,214 @@
+package loginguard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"foodcourt-backend/internal/config"
+)
+
+const testIP = "10.0.0.1"
+
+// clock is a settable time shared by a guard and its store
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func newTestGuard(t *testing.T) (*Guard, *clock) {
+	t.Helper()
+
+	c := &clock{now: time.Date(2026, 3, 16, 12, 0, 0, 0, time.UTC)}
+	store := NewMemoryStore()
+	store.now = func() time.Time { return c.now }
+
+	guard := New(store, config.LoginGuardConfig{
+		MaxAttempts:     5,
+		IPMaxAttempts:   8,
+		BackoffAfter:    3,
+		BackoffBase:     time.Minute,
+		LockoutDuration: 15 * time.Minute,
+		Window:          time.Hour,
+	})
+	guard.now = func() time.Time { return c.now }
+	return guard, c
+}
+
+// fail makes an attempt that is expected through and lets it fail
+func fail(t *testing.T, g *Guard, username, ip string) *BlockedError {
+	t.Helper()
+
+	attempt, err := g.Attempt(context.Background(), username, ip)
+	if err != nil {
+		t.Fatalf("expected the attempt through, got %v", err)
+	}
+	return attempt.Failed()
+}
+
+// expectBlocked checks that an attempt is rejected for retryAfter
+func expectBlocked(t *testing.T, g *Guard, username, ip string, retryAfter time.Duration, locked bool) {
+	t.Helper()
+
+	_, err := g.Attempt(context.Background(), username, ip)
+	var blocked *BlockedError
+	if !errors.As(err, &blocked) {
+		t.Fatalf("expected the attempt blocked, got %v", err)
+	}
+	if blocked.RetryAfter != retryAfter || blocked.Locked != locked {
+		t.Fatalf("expected a block of %s (locked %v), got %+v", retryAfter, locked, blocked)
+	}
+}
+
+func TestBackoffDoublesAfterEveryFailure(t *testing.T) {
+	g, c := newTestGuard(t)
+
+	for i := 0; i < 2; i++ {
+		if blocked := fail(t, g, "padang_user", testIP); blocked != nil {
+			t.Fatalf("expected no backoff before the third failure, got %+v", blocked)
+		}
+	}
+
+	for _, delay := range []time.Duration{time.Minute, 2 * time.Minute} {
+		if blocked := fail(t, g, "padang_user", testIP); blocked == nil || blocked.RetryAfter != delay || blocked.Locked {
+			t.Fatalf("expected a backoff of %s, got %+v", delay, blocked)
+		}
+		expectBlocked(t, g, "padang_user", testIP, delay, false)
+
+		c.advance(delay / 2)
+		expectBlocked(t, g, "padang_user", testIP, delay/2, false)
+		if err := g.Check(context.Background(), "Padang_User ", "10.0.0.2"); err == nil {
+			t.Fatal("expected usernames to share their counter whatever their case")
+		}
+		c.advance(delay / 2)
+	}
+}
+
+func TestLockoutExpires(t *testing.T) {
+	g, c := newTestGuard(t)
+
+	for i := 0; i < 4; i++ {
+		fail(t, g, "padang_user", testIP)
+		c.advance(15 * time.Minute)
+	}
+	if blocked := fail(t, g, "padang_user", testIP); blocked == nil || blocked.RetryAfter != 15*time.Minute || !blocked.Locked {
+		t.Fatalf("expected a lockout on the fifth failure, got %+v", blocked)
+	}
+	expectBlocked(t, g, "padang_user", testIP, 15*time.Minute, true)
+
+	c.advance(15 * time.Minute)
+	attempt, err := g.Attempt(context.Background(), "padang_user", testIP)
+	if err != nil {
+		t.Fatalf("expected the lockout over, got %v", err)
+	}
+	if blocked := attempt.Failed(); blocked == nil || !blocked.Locked {
+		t.Fatalf("expected the counter kept within the window, got %+v", blocked)
+	}
+
+	// The counter starts over after a window without attempts
+	c.advance(time.Hour)
+	if blocked := fail(t, g, "padang_user", testIP); blocked != nil {
+		t.Fatalf("expected the counter reset, got %+v", blocked)
+	}
+}
+
+func TestUnlock(t *testing.T) {
+	g, c := newTestGuard(t)
+	ctx := context.Background()
+
+	for i := 0; i < 5; i++ {
+		c.advance(15 * time.Minute)
+		fail(t, g, "padang_user", fmt.Sprintf("10.0.1.%d", i))
+	}
+	expectBlocked(t, g, "padang_user", testIP, 15*time.Minute, true)
+
+	if err := g.Unlock(ctx, "padang_user", ""); err != nil {
+		t.Fatalf("unlock: %v", err)
+	}
+	if blocked := fail(t, g, "padang_user", testIP); blocked != nil {
+		t.Fatalf("expected the username unlocked, got %+v", blocked)
+	}
+
+	// Guesses against many usernames lock the IP out
+	for i := 1; i < 8; i++ {
+		c.advance(15 * time.Minute)
+		fail(t, g, fmt.Sprintf("user%d", i), testIP)
+	}
+	expectBlocked(t, g, "cashier", testIP, 15*time.Minute, true)
+
+	if err := g.Unlock(ctx, "", testIP); err != nil {
+		t.Fatalf("unlock: %v", err)
+	}
+	if err := g.Check(ctx, "cashier", testIP); err != nil {
+		t.Fatalf("expected the IP unlocked, got %v", err)
+	}
+}
+
+func TestVerifiedAttemptsAreNotCounted(t *testing.T) {
+	g, _ := newTestGuard(t)
+	ctx := context.Background()
+
+	fail(t, g, "padang_user", "10.0.1.1")
+	fail(t, g, "padang_user", "10.0.1.2")
+
+	// A valid password on the third attempt lifts the backoff it would have earned
+	attempt, err := g.Attempt(ctx, "padang_user", testIP)
+	if err != nil {
+		t.Fatalf("attempt: %v", err)
+	}
+	if blocked := attempt.Failed(); blocked == nil || blocked.RetryAfter != time.Minute {
+		t.Fatalf("expected the attempt to hold a backoff until verified, got %+v", blocked)
+	}
+	if err := g.Check(ctx, "padang_user", "10.0.1.3"); err == nil {
+		t.Fatal("expected further attempts blocked while the attempt is checked")
+	}
+	if err := attempt.Verified(ctx); err != nil {
+		t.Fatalf("verified: %v", err)
+	}
+	if err := g.Check(ctx, "padang_user", "10.0.1.3"); err != nil {
+		t.Fatalf("expected the backoff lifted, got %v", err)
+	}
+
+	// Neither the username nor the IP counted it
+	state, err := g.store.Get(ctx, ipKey(testIP))
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if state.Failures != 0 {
+		t.Fatalf("expected no failures of the IP, got %d", state.Failures)
+	}
+	if blocked := fail(t, g, "padang_user", "10.0.1.3"); blocked == nil || blocked.RetryAfter != time.Minute {
+		t.Fatalf("expected the first backoff on the third failure, got %+v", blocked)
+	}
+}
+
+func TestParallelAttemptsStopAtTheBackoff(t *testing.T) {
+	g, _ := newTestGuard(t)
+
+	// None of the attempts has failed yet when the others are made
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	allowed := 0
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(ip string) {
+			defer wg.Done()
+			if _, err := g.Attempt(context.Background(), "padang_user", ip); err == nil {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}(fmt.Sprintf("10.0.1.%d", i))
+	}
+	wg.Wait()
+
+	if allowed != 3 {
+		t.Fatalf("expected 3 attempts before the backoff, got %d", allowed)
+	}
+}
@@ -0,0 +1,120 @@
+package loginguard
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const pruneInterval = time.Minute
+
+type memoryEntry struct {
+	State
+	expiresAt time.Time
+}
+
+// MemoryStore keeps counters in process memory. It is used when Redis is not configured.
+type MemoryStore struct {
+	mu        sync.Mutex
+	entries   map[string]*memoryEntry
+	lastPrune time.Time
+	now       func() time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		entries: make(map[string]*memoryEntry),
+		now:     time.Now,
+	}
+}
+
+func (s *MemoryStore) Get(ctx context.Context, key string) (State, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry := s.entry(key, s.now())
+	if entry == nil {
+		return State{}, nil
+	}
+	return entry.State, nil
+}
+
+func (s *MemoryStore) Attempt(ctx context.Context, key string, policy Policy, now time.Time) (State, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.prune(now)
+
+	entry := s.entry(key, now)
+	if entry == nil {
+		entry = &memoryEntry{}
+		s.entries[key] = entry
+	}
+	if entry.BlockedUntil.After(now) {
+		return entry.State, false, nil
+	}
+
+	entry.Failures++
+	entry.BlockedUntil = time.Time{}
+	entry.expiresAt = now.Add(policy.Window)
+	if delay := policy.delay(entry.Failures); delay > 0 {
+		entry.BlockedUntil = now.Add(delay)
+		if entry.BlockedUntil.After(entry.expiresAt) {
+			entry.expiresAt = entry.BlockedUntil
+		}
+	}
+
+	return entry.State, true, nil
+}
+
+func (s *MemoryStore) Release(ctx context.Context, key string, blockedUntil time.Time, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry := s.entry(key, now)
+	if entry == nil {
+		return nil
+	}
+	if entry.Failures > 0 {
+		entry.Failures--
+	}
+	if !blockedUntil.IsZero() && entry.BlockedUntil.Equal(blockedUntil) {
+		entry.BlockedUntil = time.Time{}
+	}
+	return nil
+}
+
+func (s *MemoryStore) Reset(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, key)
+	return nil
+}
+
+// entry returns the live entry for key, dropping it if expired. Callers must hold mu.
+func (s *MemoryStore) entry(key string, now time.Time) *memoryEntry {
+	entry, ok := s.entries[key]
+	if !ok {
+		return nil
+	}
+	if !now.Before(entry.expiresAt) {
+		delete(s.entries, key)
+		return nil
+	}
+	return entry
+}
+
+// prune drops expired entries so that scans from many IPs don't grow the map forever. Callers must hold mu.
+func (s *MemoryStore) prune(now time.Time) {
+	if now.Sub(s.lastPrune) < pruneInterval {
+		return
+	}
+	s.lastPrune = now
+
+	for key, entry := range s.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(s.entries, key)
+		}
+	}
+}
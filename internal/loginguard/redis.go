@@ -0,0 +1,116 @@
+package loginguard
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const redisKeyPrefix = "loginguard:"
+
+// attemptScript counts an attempt and blocks the key like MemoryStore.Attempt.
+// The failures key holds the count and expires after the window, the blocked
+// key holds the end of the block in milliseconds and expires with it. ARGV
+// holds the time, the window and then the delay after 1 to MaxAttempts
+// attempts in milliseconds, so the backoff is only computed in Go.
+var attemptScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+
+local blocked = tonumber(redis.call("GET", KEYS[2]) or "0")
+if blocked > now then
+	local failures = tonumber(redis.call("GET", KEYS[1]) or "0")
+	return {0, failures, blocked}
+end
+
+local failures = redis.call("INCR", KEYS[1])
+redis.call("PEXPIRE", KEYS[1], ARGV[2])
+
+local delay = tonumber(ARGV[math.min(failures, #ARGV - 2) + 2])
+if delay > 0 then
+	blocked = now + delay
+	redis.call("SET", KEYS[2], blocked, "PX", delay)
+else
+	blocked = 0
+end
+return {1, failures, blocked}
+`)
+
+// releaseScript takes back an attempt like MemoryStore.Release. ARGV[1] is
+// the end of the block the attempt set in milliseconds, or 0.
+var releaseScript = redis.NewScript(`
+local failures = tonumber(redis.call("GET", KEYS[1]) or "0")
+if failures > 0 then
+	redis.call("DECR", KEYS[1])
+end
+if ARGV[1] ~= "0" and redis.call("GET", KEYS[2]) == ARGV[1] then
+	redis.call("DEL", KEYS[2])
+end
+return 0
+`)
+
+// RedisStore shares counters between server instances through Redis
+type RedisStore struct {
+	client *redis.Client
+}
+
+func NewRedisStore(client *redis.Client) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+func (s *RedisStore) Get(ctx context.Context, key string) (State, error) {
+	values, err := s.client.MGet(ctx, failuresKey(key), blockedKey(key)).Result()
+	if err != nil {
+		return State{}, err
+	}
+
+	var state State
+	if v, ok := values[0].(string); ok {
+		state.Failures, _ = strconv.Atoi(v)
+	}
+	if v, ok := values[1].(string); ok {
+		if ms, err := strconv.ParseInt(v, 10, 64); err == nil {
+			state.BlockedUntil = time.UnixMilli(ms)
+		}
+	}
+	return state, nil
+}
+
+func (s *RedisStore) Attempt(ctx context.Context, key string, policy Policy, now time.Time) (State, bool, error) {
+	args := []interface{}{now.UnixMilli(), policy.Window.Milliseconds()}
+	for failures := 1; failures <= policy.MaxAttempts; failures++ {
+		args = append(args, policy.delay(failures).Milliseconds())
+	}
+
+	values, err := attemptScript.Run(ctx, s.client, []string{failuresKey(key), blockedKey(key)}, args...).Int64Slice()
+	if err != nil {
+		return State{}, false, err
+	}
+
+	state := State{Failures: int(values[1])}
+	if values[2] > 0 {
+		state.BlockedUntil = time.UnixMilli(values[2])
+	}
+	return state, values[0] == 1, nil
+}
+
+func (s *RedisStore) Release(ctx context.Context, key string, blockedUntil time.Time, now time.Time) error {
+	var until int64
+	if !blockedUntil.IsZero() {
+		until = blockedUntil.UnixMilli()
+	}
+	return releaseScript.Run(ctx, s.client, []string{failuresKey(key), blockedKey(key)}, until).Err()
+}
+
+func (s *RedisStore) Reset(ctx context.Context, key string) error {
+	return s.client.Del(ctx, failuresKey(key), blockedKey(key)).Err()
+}
+
+func failuresKey(key string) string {
+	return redisKeyPrefix + key + ":failures"
+}
+
+func blockedKey(key string) string {
+	return redisKeyPrefix + key + ":blocked"
+}
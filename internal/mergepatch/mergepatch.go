@@ -0,0 +1,55 @@
+// Package mergepatch applies JSON merge patches (RFC 7396). A patch is a JSON
+// document shaped like its target: members set to null are removed, objects
+// are merged recursively and any other value replaces the one in the target.
+package mergepatch
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// Apply returns doc patched with patch. Both must be valid JSON.
+func Apply(doc, patch []byte) ([]byte, error) {
+	target, err := decode(doc)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := decode(patch)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(merge(target, changes))
+}
+
+// decode parses a JSON document, keeping numbers as they are written
+func decode(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// merge is the MergePatch function of RFC 7396
+func merge(target, patch interface{}) interface{} {
+	changes, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	object, ok := target.(map[string]interface{})
+	if !ok {
+		object = make(map[string]interface{}, len(changes))
+	}
+	for name, value := range changes {
+		if value == nil {
+			delete(object, name)
+			continue
+		}
+		object[name] = merge(object[name], value)
+	}
+	return object
+}
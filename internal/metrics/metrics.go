@@ -0,0 +1,182 @@
+// Package metrics exposes Prometheus metrics of HTTP requests, database
+// queries and the business: orders and kios queues.
+package metrics
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"foodcourt-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "foodcourt"
+
+// Metrics holds the collectors of the server in its own registry
+type Metrics struct {
+	registry *prometheus.Registry
+
+	requests        *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+	queryDuration   *prometheus.HistogramVec
+
+	ordersCreated   *prometheus.CounterVec
+	ordersPaid      *prometheus.CounterVec
+	ordersCancelled *prometheus.CounterVec
+	paidToReady     *prometheus.HistogramVec
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests by method, route and status code.",
+		}, []string{"method", "route", "status"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by method, route and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		queryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "db_query_duration_seconds",
+			Help:      "Database query latency by operation and table.",
+			Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"operation", "table"}),
+		ordersCreated: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "orders_created_total",
+			Help:      "Orders created by kios.",
+		}, []string{"kios_id"}),
+		ordersPaid: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "orders_paid_total",
+			Help:      "Orders paid by kios.",
+		}, []string{"kios_id"}),
+		ordersCancelled: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "orders_cancelled_total",
+			Help:      "Orders cancelled by kios.",
+		}, []string{"kios_id"}),
+		paidToReady: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "order_paid_to_ready_seconds",
+			Help:      "Time from payment until an order is ready, by kios. Divide the sum by the count for the average.",
+			Buckets:   []float64{60, 120, 180, 300, 420, 600, 900, 1200, 1800, 2700, 3600},
+		}, []string{"kios_id"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requests,
+		m.requestDuration,
+		m.queryDuration,
+		m.ordersCreated,
+		m.ordersPaid,
+		m.ordersCancelled,
+		m.paidToReady,
+	)
+	return m
+}
+
+// Handler serves the metrics in the Prometheus text format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// Middleware counts requests and their latency. Requests are labelled with
+// the route pattern, such as /api/v1/orders/:id, so IDs do not multiply series.
+func (m *Metrics) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		status := strconv.Itoa(c.Writer.Status())
+		m.requests.WithLabelValues(c.Request.Method, route, status).Inc()
+		m.requestDuration.WithLabelValues(c.Request.Method, route, status).Observe(time.Since(start).Seconds())
+	}
+}
+
+// OrderCreated counts a new order
+func (m *Metrics) OrderCreated(order *models.Order) {
+	m.ordersCreated.WithLabelValues(kiosLabel(order.KiosID)).Inc()
+}
+
+// OrderStatusChanged counts payments and cancellations and records how long
+// paid orders took to become ready
+func (m *Metrics) OrderStatusChanged(order *models.Order, from models.OrderStatus) {
+	if order.Status == from {
+		return
+	}
+	kios := kiosLabel(order.KiosID)
+
+	switch order.Status {
+	case models.StatusPaid:
+		m.ordersPaid.WithLabelValues(kios).Inc()
+	case models.StatusCancelled:
+		m.ordersCancelled.WithLabelValues(kios).Inc()
+	case models.StatusReady:
+		if order.PaidAt != nil && order.ReadyAt != nil {
+			m.paidToReady.WithLabelValues(kios).Observe(order.ReadyAt.Sub(*order.PaidAt).Seconds())
+		}
+	}
+}
+
+// QueueLengthFunc counts the orders waiting in the queue of every kios
+type QueueLengthFunc func(ctx context.Context) (map[uint]int64, error)
+
+// RegisterQueueLength exposes the queue length of every kios, counted when
+// the metrics are scraped
+func (m *Metrics) RegisterQueueLength(count QueueLengthFunc) {
+	m.registry.MustRegister(&queueCollector{
+		count: count,
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "queue_length"),
+			"Orders paid but not yet collected, by kios.",
+			[]string{"kios_id"}, nil,
+		),
+	})
+}
+
+type queueCollector struct {
+	count QueueLengthFunc
+	desc  *prometheus.Desc
+}
+
+func (q *queueCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- q.desc
+}
+
+func (q *queueCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	lengths, err := q.count(ctx)
+	if err != nil {
+		slog.Error("Failed to count queue lengths for metrics", "error", err)
+		ch <- prometheus.NewInvalidMetric(q.desc, err)
+		return
+	}
+	for kiosID, length := range lengths {
+		ch <- prometheus.MustNewConstMetric(q.desc, prometheus.GaugeValue, float64(length), kiosLabel(kiosID))
+	}
+}
+
+func kiosLabel(id uint) string {
+	return strconv.FormatUint(uint64(id), 10)
+}
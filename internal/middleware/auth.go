@@ -4,50 +4,69 @@ import (
 	"net/http"
 	"strings"
 
+	"foodcourt-backend/internal/apierror"
 	"foodcourt-backend/internal/models"
 	"foodcourt-backend/pkg/auth"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type AuthMiddleware struct {
 	jwtService *auth.JWTService
+	db         *gorm.DB
 }
 
-func NewAuthMiddleware(jwtService *auth.JWTService) *AuthMiddleware {
+func NewAuthMiddleware(jwtService *auth.JWTService, db *gorm.DB) *AuthMiddleware {
 	return &AuthMiddleware{
 		jwtService: jwtService,
+		db:         db,
 	}
 }
 
 func (a *AuthMiddleware) RequireAuth() gin.HandlerFunc {
+	return a.requireAuth(false)
+}
+
+// RequireAuthForPasswordChange also accepts users who must change their password
+// before anything else. It is only used by the routes they need to do so.
+func (a *AuthMiddleware) RequireAuthForPasswordChange() gin.HandlerFunc {
+	return a.requireAuth(true)
+}
+
+func (a *AuthMiddleware) requireAuth(allowPasswordChange bool) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "Authorization header required",
-			})
-			c.Abort()
+			apierror.Abort(c, apierror.Unauthenticated("Authorization header required"))
 			return
 		}
 
 		// Extract token from "Bearer <token>"
 		tokenParts := strings.Split(authHeader, " ")
 		if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "Invalid authorization header format",
-			})
-			c.Abort()
+			apierror.Abort(c, apierror.Unauthenticated("Invalid authorization header format"))
 			return
 		}
 
 		token := tokenParts[1]
 		claims, err := a.jwtService.ValidateToken(token)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "Invalid or expired token",
-			})
-			c.Abort()
+			apierror.Abort(c, apierror.Unauthenticated("Invalid or expired token"))
+			return
+		}
+
+		// Reject tokens of deactivated users and tokens issued before a password change
+		var user models.User
+		if err := a.db.WithContext(c.Request.Context()).Select("id", "is_active", "token_version", "must_change_password").First(&user, claims.UserID).Error; err != nil ||
+			!user.IsActive || user.TokenVersion != claims.Version {
+			apierror.Abort(c, apierror.New(http.StatusUnauthorized, apierror.CodeSessionRevoked, "Session has been revoked, please log in again"))
+			return
+		}
+
+		if user.MustChangePassword && !allowPasswordChange {
+			apierror.Abort(c, apierror.New(http.StatusForbidden, apierror.CodePasswordChangeRequired,
+				"Password change required, change it with POST /api/v1/me/password before using other endpoints"))
 			return
 		}
 
@@ -56,6 +75,11 @@ func (a *AuthMiddleware) RequireAuth() gin.HandlerFunc {
 		c.Set("username", claims.Username)
 		c.Set("role", claims.Role)
 		c.Set("kios_id", claims.KiosID)
+		if claims.KiosID != nil {
+			annotateLog(c, "user_id", claims.UserID, "kios_id", *claims.KiosID)
+		} else {
+			annotateLog(c, "user_id", claims.UserID)
+		}
 
 		c.Next()
 	}
@@ -65,10 +89,7 @@ func (a *AuthMiddleware) RequireRole(roles ...models.UserRole) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userRole, exists := c.Get("role")
 		if !exists {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "User role not found in context",
-			})
-			c.Abort()
+			apierror.Abort(c, apierror.Unauthenticated("User role not found in context"))
 			return
 		}
 
@@ -80,10 +101,7 @@ func (a *AuthMiddleware) RequireRole(roles ...models.UserRole) gin.HandlerFunc {
 			}
 		}
 
-		c.JSON(http.StatusForbidden, gin.H{
-			"error": "Insufficient permissions",
-		})
-		c.Abort()
+		apierror.Abort(c, apierror.Forbidden("Insufficient permissions"))
 	}
 }
 
@@ -102,10 +120,7 @@ func (a *AuthMiddleware) RequireKiosAccess() gin.HandlerFunc {
 		if role == models.RoleKios {
 			userKiosID, exists := c.Get("kios_id")
 			if !exists || userKiosID == nil {
-				c.JSON(http.StatusForbidden, gin.H{
-					"error": "Kios user must be assigned to a kios",
-				})
-				c.Abort()
+				apierror.Abort(c, apierror.Forbidden("Kios user must be assigned to a kios"))
 				return
 			}
 
@@ -114,10 +129,7 @@ func (a *AuthMiddleware) RequireKiosAccess() gin.HandlerFunc {
 			if requestedKiosID != "" {
 				userKiosIDUint := userKiosID.(*uint)
 				if requestedKiosID != string(rune(*userKiosIDUint)) {
-					c.JSON(http.StatusForbidden, gin.H{
-						"error": "Access denied to this kios",
-					})
-					c.Abort()
+					apierror.Abort(c, apierror.Forbidden("Access denied to this kios"))
 					return
 				}
 			}
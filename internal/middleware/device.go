@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"time"
+
+	"foodcourt-backend/internal/apierror"
+	"foodcourt-backend/internal/logging"
+	"foodcourt-backend/internal/models"
+	"foodcourt-backend/pkg/auth"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// DeviceKeyHeader carries the API key of a registered kiosk device
+const DeviceKeyHeader = "X-Device-Key"
+
+// lastSeenInterval limits how often last-seen timestamps are written for a device
+const lastSeenInterval = time.Minute
+
+type DeviceAuthMiddleware struct {
+	db       *gorm.DB
+	userAuth *AuthMiddleware
+}
+
+func NewDeviceAuthMiddleware(db *gorm.DB, userAuth *AuthMiddleware) *DeviceAuthMiddleware {
+	return &DeviceAuthMiddleware{
+		db:       db,
+		userAuth: userAuth,
+	}
+}
+
+// RequireDevice only accepts requests authenticated with a device API key carrying all given scopes
+func (d *DeviceAuthMiddleware) RequireDevice(scopes ...models.DeviceScope) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		d.authenticate(c, scopes)
+	}
+}
+
+// RequireUserOrDevice accepts either a user JWT or a device API key carrying all given scopes
+func (d *DeviceAuthMiddleware) RequireUserOrDevice(scopes ...models.DeviceScope) gin.HandlerFunc {
+	requireUser := d.userAuth.RequireAuth()
+	return func(c *gin.Context) {
+		if c.GetHeader(DeviceKeyHeader) == "" {
+			requireUser(c)
+			return
+		}
+		d.authenticate(c, scopes)
+	}
+}
+
+func (d *DeviceAuthMiddleware) authenticate(c *gin.Context, scopes []models.DeviceScope) {
+	key := c.GetHeader(DeviceKeyHeader)
+	if key == "" {
+		apierror.Abort(c, apierror.Unauthenticated("Device key required"))
+		return
+	}
+
+	prefix, err := auth.ParseAPIKeyPrefix(key)
+	if err != nil {
+		apierror.Abort(c, apierror.Unauthenticated("Invalid device key"))
+		return
+	}
+
+	var apiKey models.DeviceAPIKey
+	if err := d.db.WithContext(c.Request.Context()).Preload("Device").Where("prefix = ?", prefix).First(&apiKey).Error; err != nil {
+		apierror.Abort(c, apierror.Unauthenticated("Invalid device key"))
+		return
+	}
+
+	now := time.Now()
+	if !auth.CheckAPIKey(apiKey.KeyHash, key) || !apiKey.IsUsable(now) ||
+		apiKey.Device.ID == 0 || !apiKey.Device.IsActive {
+		apierror.Abort(c, apierror.Unauthenticated("Invalid or revoked device key"))
+		return
+	}
+
+	for _, scope := range scopes {
+		if !apiKey.HasScope(scope) {
+			apierror.Abort(c, apierror.Forbidden("Device key lacks required scope"))
+			return
+		}
+	}
+
+	// Set device info in context
+	kiosID := apiKey.Device.KiosID
+	c.Set("device_id", apiKey.DeviceID)
+	c.Set("device_scopes", apiKey.ScopeList())
+	c.Set("kios_id", &kiosID)
+	annotateLog(c, "device_id", apiKey.DeviceID, "kios_id", kiosID)
+
+	// Track last seen, throttled to avoid a write on every poll
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) > lastSeenInterval {
+		d.markSeen(c, &apiKey, now)
+	}
+
+	c.Next()
+}
+
+// markSeen records that the device used apiKey at now. Failures are logged
+// and do not fail the request, which is already authenticated.
+func (d *DeviceAuthMiddleware) markSeen(c *gin.Context, apiKey *models.DeviceAPIKey, now time.Time) {
+	ctx := c.Request.Context()
+	if err := d.db.WithContext(ctx).Model(&models.DeviceAPIKey{}).Where("id = ?", apiKey.ID).
+		UpdateColumn("last_used_at", now).Error; err != nil {
+		logging.FromContext(ctx).WarnContext(ctx, "Failed to record device key use", "error", err)
+	}
+	if err := d.db.WithContext(ctx).Model(&models.Device{}).Where("id = ?", apiKey.DeviceID).
+		UpdateColumns(map[string]interface{}{
+			"last_seen_at": now,
+			"last_seen_ip": c.ClientIP(),
+		}).Error; err != nil {
+		logging.FromContext(ctx).WarnContext(ctx, "Failed to record device last seen", "error", err)
+	}
+}
@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"foodcourt-backend/internal/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Locale negotiates the language of the response from the Accept-Language
+// header and stores it in the request context for handlers and error
+// responses. Requests naming no supported language get fallback.
+func Locale(fallback string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		locale := i18n.Negotiate(c.GetHeader("Accept-Language"), fallback)
+		c.Request = c.Request.WithContext(i18n.WithLocale(c.Request.Context(), locale))
+		c.Header("Content-Language", locale)
+		c.Header("Vary", "Accept-Language")
+		c.Next()
+	}
+}
@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"foodcourt-backend/internal/apierror"
+	"foodcourt-backend/internal/logging"
+	"foodcourt-backend/internal/ratelimit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimit takes a token from the bucket of the client under policy and
+// rejects the request with 429 when there is none. Clients are the user or
+// device authenticated by an earlier middleware, or the client IP. Responses
+// carry the RateLimit-* headers of the IETF draft, and Retry-After when
+// rejected. When the store fails, requests are let through.
+func RateLimit(limiter *ratelimit.Limiter, policy ratelimit.Policy) gin.HandlerFunc {
+	policyHeader := fmt.Sprintf("%d;w=%d", policy.Requests, int(policy.Window.Seconds()))
+
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		result, err := limiter.Allow(ctx, policy, rateLimitClient(c))
+		if err != nil {
+			logging.FromContext(ctx).WarnContext(ctx, "Rate limit unavailable, request let through",
+				"policy", policy.Name, "error", err)
+			c.Next()
+			return
+		}
+
+		c.Header("RateLimit-Policy", policyHeader)
+		c.Header("RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("RateLimit-Reset", strconv.Itoa(result.ResetSeconds()))
+		if !result.Allowed {
+			apierror.Abort(c, apierror.TooManyRequests(result.RetryAfterSeconds()))
+			return
+		}
+		c.Next()
+	}
+}
+
+// rateLimitClient identifies the caller by user, device or IP
+func rateLimitClient(c *gin.Context) string {
+	if userID, ok := c.Get("user_id"); ok {
+		return fmt.Sprintf("user:%v", userID)
+	}
+	if deviceID, ok := c.Get("device_id"); ok {
+		return fmt.Sprintf("device:%v", deviceID)
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// BodyLimit rejects request bodies larger than maxBytes with 413. Bodies
+// announcing their length are rejected up front, others once binding reads
+// past the limit.
+func BodyLimit(maxBytes int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.ContentLength > maxBytes {
+			apierror.Abort(c, apierror.PayloadTooLarge(maxBytes))
+			return
+		}
+		if c.Request.Body != nil && c.Request.Body != http.NoBody {
+			c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes)
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"strings"
+
+	"foodcourt-backend/internal/apierror"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireToken requires the bearer token, e.g. of a metrics scraper. An empty
+// token lets every request through.
+func RequireToken(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			c.Next()
+			return
+		}
+
+		given := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			apierror.Abort(c, apierror.Unauthenticated("Invalid token"))
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrAuditLogImmutable = errors.New("audit log entries are append-only")
+
+type AuditLog struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	ActorID       *uint     `json:"actor_id" gorm:"index"`
+	ActorUsername string    `json:"actor_username"`
+	ActorDeviceID *uint     `json:"actor_device_id"`
+	Action        string    `json:"action" gorm:"not null;index"`
+	EntityType    string    `json:"entity_type" gorm:"index:idx_audit_entity"`
+	EntityID      *uint     `json:"entity_id" gorm:"index:idx_audit_entity"`
+	Changes       string    `json:"changes"`  // JSON object of field -> {"from", "to"}
+	Metadata      string    `json:"metadata"` // JSON
+	IPAddress     string    `json:"ip_address"`
+	UserAgent     string    `json:"user_agent"`
+	RequestMethod string    `json:"request_method"`
+	RequestPath   string    `json:"request_path"`
+	CreatedAt     time.Time `json:"created_at" gorm:"index"`
+}
+
+func (a *AuditLog) BeforeUpdate(tx *gorm.DB) error {
+	return ErrAuditLogImmutable
+}
+
+func (a *AuditLog) BeforeDelete(tx *gorm.DB) error {
+	return ErrAuditLogImmutable
+}
+
+type AuditLogResponse struct {
+	ID            uint            `json:"id"`
+	ActorID       *uint           `json:"actor_id"`
+	ActorUsername string          `json:"actor_username"`
+	ActorDeviceID *uint           `json:"actor_device_id,omitempty"`
+	Action        string          `json:"action"`
+	EntityType    string          `json:"entity_type"`
+	EntityID      *uint           `json:"entity_id"`
+	Changes       json.RawMessage `json:"changes,omitempty"`
+	Metadata      json.RawMessage `json:"metadata,omitempty"`
+	IPAddress     string          `json:"ip_address"`
+	UserAgent     string          `json:"user_agent"`
+	RequestMethod string          `json:"request_method"`
+	RequestPath   string          `json:"request_path"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
+
+func (a *AuditLog) ToResponse() *AuditLogResponse {
+	response := &AuditLogResponse{
+		ID:            a.ID,
+		ActorID:       a.ActorID,
+		ActorUsername: a.ActorUsername,
+		ActorDeviceID: a.ActorDeviceID,
+		Action:        a.Action,
+		EntityType:    a.EntityType,
+		EntityID:      a.EntityID,
+		IPAddress:     a.IPAddress,
+		UserAgent:     a.UserAgent,
+		RequestMethod: a.RequestMethod,
+		RequestPath:   a.RequestPath,
+		CreatedAt:     a.CreatedAt,
+	}
+	if a.Changes != "" {
+		response.Changes = json.RawMessage(a.Changes)
+	}
+	if a.Metadata != "" {
+		response.Metadata = json.RawMessage(a.Metadata)
+	}
+	return response
+}
@@ -0,0 +1,171 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type DeviceType string
+
+const (
+	DeviceKitchenDisplay DeviceType = "kitchen_display"
+	DeviceQueueDisplay   DeviceType = "queue_display"
+	DevicePrinter        DeviceType = "printer"
+)
+
+type DeviceScope string
+
+const (
+	ScopeQueueRead   DeviceScope = "queue:read"   // Membaca antrian kios
+	ScopeOrdersRead  DeviceScope = "orders:read"  // Membaca detail pesanan (struk)
+	ScopeOrdersReady DeviceScope = "orders:ready" // Menandai pesanan siap diambil
+)
+
+type Device struct {
+	ID         uint           `json:"id" gorm:"primaryKey"`
+	KiosID     uint           `json:"kios_id" gorm:"not null;index"`
+	Kios       Kios           `json:"kios" gorm:"foreignKey:KiosID"`
+	Name       string         `json:"name" gorm:"not null"`
+	Type       DeviceType     `json:"type" gorm:"not null"`
+	IsActive   bool           `json:"is_active" gorm:"default:true"`
+	LastSeenAt *time.Time     `json:"last_seen_at"`
+	LastSeenIP string         `json:"last_seen_ip"`
+	APIKeys    []DeviceAPIKey `json:"api_keys,omitempty" gorm:"foreignKey:DeviceID"`
+	CreatedBy  uint           `json:"created_by" gorm:"not null"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+type DeviceAPIKey struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	DeviceID   uint       `json:"device_id" gorm:"not null;index"`
+	Device     Device     `json:"device" gorm:"foreignKey:DeviceID"`
+	Prefix     string     `json:"prefix" gorm:"uniqueIndex;not null"`
+	KeyHash    string     `json:"-" gorm:"not null"`
+	Scopes     string     `json:"scopes" gorm:"not null"` // Dipisahkan koma, mis. "queue:read,orders:ready"
+	LastUsedAt *time.Time `json:"last_used_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func (k *DeviceAPIKey) ScopeList() []DeviceScope {
+	if k.Scopes == "" {
+		return []DeviceScope{}
+	}
+	parts := strings.Split(k.Scopes, ",")
+	scopes := make([]DeviceScope, len(parts))
+	for i, p := range parts {
+		scopes[i] = DeviceScope(p)
+	}
+	return scopes
+}
+
+func (k *DeviceAPIKey) HasScope(scope DeviceScope) bool {
+	for _, s := range k.ScopeList() {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+func (k *DeviceAPIKey) IsUsable(now time.Time) bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+	if k.ExpiresAt != nil && !now.Before(*k.ExpiresAt) {
+		return false
+	}
+	return true
+}
+
+func JoinScopes(scopes []DeviceScope) string {
+	parts := make([]string, len(scopes))
+	for i, s := range scopes {
+		parts[i] = string(s)
+	}
+	return strings.Join(parts, ",")
+}
+
+type CreateDeviceRequest struct {
+	KiosID uint       `json:"kios_id" binding:"required"`
+	Name   string     `json:"name" binding:"required,min=2,max=100"`
+	Type   DeviceType `json:"type" binding:"required,oneof=kitchen_display queue_display printer"`
+}
+
+type UpdateDeviceRequest struct {
+	Name     string `json:"name" binding:"omitempty,min=2,max=100"`
+	IsActive *bool  `json:"is_active"`
+}
+
+type CreateDeviceKeyRequest struct {
+	Scopes    []DeviceScope `json:"scopes" binding:"required,min=1,dive,oneof=queue:read orders:read orders:ready"`
+	ExpiresAt *time.Time    `json:"expires_at"`
+}
+
+type DeviceResponse struct {
+	ID         uint                    `json:"id"`
+	KiosID     uint                    `json:"kios_id"`
+	KiosName   string                  `json:"kios_name"`
+	Name       string                  `json:"name"`
+	Type       DeviceType              `json:"type"`
+	IsActive   bool                    `json:"is_active"`
+	LastSeenAt *time.Time              `json:"last_seen_at"`
+	LastSeenIP string                  `json:"last_seen_ip"`
+	APIKeys    []*DeviceAPIKeyResponse `json:"api_keys"`
+	CreatedAt  time.Time               `json:"created_at"`
+	UpdatedAt  time.Time               `json:"updated_at"`
+}
+
+type DeviceAPIKeyResponse struct {
+	ID         uint          `json:"id"`
+	Prefix     string        `json:"prefix"`
+	Scopes     []DeviceScope `json:"scopes"`
+	LastUsedAt *time.Time    `json:"last_used_at"`
+	ExpiresAt  *time.Time    `json:"expires_at"`
+	RevokedAt  *time.Time    `json:"revoked_at"`
+	CreatedAt  time.Time     `json:"created_at"`
+}
+
+// IssuedDeviceKeyResponse is only returned once, when the key is created or rotated.
+type IssuedDeviceKeyResponse struct {
+	*DeviceAPIKeyResponse
+	Key string `json:"key"`
+}
+
+func (d *Device) ToResponse() *DeviceResponse {
+	keys := make([]*DeviceAPIKeyResponse, len(d.APIKeys))
+	for i := range d.APIKeys {
+		keys[i] = d.APIKeys[i].ToResponse()
+	}
+
+	return &DeviceResponse{
+		ID:         d.ID,
+		KiosID:     d.KiosID,
+		KiosName:   d.Kios.Name,
+		Name:       d.Name,
+		Type:       d.Type,
+		IsActive:   d.IsActive,
+		LastSeenAt: d.LastSeenAt,
+		LastSeenIP: d.LastSeenIP,
+		APIKeys:    keys,
+		CreatedAt:  d.CreatedAt,
+		UpdatedAt:  d.UpdatedAt,
+	}
+}
+
+func (k *DeviceAPIKey) ToResponse() *DeviceAPIKeyResponse {
+	return &DeviceAPIKeyResponse{
+		ID:         k.ID,
+		Prefix:     k.Prefix,
+		Scopes:     k.ScopeList(),
+		LastUsedAt: k.LastUsedAt,
+		ExpiresAt:  k.ExpiresAt,
+		RevokedAt:  k.RevokedAt,
+		CreatedAt:  k.CreatedAt,
+	}
+}
@@ -7,54 +7,128 @@ import (
 )
 
 type Kios struct {
-	ID          uint           `json:"id" gorm:"primaryKey"`
-	Name        string         `json:"name" gorm:"not null"`
-	Description string         `json:"description"`
-	Location    string         `json:"location"`
-	IsActive    bool           `json:"is_active" gorm:"default:true"`
-	Users       []User         `json:"users,omitempty" gorm:"foreignKey:KiosID"`
-	Menus       []Menu         `json:"menus,omitempty" gorm:"foreignKey:KiosID"`
-	Orders      []Order        `json:"orders,omitempty" gorm:"foreignKey:KiosID"`
-	CreatedAt   time.Time      `json:"created_at"`
-	UpdatedAt   time.Time      `json:"updated_at"`
-	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
+	ID           uint              `json:"id" gorm:"primaryKey"`
+	Name         string            `json:"name" gorm:"not null"`
+	Description  string            `json:"description"`
+	Location     string            `json:"location"`
+	IsActive     bool              `json:"is_active" gorm:"default:true"`
+	Users        []User            `json:"users,omitempty" gorm:"foreignKey:KiosID"`
+	Menus        []Menu            `json:"menus,omitempty" gorm:"foreignKey:KiosID"`
+	Orders       []Order           `json:"orders,omitempty" gorm:"foreignKey:KiosID"`
+	Translations []KiosTranslation `json:"-" gorm:"foreignKey:KiosID"`
+	Version      uint              `json:"version" gorm:"not null;default:1"` // Incremented by every update
+	CreatedAt    time.Time         `json:"created_at"`
+	UpdatedAt    time.Time         `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt    `json:"-" gorm:"index"`
+
+	Stats KiosStats `json:"-" gorm:"-"`
+}
+
+// KiosStats are aggregate figures of a kios, computed by queries rather than stored
+type KiosStats struct {
+	MenuCount       int64
+	OrderCount      int64
+	TodayOrderCount int64
+	QueueLength     int64   // Orders waiting in the queue
+	TodayRevenue    float64 // Total of today's paid orders
 }
 
 type CreateKiosRequest struct {
-	Name        string `json:"name" binding:"required,min=2,max=100"`
-	Description string `json:"description" binding:"max=500"`
-	Location    string `json:"location" binding:"max=200"`
+	Name         string              `json:"name" binding:"required,min=2,max=100"`
+	Description  string              `json:"description" binding:"max=500"`
+	Location     string              `json:"location" binding:"max=200"`
+	Translations TranslationsRequest `json:"translations" binding:"omitempty,dive,keys,oneof=en id,endkeys"`
 }
 
+// UpdateKiosRequest changes the fields it sets. Empty strings leave fields as
+// they are, see KiosPatch to clear them.
 type UpdateKiosRequest struct {
-	Name        string `json:"name" binding:"omitempty,min=2,max=100"`
-	Description string `json:"description" binding:"omitempty,max=500"`
-	Location    string `json:"location" binding:"omitempty,max=200"`
-	IsActive    *bool  `json:"is_active"`
+	Name         string              `json:"name" binding:"omitempty,min=2,max=100"`
+	Description  string              `json:"description" binding:"omitempty,max=500"`
+	Location     string              `json:"location" binding:"omitempty,max=200"`
+	IsActive     *bool               `json:"is_active"`
+	Translations TranslationsRequest `json:"translations" binding:"omitempty,dive,keys,oneof=en id,endkeys"`
+}
+
+// KiosPatch holds the fields of a kios that PATCH requests change. Requests are
+// JSON merge patches of these fields, where null clears a field, and the
+// patched fields must be valid as a whole.
+type KiosPatch struct {
+	Name         string                 `json:"name" binding:"required,min=2,max=100"`
+	Description  string                 `json:"description" binding:"max=500"`
+	Location     string                 `json:"location" binding:"max=200"`
+	IsActive     *bool                  `json:"is_active" binding:"required"`
+	Translations map[string]Translation `json:"translations" binding:"omitempty,dive,keys,oneof=en id,endkeys"`
+}
+
+// Patchable returns the current values of the fields in KiosPatch
+func (k *Kios) Patchable() KiosPatch {
+	isActive := k.IsActive
+	return KiosPatch{
+		Name:         k.Name,
+		Description:  k.Description,
+		Location:     k.Location,
+		IsActive:     &isActive,
+		Translations: k.TranslationMap(),
+	}
+}
+
+// ApplyPatch sets the fields of the kios to the values of a validated patch
+func (k *Kios) ApplyPatch(patch KiosPatch) {
+	k.Name = patch.Name
+	k.Description = patch.Description
+	k.Location = patch.Location
+	k.IsActive = *patch.IsActive
+	k.SetTranslationMap(patch.Translations)
 }
 
 type KiosResponse struct {
-	ID          uint      `json:"id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	Location    string    `json:"location"`
-	IsActive    bool      `json:"is_active"`
-	MenuCount   int64     `json:"menu_count"`
-	OrderCount  int64     `json:"order_count"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID              uint      `json:"id"`
+	Name            string    `json:"name"`
+	Description     string    `json:"description"`
+	Location        string    `json:"location"`
+	IsActive        bool      `json:"is_active"`
+	MenuCount       int64     `json:"menu_count"`
+	OrderCount      int64     `json:"order_count"`
+	TodayOrderCount int64     `json:"today_order_count"`
+	QueueLength     int64     `json:"queue_length"`
+	TodayRevenue    float64   `json:"today_revenue"`
+	Version         uint      `json:"version"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+
+	Translations map[string]Translation `json:"translations,omitempty"`
 }
 
 func (k *Kios) ToResponse() *KiosResponse {
-	return &KiosResponse{
-		ID:          k.ID,
-		Name:        k.Name,
-		Description: k.Description,
-		Location:    k.Location,
-		IsActive:    k.IsActive,
-		MenuCount:   int64(len(k.Menus)),
-		OrderCount:  int64(len(k.Orders)),
-		CreatedAt:   k.CreatedAt,
-		UpdatedAt:   k.UpdatedAt,
+	resp := &KiosResponse{
+		ID:           k.ID,
+		Name:         k.Name,
+		Description:  k.Description,
+		Location:     k.Location,
+		IsActive:     k.IsActive,
+		Version:      k.Version,
+		CreatedAt:    k.CreatedAt,
+		UpdatedAt:    k.UpdatedAt,
+		Translations: k.TranslationMap(),
 	}
+	resp.SetStats(k.Stats)
+	return resp
+}
+
+// SetStats replaces the figures of the response
+func (r *KiosResponse) SetStats(stats KiosStats) {
+	r.MenuCount = stats.MenuCount
+	r.OrderCount = stats.OrderCount
+	r.TodayOrderCount = stats.TodayOrderCount
+	r.QueueLength = stats.QueueLength
+	r.TodayRevenue = stats.TodayRevenue
+}
+
+// ToLocalizedResponse is ToResponse with the name and description in locale
+func (k *Kios) ToLocalizedResponse(locale string) *KiosResponse {
+	resp := k.ToResponse()
+	t := k.Translated(locale)
+	resp.Name, resp.Description = t.Name, t.Description
+	return resp
 }
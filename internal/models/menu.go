@@ -16,19 +16,21 @@ const (
 )
 
 type Menu struct {
-	ID          uint           `json:"id" gorm:"primaryKey"`
-	KiosID      uint           `json:"kios_id" gorm:"not null;index"`
-	Kios        Kios           `json:"kios" gorm:"foreignKey:KiosID"`
-	Name        string         `json:"name" gorm:"not null"`
-	Description string         `json:"description"`
-	Price       float64        `json:"price" gorm:"not null"`
-	Category    MenuCategory   `json:"category" gorm:"not null"`
-	ImageURL    string         `json:"image_url"`
-	IsAvailable bool           `json:"is_available" gorm:"default:true"`
-	OrderItems  []OrderItem    `json:"order_items,omitempty" gorm:"foreignKey:MenuID"`
-	CreatedAt   time.Time      `json:"created_at"`
-	UpdatedAt   time.Time      `json:"updated_at"`
-	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
+	ID           uint              `json:"id" gorm:"primaryKey"`
+	KiosID       uint              `json:"kios_id" gorm:"not null;index"`
+	Kios         Kios              `json:"kios" gorm:"foreignKey:KiosID"`
+	Name         string            `json:"name" gorm:"not null"`
+	Description  string            `json:"description"`
+	Price        float64           `json:"price" gorm:"not null"`
+	Category     MenuCategory      `json:"category" gorm:"not null"`
+	ImageURL     string            `json:"image_url"`
+	IsAvailable  bool              `json:"is_available" gorm:"default:true"`
+	OrderItems   []OrderItem       `json:"order_items,omitempty" gorm:"foreignKey:MenuID"`
+	Translations []MenuTranslation `json:"-" gorm:"foreignKey:MenuID"`
+	Version      uint              `json:"version" gorm:"not null;default:1"` // Incremented by every update
+	CreatedAt    time.Time         `json:"created_at"`
+	UpdatedAt    time.Time         `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt    `json:"-" gorm:"index"`
 }
 
 type CreateMenuRequest struct {
@@ -39,8 +41,12 @@ type CreateMenuRequest struct {
 	Category    MenuCategory `json:"category" binding:"required,oneof=food drink snack dessert"`
 	ImageURL    string       `json:"image_url" binding:"omitempty,url"`
 	IsAvailable *bool        `json:"is_available"`
+
+	Translations TranslationsRequest `json:"translations" binding:"omitempty,dive,keys,oneof=en id,endkeys"`
 }
 
+// UpdateMenuRequest changes the fields it sets. Empty strings leave fields as
+// they are, see MenuPatch to clear them.
 type UpdateMenuRequest struct {
 	Name        string       `json:"name" binding:"omitempty,min=2,max=100"`
 	Description string       `json:"description" binding:"omitempty,max=500"`
@@ -48,6 +54,47 @@ type UpdateMenuRequest struct {
 	Category    MenuCategory `json:"category" binding:"omitempty,oneof=food drink snack dessert"`
 	ImageURL    string       `json:"image_url" binding:"omitempty,url"`
 	IsAvailable *bool        `json:"is_available"`
+
+	Translations TranslationsRequest `json:"translations" binding:"omitempty,dive,keys,oneof=en id,endkeys"`
+}
+
+// MenuPatch holds the fields of a menu that PATCH requests change. Requests are
+// JSON merge patches of these fields, where null clears a field, and the
+// patched fields must be valid as a whole.
+type MenuPatch struct {
+	Name        string       `json:"name" binding:"required,min=2,max=100"`
+	Description string       `json:"description" binding:"max=500"`
+	Price       *float64     `json:"price" binding:"required,gt=0"`
+	Category    MenuCategory `json:"category" binding:"required,oneof=food drink snack dessert"`
+	ImageURL    string       `json:"image_url" binding:"omitempty,url"`
+	IsAvailable *bool        `json:"is_available" binding:"required"`
+
+	Translations map[string]Translation `json:"translations" binding:"omitempty,dive,keys,oneof=en id,endkeys"`
+}
+
+// Patchable returns the current values of the fields in MenuPatch
+func (m *Menu) Patchable() MenuPatch {
+	price, isAvailable := m.Price, m.IsAvailable
+	return MenuPatch{
+		Name:         m.Name,
+		Description:  m.Description,
+		Price:        &price,
+		Category:     m.Category,
+		ImageURL:     m.ImageURL,
+		IsAvailable:  &isAvailable,
+		Translations: m.TranslationMap(),
+	}
+}
+
+// ApplyPatch sets the fields of the menu to the values of a validated patch
+func (m *Menu) ApplyPatch(patch MenuPatch) {
+	m.Name = patch.Name
+	m.Description = patch.Description
+	m.Price = *patch.Price
+	m.Category = patch.Category
+	m.ImageURL = patch.ImageURL
+	m.IsAvailable = *patch.IsAvailable
+	m.SetTranslationMap(patch.Translations)
 }
 
 type MenuResponse struct {
@@ -60,8 +107,11 @@ type MenuResponse struct {
 	Category    MenuCategory `json:"category"`
 	ImageURL    string       `json:"image_url"`
 	IsAvailable bool         `json:"is_available"`
+	Version     uint         `json:"version"`
 	CreatedAt   time.Time    `json:"created_at"`
 	UpdatedAt   time.Time    `json:"updated_at"`
+
+	Translations map[string]Translation `json:"translations,omitempty"`
 }
 
 func (m *Menu) ToResponse() *MenuResponse {
@@ -75,7 +125,19 @@ func (m *Menu) ToResponse() *MenuResponse {
 		Category:    m.Category,
 		ImageURL:    m.ImageURL,
 		IsAvailable: m.IsAvailable,
+		Version:     m.Version,
 		CreatedAt:   m.CreatedAt,
 		UpdatedAt:   m.UpdatedAt,
+
+		Translations: m.TranslationMap(),
 	}
 }
+
+// ToLocalizedResponse is ToResponse with the names and description in locale
+func (m *Menu) ToLocalizedResponse(locale string) *MenuResponse {
+	resp := m.ToResponse()
+	t := m.Translated(locale)
+	resp.Name, resp.Description = t.Name, t.Description
+	resp.KiosName = m.Kios.Translated(locale).Name
+	return resp
+}
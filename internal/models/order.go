@@ -1,6 +1,7 @@
 package models
 
 import (
+	"math"
 	"time"
 
 	"gorm.io/gorm"
@@ -17,6 +18,21 @@ const (
 	StatusCancelled OrderStatus = "cancelled" // Dibatalkan
 )
 
+// QueueStatuses are the statuses of orders waiting in a kios queue
+var QueueStatuses = []OrderStatus{StatusPaid, StatusPreparing, StatusReady}
+
+// PaidStatuses are the statuses of orders that have been paid for and count as revenue
+var PaidStatuses = []OrderStatus{StatusPaid, StatusPreparing, StatusReady, StatusCompleted}
+
+// IsValid reports whether s is one of the known order statuses
+func (s OrderStatus) IsValid() bool {
+	switch s {
+	case StatusPending, StatusPaid, StatusPreparing, StatusReady, StatusCompleted, StatusCancelled:
+		return true
+	}
+	return false
+}
+
 type PaymentMethod string
 
 const (
@@ -28,11 +44,12 @@ const (
 type Order struct {
 	ID            uint           `json:"id" gorm:"primaryKey"`
 	QueueNumber   string         `json:"queue_number" gorm:"uniqueIndex;not null"`
-	KiosID        uint           `json:"kios_id" gorm:"not null;index"`
+	KiosID        uint           `json:"kios_id" gorm:"not null;index;index:idx_orders_kios_created,priority:1;index:idx_orders_kios_status,priority:1"`
 	Kios          Kios           `json:"kios" gorm:"foreignKey:KiosID"`
 	CustomerName  string         `json:"customer_name"`
-	Status        OrderStatus    `json:"status" gorm:"default:pending"`
+	Status        OrderStatus    `json:"status" gorm:"default:pending;index:idx_orders_kios_status,priority:2"`
 	TotalAmount   float64        `json:"total_amount" gorm:"not null"`
+	TaxAmount     float64        `json:"tax_amount" gorm:"not null;default:0"` // Part of the total amount
 	PaymentMethod *PaymentMethod `json:"payment_method"`
 	PaidAt        *time.Time     `json:"paid_at"`
 	PreparedAt    *time.Time     `json:"prepared_at"`
@@ -42,11 +59,32 @@ type Order struct {
 	OrderItems    []OrderItem    `json:"order_items" gorm:"foreignKey:OrderID"`
 	CreatedBy     uint           `json:"created_by" gorm:"not null"`
 	Creator       User           `json:"creator" gorm:"foreignKey:CreatedBy"`
-	CreatedAt     time.Time      `json:"created_at"`
+	Version       uint           `json:"version" gorm:"not null;default:1"` // Incremented by every update
+	CreatedAt     time.Time      `json:"created_at" gorm:"index:idx_orders_kios_created,priority:2"`
 	UpdatedAt     time.Time      `json:"updated_at"`
 	DeletedAt     gorm.DeletedAt `json:"-" gorm:"index"`
 }
 
+// Tax is how orders are taxed
+type Tax struct {
+	Rate     float64 // Fraction of the price, 0.11 for 11%
+	Included bool    // Whether menu prices already include the tax
+}
+
+// Apply returns the tax on the sum of the prices of an order and the total to
+// pay, rounded to cents
+func (t Tax) Apply(amount float64) (tax, total float64) {
+	if t.Included {
+		return roundCents(amount - amount/(1+t.Rate)), amount
+	}
+	tax = roundCents(amount * t.Rate)
+	return tax, amount + tax
+}
+
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
 type OrderItem struct {
 	ID       uint    `json:"id" gorm:"primaryKey"`
 	OrderID  uint    `json:"order_id" gorm:"not null;index"`
@@ -63,7 +101,7 @@ type CreateOrderRequest struct {
 	KiosID       uint                     `json:"kios_id,omitempty"` // Optional in JSON, will be set from URL
 	CustomerName string                   `json:"customer_name" binding:"max=100"`
 	Notes        string                   `json:"notes" binding:"max=500"`
-	Items        []CreateOrderItemRequest `json:"items" binding:"required,min=1"`
+	Items        []CreateOrderItemRequest `json:"items" binding:"required,min=1,dive"`
 }
 
 type CreateOrderItemRequest struct {
@@ -85,6 +123,7 @@ type OrderResponse struct {
 	CustomerName  string              `json:"customer_name"`
 	Status        OrderStatus         `json:"status"`
 	TotalAmount   float64             `json:"total_amount"`
+	TaxAmount     float64             `json:"tax_amount"`
 	PaymentMethod *PaymentMethod      `json:"payment_method"`
 	PaidAt        *time.Time          `json:"paid_at"`
 	PreparedAt    *time.Time          `json:"prepared_at"`
@@ -94,6 +133,7 @@ type OrderResponse struct {
 	OrderItems    []OrderItemResponse `json:"order_items"`
 	CreatedBy     uint                `json:"created_by"`
 	CreatorName   string              `json:"creator_name"`
+	Version       uint                `json:"version"`
 	CreatedAt     time.Time           `json:"created_at"`
 	UpdatedAt     time.Time           `json:"updated_at"`
 }
@@ -130,6 +170,7 @@ func (o *Order) ToResponse() *OrderResponse {
 		CustomerName:  o.CustomerName,
 		Status:        o.Status,
 		TotalAmount:   o.TotalAmount,
+		TaxAmount:     o.TaxAmount,
 		PaymentMethod: o.PaymentMethod,
 		PaidAt:        o.PaidAt,
 		PreparedAt:    o.PreparedAt,
@@ -139,6 +180,7 @@ func (o *Order) ToResponse() *OrderResponse {
 		OrderItems:    items,
 		CreatedBy:     o.CreatedBy,
 		CreatorName:   o.Creator.FullName,
+		Version:       o.Version,
 		CreatedAt:     o.CreatedAt,
 		UpdatedAt:     o.UpdatedAt,
 	}
@@ -0,0 +1,134 @@
+package models
+
+import "sort"
+
+// Translation is the name and description of a kios or menu in one locale.
+// The untranslated columns of kios and menus hold the content entered first,
+// usually in Bahasa Indonesia.
+type Translation struct {
+	Name        string `json:"name" binding:"required,min=2,max=100"`
+	Description string `json:"description" binding:"max=500"`
+}
+
+// TranslationsRequest sets translations by locale, e.g. {"en": {"name": "Beef
+// rendang with rice"}}. On updates a null translation removes the locale and
+// locales left out are kept.
+type TranslationsRequest map[string]*Translation
+
+// KiosTranslation is a Translation of a kios
+type KiosTranslation struct {
+	ID          uint   `json:"-" gorm:"primaryKey"`
+	KiosID      uint   `json:"-" gorm:"not null;uniqueIndex:idx_kios_translations_kios_locale"`
+	Locale      string `json:"-" gorm:"not null;uniqueIndex:idx_kios_translations_kios_locale"`
+	Name        string `json:"name" gorm:"not null"`
+	Description string `json:"description"`
+}
+
+// MenuTranslation is a Translation of a menu
+type MenuTranslation struct {
+	ID          uint   `json:"-" gorm:"primaryKey"`
+	MenuID      uint   `json:"-" gorm:"not null;uniqueIndex:idx_menu_translations_menu_locale"`
+	Locale      string `json:"-" gorm:"not null;uniqueIndex:idx_menu_translations_menu_locale"`
+	Name        string `json:"name" gorm:"not null"`
+	Description string `json:"description"`
+}
+
+// translate returns the translation of locale, falling back to the
+// untranslated content for missing translations and descriptions
+func translate(base Translation, translations map[string]Translation, locale string) Translation {
+	t, ok := translations[locale]
+	if !ok {
+		return base
+	}
+	if t.Description == "" {
+		t.Description = base.Description
+	}
+	return t
+}
+
+// applyTranslations merges req into the translations by locale
+func applyTranslations(translations map[string]Translation, req TranslationsRequest) map[string]Translation {
+	if translations == nil {
+		translations = make(map[string]Translation, len(req))
+	}
+	for locale, t := range req {
+		if t == nil {
+			delete(translations, locale)
+		} else {
+			translations[locale] = *t
+		}
+	}
+	return translations
+}
+
+// sortedLocales returns the locales of translations in a stable order
+func sortedLocales(translations map[string]Translation) []string {
+	locales := make([]string, 0, len(translations))
+	for locale := range translations {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// TranslationMap returns the translations of the kios by locale
+func (k *Kios) TranslationMap() map[string]Translation {
+	if len(k.Translations) == 0 {
+		return nil
+	}
+	translations := make(map[string]Translation, len(k.Translations))
+	for _, t := range k.Translations {
+		translations[t.Locale] = Translation{Name: t.Name, Description: t.Description}
+	}
+	return translations
+}
+
+// Translated returns the name and description of the kios in locale
+func (k *Kios) Translated(locale string) Translation {
+	return translate(Translation{Name: k.Name, Description: k.Description}, k.TranslationMap(), locale)
+}
+
+// SetTranslations applies the requested translations to the kios
+func (k *Kios) SetTranslations(req TranslationsRequest) {
+	k.SetTranslationMap(applyTranslations(k.TranslationMap(), req))
+}
+
+// SetTranslationMap replaces the translations of the kios
+func (k *Kios) SetTranslationMap(translations map[string]Translation) {
+	k.Translations = make([]KiosTranslation, 0, len(translations))
+	for _, locale := range sortedLocales(translations) {
+		t := translations[locale]
+		k.Translations = append(k.Translations, KiosTranslation{KiosID: k.ID, Locale: locale, Name: t.Name, Description: t.Description})
+	}
+}
+
+// TranslationMap returns the translations of the menu by locale
+func (m *Menu) TranslationMap() map[string]Translation {
+	if len(m.Translations) == 0 {
+		return nil
+	}
+	translations := make(map[string]Translation, len(m.Translations))
+	for _, t := range m.Translations {
+		translations[t.Locale] = Translation{Name: t.Name, Description: t.Description}
+	}
+	return translations
+}
+
+// Translated returns the name and description of the menu in locale
+func (m *Menu) Translated(locale string) Translation {
+	return translate(Translation{Name: m.Name, Description: m.Description}, m.TranslationMap(), locale)
+}
+
+// SetTranslations applies the requested translations to the menu
+func (m *Menu) SetTranslations(req TranslationsRequest) {
+	m.SetTranslationMap(applyTranslations(m.TranslationMap(), req))
+}
+
+// SetTranslationMap replaces the translations of the menu
+func (m *Menu) SetTranslationMap(translations map[string]Translation) {
+	m.Translations = make([]MenuTranslation, 0, len(translations))
+	for _, locale := range sortedLocales(translations) {
+		t := translations[locale]
+		m.Translations = append(m.Translations, MenuTranslation{MenuID: m.ID, Locale: locale, Name: t.Name, Description: t.Description})
+	}
+}
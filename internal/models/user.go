@@ -26,6 +26,38 @@ type User struct {
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Two-factor authentication (TOTP). The secret is set on enrollment and only
+	// used once TOTPEnabled is true, after the user confirmed a first code.
+	TOTPSecret      string `json:"-"`
+	TOTPEnabled     bool   `json:"totp_enabled" gorm:"default:false"`
+	TOTPLastCounter int64  `json:"-"` // Last accepted time step, prevents code replay
+
+	// Incremented on password changes, invalidating all previously issued tokens
+	TokenVersion      uint       `json:"-" gorm:"not null;default:0"`
+	PasswordChangedAt *time.Time `json:"password_changed_at"`
+
+	// Set on seeded accounts whose password is generated or known. They can only
+	// change their password until they do.
+	MustChangePassword bool `json:"must_change_password" gorm:"not null;default:false"`
+}
+
+type PasswordResetToken struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	UserID      uint       `json:"user_id" gorm:"not null;index"`
+	TokenHash   string     `json:"-" gorm:"uniqueIndex;not null"`
+	ExpiresAt   time.Time  `json:"expires_at" gorm:"not null"`
+	UsedAt      *time.Time `json:"used_at"`
+	RequestedIP string     `json:"requested_ip"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+type RecoveryCode struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uint       `json:"user_id" gorm:"not null;index"`
+	CodeHash  string     `json:"-" gorm:"not null"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
 }
 
 type LoginRequest struct {
@@ -33,15 +65,95 @@ type LoginRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
+type UnlockLoginRequest struct {
+	Username  string `json:"username" binding:"required_without=IPAddress"`
+	IPAddress string `json:"ip_address" binding:"omitempty,ip"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required,min=8,max=72"`
+}
+
+type ForgotPasswordRequest struct {
+	Identifier string `json:"identifier" binding:"required"` // Username or email
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=8,max=72"`
+}
+
+type VerifyTwoFactorRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required_without=RecoveryCode"`
+	RecoveryCode   string `json:"recovery_code"`
+}
+
+type TwoFactorSetupRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code"`
+}
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required,len=6,numeric"`
+}
+
+type DisableTwoFactorRequest struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required,len=6,numeric"`
+}
+
+type TwoFactorEnrollmentResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
 type RegisterRequest struct {
 	Username string   `json:"username" binding:"required,min=3,max=50"`
 	Email    string   `json:"email" binding:"required,email"`
-	Password string   `json:"password" binding:"required,min=6"`
+	Password string   `json:"password" binding:"required,min=8,max=72"`
 	FullName string   `json:"full_name" binding:"required,min=2,max=100"`
 	Role     UserRole `json:"role" binding:"required,oneof=cashier kios"`
 	KiosID   *uint    `json:"kios_id,omitempty"`
 }
 
+// UserPatch holds the fields of a user that PATCH requests change. Requests are
+// JSON merge patches of these fields and the patched fields must be valid as a
+// whole: kios users, for instance, need a kios.
+type UserPatch struct {
+	Email    string   `json:"email" binding:"required,email"`
+	FullName string   `json:"full_name" binding:"required,min=2,max=100"`
+	Role     UserRole `json:"role" binding:"required,oneof=cashier kios"`
+	KiosID   *uint    `json:"kios_id" binding:"required_if=Role kios"`
+	IsActive *bool    `json:"is_active" binding:"required"`
+}
+
+// Patchable returns the current values of the fields in UserPatch
+func (u *User) Patchable() UserPatch {
+	isActive := u.IsActive
+	return UserPatch{
+		Email:    u.Email,
+		FullName: u.FullName,
+		Role:     u.Role,
+		KiosID:   u.KiosID,
+		IsActive: &isActive,
+	}
+}
+
+// ApplyPatch sets the fields of the user to the values of a validated patch.
+// Only kios staff belong to a kios, so a cashier loses the one they had.
+func (u *User) ApplyPatch(patch UserPatch) {
+	u.Email = patch.Email
+	u.FullName = patch.FullName
+	u.Role = patch.Role
+	u.KiosID = patch.KiosID
+	if u.Role != RoleKios {
+		u.KiosID = nil
+	}
+	u.IsActive = *patch.IsActive
+}
+
 type UserResponse struct {
 	ID       uint     `json:"id"`
 	Username string   `json:"username"`
@@ -51,6 +163,9 @@ type UserResponse struct {
 	IsActive bool     `json:"is_active"`
 	KiosID   *uint    `json:"kios_id,omitempty"`
 	Kios     *Kios    `json:"kios,omitempty"`
+
+	TwoFactorEnabled   bool `json:"two_factor_enabled"`
+	MustChangePassword bool `json:"must_change_password"`
 }
 
 func (u *User) ToResponse() *UserResponse {
@@ -63,5 +178,8 @@ func (u *User) ToResponse() *UserResponse {
 		IsActive: u.IsActive,
 		KiosID:   u.KiosID,
 		Kios:     u.Kios,
+
+		TwoFactorEnabled:   u.TOTPEnabled,
+		MustChangePassword: u.MustChangePassword,
 	}
 }
@@ -0,0 +1,77 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"foodcourt-backend/internal/logging"
+)
+
+// LogNotifier writes messages to the application log, reset tokens included.
+// Meant for local development only; config.Validate refuses it in release mode.
+type LogNotifier struct{}
+
+func NewLogNotifier() *LogNotifier {
+	return &LogNotifier{}
+}
+
+func (n *LogNotifier) SendPasswordReset(ctx context.Context, reset PasswordReset) error {
+	logging.FromContext(ctx).Info("Password reset",
+		"username", reset.User.Username, "email", reset.User.Email, "token", reset.Token,
+		"url", reset.ResetURL, "expires_at", reset.ExpiresAt.Format(time.RFC3339))
+	return nil
+}
+
+// FileNotifier appends messages as JSON lines to a file, e.g. for picking them up in tests or a local mail catcher
+type FileNotifier struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewFileNotifier(path string) *FileNotifier {
+	return &FileNotifier{path: path}
+}
+
+type fileMessage struct {
+	Type      string    `json:"type"`
+	To        string    `json:"to"`
+	Username  string    `json:"username"`
+	Token     string    `json:"token"`
+	URL       string    `json:"url,omitempty"`
+	ExpiresAt time.Time `json:"expires_at"`
+	SentAt    time.Time `json:"sent_at"`
+}
+
+func (n *FileNotifier) SendPasswordReset(ctx context.Context, reset PasswordReset) error {
+	return n.write(fileMessage{
+		Type:      "password_reset",
+		To:        reset.User.Email,
+		Username:  reset.User.Username,
+		Token:     reset.Token,
+		URL:       reset.ResetURL,
+		ExpiresAt: reset.ExpiresAt,
+		SentAt:    time.Now(),
+	})
+}
+
+func (n *FileNotifier) write(message fileMessage) error {
+	line, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	f, err := os.OpenFile(n.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
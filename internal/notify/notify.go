@@ -0,0 +1,35 @@
+// Package notify delivers messages such as password reset links to users.
+package notify
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"foodcourt-backend/internal/config"
+	"foodcourt-backend/internal/models"
+)
+
+type PasswordReset struct {
+	User      *models.User
+	Token     string
+	ResetURL  string // Empty when no frontend URL is configured
+	ExpiresAt time.Time
+}
+
+// Notifier sends messages to users. Implementations may deliver by e-mail, chat, etc.
+type Notifier interface {
+	SendPasswordReset(ctx context.Context, reset PasswordReset) error
+}
+
+// New creates the notifier selected by the configuration
+func New(cfg config.NotifierConfig) (Notifier, error) {
+	switch cfg.Driver {
+	case "", "log":
+		return NewLogNotifier(), nil
+	case "file":
+		return NewFileNotifier(cfg.FilePath), nil
+	default:
+		return nil, fmt.Errorf("unknown notifier driver %q", cfg.Driver)
+	}
+}
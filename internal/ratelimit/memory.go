@@ -0,0 +1,56 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const pruneInterval = time.Minute
+
+type memoryEntry struct {
+	bucket
+	expiresAt time.Time // When the bucket is full again and can be forgotten
+}
+
+// MemoryStore keeps buckets in process memory. It is used when Redis is not
+// configured, so every server instance limits clients on its own.
+type MemoryStore struct {
+	mu        sync.Mutex
+	entries   map[string]*memoryEntry
+	lastPrune time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]*memoryEntry)}
+}
+
+func (s *MemoryStore) Take(ctx context.Context, key string, policy Policy, now time.Time) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.prune(now)
+
+	var current bucket
+	if entry, ok := s.entries[key]; ok {
+		current = entry.bucket
+	}
+	next, result := take(current, policy, now)
+	s.entries[key] = &memoryEntry{bucket: next, expiresAt: now.Add(result.Reset)}
+
+	return result, nil
+}
+
+// prune drops full buckets so that many clients don't grow the map forever. Callers must hold mu.
+func (s *MemoryStore) prune(now time.Time) {
+	if now.Sub(s.lastPrune) < pruneInterval {
+		return
+	}
+	s.lastPrune = now
+
+	for key, entry := range s.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(s.entries, key)
+		}
+	}
+}
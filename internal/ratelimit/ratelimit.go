@@ -0,0 +1,106 @@
+// Package ratelimit throttles clients with token buckets. Every client has a
+// bucket per policy holding up to Requests tokens, refilled at Requests per
+// Window; a request takes a token and is rejected when the bucket is empty.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Policy is the limit of a group of routes
+type Policy struct {
+	Name     string // Part of the bucket keys, so policies do not share buckets
+	Requests int
+	Window   time.Duration
+}
+
+// rate returns the tokens added per second
+func (p Policy) rate() float64 {
+	return float64(p.Requests) / p.Window.Seconds()
+}
+
+// Result is the state of a bucket after taking a token from it
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration // Until the bucket is full again
+	RetryAfter time.Duration // Until the next token, when not allowed
+}
+
+// ResetSeconds rounds Reset up to whole seconds, as used by the RateLimit-Reset header
+func (r Result) ResetSeconds() int {
+	return int(math.Ceil(r.Reset.Seconds()))
+}
+
+// RetryAfterSeconds rounds RetryAfter up to whole seconds, as used by the Retry-After header
+func (r Result) RetryAfterSeconds() int {
+	return int(math.Ceil(r.RetryAfter.Seconds()))
+}
+
+// Store keeps the buckets. Implementations must be safe for concurrent use.
+type Store interface {
+	// Take takes a token from the bucket of key, refilled according to policy
+	Take(ctx context.Context, key string, policy Policy, now time.Time) (Result, error)
+}
+
+type Limiter struct {
+	store Store
+	now   func() time.Time
+}
+
+func New(store Store) *Limiter {
+	return &Limiter{
+		store: store,
+		now:   time.Now,
+	}
+}
+
+// Allow takes a token from the bucket of the client under policy. client
+// identifies the caller, such as user:12 or ip:10.0.0.1.
+func (l *Limiter) Allow(ctx context.Context, policy Policy, client string) (Result, error) {
+	return l.store.Take(ctx, policy.Name+":"+client, policy, l.now())
+}
+
+// bucket is the state of a token bucket at a point in time
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// take refills b up to now and takes a token from it if there is one
+func take(b bucket, policy Policy, now time.Time) (bucket, Result) {
+	capacity := float64(policy.Requests)
+	tokens := capacity
+	if !b.updated.IsZero() {
+		elapsed := now.Sub(b.updated).Seconds()
+		tokens = math.Min(capacity, b.tokens+math.Max(0, elapsed)*policy.rate())
+	}
+
+	allowed := tokens >= 1
+	if allowed {
+		tokens--
+	}
+	return bucket{tokens: tokens, updated: now}, newResult(policy, tokens, allowed)
+}
+
+// newResult describes a bucket left with tokens after a request
+func newResult(policy Policy, tokens float64, allowed bool) Result {
+	rate := policy.rate()
+	result := Result{
+		Allowed:   allowed,
+		Limit:     policy.Requests,
+		Remaining: int(tokens),
+		Reset:     seconds((float64(policy.Requests) - tokens) / rate),
+	}
+	if !allowed {
+		result.RetryAfter = seconds((1 - tokens) / rate)
+	}
+	return result
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
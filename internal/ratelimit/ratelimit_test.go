@@ -0,0 +1,47 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreRefillsBuckets(t *testing.T) {
+	store := NewMemoryStore()
+	policy := Policy{Name: "test", Requests: 2, Window: 10 * time.Second}
+	start := time.Date(2026, 3, 16, 12, 0, 0, 0, time.UTC)
+
+	takeAt := func(offset time.Duration) Result {
+		t.Helper()
+		result, err := store.Take(context.Background(), "ip:10.0.0.1", policy, start.Add(offset))
+		if err != nil {
+			t.Fatalf("take: %v", err)
+		}
+		return result
+	}
+
+	if r := takeAt(0); !r.Allowed || r.Remaining != 1 || r.ResetSeconds() != 5 {
+		t.Fatalf("expected the first request allowed, got %+v", r)
+	}
+	if r := takeAt(0); !r.Allowed || r.Remaining != 0 || r.ResetSeconds() != 10 {
+		t.Fatalf("expected the burst allowed, got %+v", r)
+	}
+
+	// A token is added every 5 seconds
+	if r := takeAt(2 * time.Second); r.Allowed || r.RetryAfterSeconds() != 3 {
+		t.Fatalf("expected a rejection for 3 more seconds, got %+v", r)
+	}
+	if r := takeAt(5 * time.Second); !r.Allowed || r.Remaining != 0 {
+		t.Fatalf("expected a refilled token, got %+v", r)
+	}
+
+	// Buckets never hold more than a burst
+	if r := takeAt(time.Hour); !r.Allowed || r.Remaining != 1 {
+		t.Fatalf("expected a full bucket, got %+v", r)
+	}
+
+	other, err := store.Take(context.Background(), "ip:10.0.0.2", policy, start)
+	if err != nil || !other.Allowed || other.Remaining != 1 {
+		t.Fatalf("expected clients to have buckets of their own, got %+v, %v", other, err)
+	}
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"foodcourt-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type DeviceRepository interface {
+	// List returns devices with kios and keys, optionally of a single kios
+	List(ctx context.Context, kiosID *uint) ([]models.Device, error)
+	// FindByID returns a device with kios and keys
+	FindByID(ctx context.Context, id uint) (*models.Device, error)
+	Create(ctx context.Context, device *models.Device) error
+	Update(ctx context.Context, device *models.Device) error
+	Delete(ctx context.Context, device *models.Device) error
+}
+
+type deviceRepository struct {
+	db *gorm.DB
+}
+
+func (r *deviceRepository) List(ctx context.Context, kiosID *uint) ([]models.Device, error) {
+	query := r.db.WithContext(ctx).Preload("Kios").Preload("APIKeys")
+	if kiosID != nil {
+		query = query.Where("kios_id = ?", *kiosID)
+	}
+
+	var devices []models.Device
+	if err := query.Order("id ASC").Find(&devices).Error; err != nil {
+		return nil, err
+	}
+	return devices, nil
+}
+
+func (r *deviceRepository) FindByID(ctx context.Context, id uint) (*models.Device, error) {
+	var device models.Device
+	if err := r.db.WithContext(ctx).Preload("Kios").Preload("APIKeys").First(&device, id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &device, nil
+}
+
+func (r *deviceRepository) Create(ctx context.Context, device *models.Device) error {
+	return r.db.WithContext(ctx).Omit("Kios").Create(device).Error
+}
+
+func (r *deviceRepository) Update(ctx context.Context, device *models.Device) error {
+	return r.db.WithContext(ctx).Model(device).Select("name", "is_active").Updates(device).Error
+}
+
+func (r *deviceRepository) Delete(ctx context.Context, device *models.Device) error {
+	return r.db.WithContext(ctx).Delete(device).Error
+}
+
+type DeviceKeyRepository interface {
+	// FindByDevice returns a key only if it belongs to the given device
+	FindByDevice(ctx context.Context, deviceID, keyID uint) (*models.DeviceAPIKey, error)
+	Create(ctx context.Context, key *models.DeviceAPIKey) error
+	Revoke(ctx context.Context, key *models.DeviceAPIKey, at time.Time) error
+	// RevokeAllForDevice revokes every key of a device that is not revoked yet
+	RevokeAllForDevice(ctx context.Context, deviceID uint, at time.Time) error
+}
+
+type deviceKeyRepository struct {
+	db *gorm.DB
+}
+
+func (r *deviceKeyRepository) FindByDevice(ctx context.Context, deviceID, keyID uint) (*models.DeviceAPIKey, error) {
+	var key models.DeviceAPIKey
+	if err := r.db.WithContext(ctx).Where("id = ? AND device_id = ?", keyID, deviceID).First(&key).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &key, nil
+}
+
+func (r *deviceKeyRepository) Create(ctx context.Context, key *models.DeviceAPIKey) error {
+	return r.db.WithContext(ctx).Omit("Device").Create(key).Error
+}
+
+func (r *deviceKeyRepository) Revoke(ctx context.Context, key *models.DeviceAPIKey, at time.Time) error {
+	if err := r.db.WithContext(ctx).Model(&models.DeviceAPIKey{}).Where("id = ?", key.ID).
+		Update("revoked_at", at).Error; err != nil {
+		return err
+	}
+	key.RevokedAt = &at
+	return nil
+}
+
+func (r *deviceKeyRepository) RevokeAllForDevice(ctx context.Context, deviceID uint, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.DeviceAPIKey{}).
+		Where("device_id = ? AND revoked_at IS NULL", deviceID).
+		Update("revoked_at", at).Error
+}
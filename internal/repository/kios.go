@@ -0,0 +1,199 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"foodcourt-backend/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type KiosFilter struct {
+	IsActive *bool
+	Search   string // Part of the name
+}
+
+// KiosSortFields are the fields kios can be sorted by
+var KiosSortFields = []string{"name", "location", "created_at"}
+
+type KiosRepository interface {
+	// List returns a page of kios, with their translations, and the total number of matches
+	List(ctx context.Context, filter KiosFilter, opts ListOptions) ([]models.Kios, int64, error)
+	// FindByID returns a kios with its translations
+	FindByID(ctx context.Context, id uint) (*models.Kios, error)
+	// Stats computes the aggregate figures of the given kios. Orders created at or
+	// after dayStart count as today's.
+	Stats(ctx context.Context, ids []uint, dayStart time.Time) (map[uint]models.KiosStats, error)
+	// QueueLengths counts the orders waiting in the queue of every active kios
+	QueueLengths(ctx context.Context) (map[uint]int64, error)
+	// Lock holds a row lock on the kios until the surrounding transaction ends
+	Lock(ctx context.Context, id uint) error
+	// Create stores a kios with its translations
+	Create(ctx context.Context, kios *models.Kios) error
+	// Update stores a kios at its next version, leaving its translations as
+	// they are. It returns ErrStale if the kios changed since it was read.
+	Update(ctx context.Context, kios *models.Kios) error
+	// ReplaceTranslations stores the translations of a kios, removing those it no longer has
+	ReplaceTranslations(ctx context.Context, kios *models.Kios) error
+	// Delete returns ErrStale if the kios changed since it was read
+	Delete(ctx context.Context, kios *models.Kios) error
+}
+
+type kiosRepository struct {
+	db *gorm.DB
+}
+
+func (r *kiosRepository) List(ctx context.Context, filter KiosFilter, opts ListOptions) ([]models.Kios, int64, error) {
+	scope := func(db *gorm.DB) *gorm.DB {
+		if filter.IsActive != nil {
+			db = db.Where("kios.is_active = ?", *filter.IsActive)
+		}
+		if filter.Search != "" {
+			db = whereContains(db, filter.Search, "kios.name")
+		}
+		return db
+	}
+
+	var total int64
+	if err := r.db.WithContext(ctx).Model(&models.Kios{}).Scopes(scope).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var kios []models.Kios
+	query := opts.apply(r.db.WithContext(ctx).Preload("Translations").Scopes(scope), KiosSortFields, "id ASC")
+	if err := query.Find(&kios).Error; err != nil {
+		return nil, 0, err
+	}
+	return kios, total, nil
+}
+
+func (r *kiosRepository) FindByID(ctx context.Context, id uint) (*models.Kios, error) {
+	var kios models.Kios
+	if err := r.db.WithContext(ctx).Preload("Translations").First(&kios, id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &kios, nil
+}
+
+// kiosAggregate is a row of a per-kios aggregate query
+type kiosAggregate struct {
+	KiosID  uint
+	Count   int64
+	Revenue float64
+}
+
+// Stats runs one grouped query per figure, each served by an index on kios_id,
+// instead of loading the menus and orders of every kios
+func (r *kiosRepository) Stats(ctx context.Context, ids []uint, dayStart time.Time) (map[uint]models.KiosStats, error) {
+	stats := make(map[uint]models.KiosStats, len(ids))
+	if len(ids) == 0 {
+		return stats, nil
+	}
+
+	menus, err := aggregateByKios(r.db.WithContext(ctx).Model(&models.Menu{}).
+		Select("kios_id, COUNT(*) AS count"), ids)
+	if err != nil {
+		return nil, err
+	}
+
+	orders, err := aggregateByKios(r.db.WithContext(ctx).Model(&models.Order{}).
+		Select("kios_id, COUNT(*) AS count"), ids)
+	if err != nil {
+		return nil, err
+	}
+
+	// Revenue counts the orders placed today that have been paid for
+	today, err := aggregateByKios(r.db.WithContext(ctx).Model(&models.Order{}).
+		Select("kios_id, COUNT(*) AS count, COALESCE(SUM(CASE WHEN status IN ? THEN total_amount ELSE 0 END), 0) AS revenue", models.PaidStatuses).
+		Where("created_at >= ?", dayStart), ids)
+	if err != nil {
+		return nil, err
+	}
+
+	queue, err := aggregateByKios(r.db.WithContext(ctx).Model(&models.Order{}).
+		Select("kios_id, COUNT(*) AS count").
+		Where("status IN ?", models.QueueStatuses), ids)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range ids {
+		stats[id] = models.KiosStats{
+			MenuCount:       menus[id].Count,
+			OrderCount:      orders[id].Count,
+			TodayOrderCount: today[id].Count,
+			QueueLength:     queue[id].Count,
+			TodayRevenue:    today[id].Revenue,
+		}
+	}
+	return stats, nil
+}
+
+func (r *kiosRepository) QueueLengths(ctx context.Context) (map[uint]int64, error) {
+	var ids []uint
+	if err := r.db.WithContext(ctx).Model(&models.Kios{}).Where("is_active = ?", true).Pluck("id", &ids).Error; err != nil {
+		return nil, err
+	}
+
+	queue, err := aggregateByKios(r.db.WithContext(ctx).Model(&models.Order{}).
+		Select("kios_id, COUNT(*) AS count").
+		Where("status IN ?", models.QueueStatuses), ids)
+	if err != nil {
+		return nil, err
+	}
+
+	lengths := make(map[uint]int64, len(ids))
+	for _, id := range ids {
+		lengths[id] = queue[id].Count
+	}
+	return lengths, nil
+}
+
+// aggregateByKios runs a query grouped by kios over the given kios
+func aggregateByKios(query *gorm.DB, ids []uint) (map[uint]kiosAggregate, error) {
+	var rows []kiosAggregate
+	if err := query.Where("kios_id IN ?", ids).Group("kios_id").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	result := make(map[uint]kiosAggregate, len(rows))
+	for _, row := range rows {
+		result[row.KiosID] = row
+	}
+	return result, nil
+}
+
+func (r *kiosRepository) Lock(ctx context.Context, id uint) error {
+	var kios models.Kios
+	err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&kios, id).Error
+	return translate(err)
+}
+
+func (r *kiosRepository) Create(ctx context.Context, kios *models.Kios) error {
+	return r.db.WithContext(ctx).Create(kios).Error
+}
+
+func (r *kiosRepository) Update(ctx context.Context, kios *models.Kios) error {
+	return saveVersioned(r.db.WithContext(ctx), kios, &kios.Version)
+}
+
+func (r *kiosRepository) ReplaceTranslations(ctx context.Context, kios *models.Kios) error {
+	db := r.db.WithContext(ctx)
+	if err := db.Where("kios_id = ?", kios.ID).Delete(&models.KiosTranslation{}).Error; err != nil {
+		return err
+	}
+	if len(kios.Translations) == 0 {
+		return nil
+	}
+	for i := range kios.Translations {
+		kios.Translations[i].ID = 0
+		kios.Translations[i].KiosID = kios.ID
+	}
+	return db.Create(&kios.Translations).Error
+}
+
+func (r *kiosRepository) Delete(ctx context.Context, kios *models.Kios) error {
+	return deleteVersioned(r.db.WithContext(ctx), kios, kios.Version)
+}
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// ListOptions selects a sorted page of a listing
+type ListOptions struct {
+	Offset int
+	Limit  int    // 0 returns all rows
+	Sort   string // One of the sort fields of the listing, empty for its default order
+	Desc   bool
+}
+
+// apply orders and pages a listing query. The ID breaks ties so that pages do not
+// overlap. Unknown sort fields fall back to the default order.
+func (o ListOptions) apply(query *gorm.DB, sortFields []string, defaultOrder string) *gorm.DB {
+	order := defaultOrder
+	if contains(sortFields, o.Sort) {
+		direction := " ASC"
+		if o.Desc {
+			direction = " DESC"
+		}
+		order = o.Sort + direction + ", id" + direction
+	}
+	query = query.Order(order)
+
+	if o.Offset > 0 {
+		query = query.Offset(o.Offset)
+	}
+	if o.Limit > 0 {
+		query = query.Limit(o.Limit)
+	}
+	return query
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// whereContains keeps rows where any of the columns contains search, ignoring case
+func whereContains(db *gorm.DB, search string, columns ...string) *gorm.DB {
+	replacer := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+	pattern := "%" + replacer.Replace(strings.ToLower(search)) + "%"
+
+	conditions := make([]string, len(columns))
+	args := make([]interface{}, len(columns))
+	for i, column := range columns {
+		conditions[i] = "LOWER(" + column + ") LIKE ? ESCAPE '\\'"
+		args[i] = pattern
+	}
+	return db.Where("("+strings.Join(conditions, " OR ")+")", args...)
+}
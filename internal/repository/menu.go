@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+
+	"foodcourt-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type MenuFilter struct {
+	Category    string
+	IsAvailable *bool
+	Search      string // Part of the name or description
+}
+
+// MenuSortFields are the fields menus can be sorted by
+var MenuSortFields = []string{"name", "price", "category", "created_at"}
+
+type MenuRepository interface {
+	// ListByKios returns a page of the menus of a kios, with their translations,
+	// and the total number of matches
+	ListByKios(ctx context.Context, kiosID uint, filter MenuFilter, opts ListOptions) ([]models.Menu, int64, error)
+	// FindByID returns a menu with its kios and their translations
+	FindByID(ctx context.Context, id uint) (*models.Menu, error)
+	// Create stores a menu with its translations
+	Create(ctx context.Context, menu *models.Menu) error
+	// Update stores a menu at its next version, leaving its translations as
+	// they are. It returns ErrStale if the menu changed since it was read.
+	Update(ctx context.Context, menu *models.Menu) error
+	// ReplaceTranslations stores the translations of a menu, removing those it no longer has
+	ReplaceTranslations(ctx context.Context, menu *models.Menu) error
+	// Delete returns ErrStale if the menu changed since it was read
+	Delete(ctx context.Context, menu *models.Menu) error
+}
+
+type menuRepository struct {
+	db *gorm.DB
+}
+
+func (r *menuRepository) ListByKios(ctx context.Context, kiosID uint, filter MenuFilter, opts ListOptions) ([]models.Menu, int64, error) {
+	scope := func(db *gorm.DB) *gorm.DB {
+		db = db.Where("kios_id = ?", kiosID)
+		if filter.Category != "" {
+			db = db.Where("category = ?", filter.Category)
+		}
+		if filter.IsAvailable != nil {
+			db = db.Where("is_available = ?", *filter.IsAvailable)
+		}
+		if filter.Search != "" {
+			db = whereContains(db, filter.Search, "name", "description")
+		}
+		return db
+	}
+
+	var total int64
+	if err := r.db.WithContext(ctx).Model(&models.Menu{}).Scopes(scope).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var menus []models.Menu
+	query := opts.apply(r.db.WithContext(ctx).Preload("Kios.Translations").Preload("Translations").Scopes(scope), MenuSortFields, "id ASC")
+	if err := query.Find(&menus).Error; err != nil {
+		return nil, 0, err
+	}
+	return menus, total, nil
+}
+
+func (r *menuRepository) FindByID(ctx context.Context, id uint) (*models.Menu, error) {
+	var menu models.Menu
+	if err := r.db.WithContext(ctx).Preload("Kios.Translations").Preload("Translations").First(&menu, id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &menu, nil
+}
+
+func (r *menuRepository) Create(ctx context.Context, menu *models.Menu) error {
+	return r.db.WithContext(ctx).Omit("Kios").Create(menu).Error
+}
+
+func (r *menuRepository) Update(ctx context.Context, menu *models.Menu) error {
+	return saveVersioned(r.db.WithContext(ctx), menu, &menu.Version)
+}
+
+func (r *menuRepository) ReplaceTranslations(ctx context.Context, menu *models.Menu) error {
+	db := r.db.WithContext(ctx)
+	if err := db.Where("menu_id = ?", menu.ID).Delete(&models.MenuTranslation{}).Error; err != nil {
+		return err
+	}
+	if len(menu.Translations) == 0 {
+		return nil
+	}
+	for i := range menu.Translations {
+		menu.Translations[i].ID = 0
+		menu.Translations[i].MenuID = menu.ID
+	}
+	return db.Create(&menu.Translations).Error
+}
+
+func (r *menuRepository) Delete(ctx context.Context, menu *models.Menu) error {
+	return deleteVersioned(r.db.WithContext(ctx), menu, menu.Version)
+}
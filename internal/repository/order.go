@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"foodcourt-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type OrderFilter struct {
+	KiosID   *uint
+	Statuses []models.OrderStatus
+	From     *time.Time // Created at or after
+	To       *time.Time // Created before
+}
+
+// OrderSortFields are the fields orders can be sorted by
+var OrderSortFields = []string{"created_at", "queue_number", "status", "total_amount"}
+
+type OrderRepository interface {
+	// List returns a page of orders with kios, items and creator, newest first by
+	// default, and the total number of matches
+	List(ctx context.Context, filter OrderFilter, opts ListOptions) ([]models.Order, int64, error)
+	// FindByID returns an order with kios, items and creator
+	FindByID(ctx context.Context, id uint) (*models.Order, error)
+	// CountCreatedBetween counts the orders of a kios created at or after from
+	// and before to
+	CountCreatedBetween(ctx context.Context, kiosID uint, from, to time.Time) (int64, error)
+	// Create stores an order together with its items
+	Create(ctx context.Context, order *models.Order) error
+	// Update stores an order at its next version, leaving its items as they
+	// are. It returns ErrStale if the order changed since it was read.
+	Update(ctx context.Context, order *models.Order) error
+}
+
+type orderRepository struct {
+	db *gorm.DB
+}
+
+func (r *orderRepository) preloaded(ctx context.Context) *gorm.DB {
+	return r.db.WithContext(ctx).Preload("Kios").Preload("OrderItems.Menu").Preload("Creator")
+}
+
+func (r *orderRepository) List(ctx context.Context, filter OrderFilter, opts ListOptions) ([]models.Order, int64, error) {
+	scope := func(db *gorm.DB) *gorm.DB {
+		if filter.KiosID != nil {
+			db = db.Where("kios_id = ?", *filter.KiosID)
+		}
+		if len(filter.Statuses) > 0 {
+			db = db.Where("status IN ?", filter.Statuses)
+		}
+		if filter.From != nil {
+			db = db.Where("created_at >= ?", *filter.From)
+		}
+		if filter.To != nil {
+			db = db.Where("created_at < ?", *filter.To)
+		}
+		return db
+	}
+
+	var total int64
+	if err := r.db.WithContext(ctx).Model(&models.Order{}).Scopes(scope).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var orders []models.Order
+	query := opts.apply(r.preloaded(ctx).Scopes(scope), OrderSortFields, "created_at DESC, id DESC")
+	if err := query.Find(&orders).Error; err != nil {
+		return nil, 0, err
+	}
+	return orders, total, nil
+}
+
+func (r *orderRepository) FindByID(ctx context.Context, id uint) (*models.Order, error) {
+	var order models.Order
+	if err := r.preloaded(ctx).First(&order, id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &order, nil
+}
+
+func (r *orderRepository) CountCreatedBetween(ctx context.Context, kiosID uint, from, to time.Time) (int64, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&models.Order{}).
+		Where("kios_id = ? AND created_at >= ? AND created_at < ?", kiosID, from, to).
+		Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (r *orderRepository) Create(ctx context.Context, order *models.Order) error {
+	// Items are created with the order, the referenced kios and creator are not touched
+	return r.db.WithContext(ctx).Omit("Kios", "Creator").Create(order).Error
+}
+
+func (r *orderRepository) Update(ctx context.Context, order *models.Order) error {
+	return saveVersioned(r.db.WithContext(ctx), order, &order.Version)
+}
@@ -0,0 +1,127 @@
+// Package repository abstracts data access behind interfaces so that services
+// do not depend on GORM. The GORM implementations live next to each interface.
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrNotFound is returned when a requested record does not exist
+var ErrNotFound = errors.New("record not found")
+
+// ErrStale is returned when a versioned record was changed or deleted since it
+// was read, so that writing it would overwrite the changes of someone else
+var ErrStale = errors.New("record changed since it was read")
+
+// Store gives access to all repositories. Repositories obtained from the Store
+// passed to a Transaction callback run inside that transaction.
+type Store interface {
+	Users() UserRepository
+	RecoveryCodes() RecoveryCodeRepository
+	PasswordResets() PasswordResetRepository
+	Kios() KiosRepository
+	Menus() MenuRepository
+	Orders() OrderRepository
+	Devices() DeviceRepository
+	DeviceKeys() DeviceKeyRepository
+	AuditLogs() AuditLogRepository
+
+	// Transaction runs fn in a database transaction, committing when fn returns nil
+	Transaction(ctx context.Context, fn func(tx Store) error) error
+}
+
+type gormStore struct {
+	db *gorm.DB
+}
+
+func NewStore(db *gorm.DB) Store {
+	return &gormStore{db: db}
+}
+
+func (s *gormStore) Users() UserRepository {
+	return &userRepository{db: s.db}
+}
+
+func (s *gormStore) RecoveryCodes() RecoveryCodeRepository {
+	return &recoveryCodeRepository{db: s.db}
+}
+
+func (s *gormStore) PasswordResets() PasswordResetRepository {
+	return &passwordResetRepository{db: s.db}
+}
+
+func (s *gormStore) Kios() KiosRepository {
+	return &kiosRepository{db: s.db}
+}
+
+func (s *gormStore) Menus() MenuRepository {
+	return &menuRepository{db: s.db}
+}
+
+func (s *gormStore) Orders() OrderRepository {
+	return &orderRepository{db: s.db}
+}
+
+func (s *gormStore) Devices() DeviceRepository {
+	return &deviceRepository{db: s.db}
+}
+
+func (s *gormStore) DeviceKeys() DeviceKeyRepository {
+	return &deviceKeyRepository{db: s.db}
+}
+
+func (s *gormStore) AuditLogs() AuditLogRepository {
+	return &auditLogRepository{db: s.db}
+}
+
+func (s *gormStore) Transaction(ctx context.Context, fn func(tx Store) error) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&gormStore{db: tx})
+	})
+}
+
+// translate maps GORM errors to repository errors
+func translate(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// saveVersioned stores all fields of a record, but not its associations, if it
+// is still at the version it was read at. The version is incremented on
+// success and left as it was on failure.
+func saveVersioned(db *gorm.DB, record interface{}, version *uint) error {
+	read := *version
+	*version = read + 1
+	ok, err := claimed(db.Model(record).Where("version = ?", read).
+		Select("*").Omit(clause.Associations).Updates(record))
+	if err != nil || !ok {
+		*version = read
+	}
+	if err == nil && !ok {
+		return ErrStale
+	}
+	return err
+}
+
+// deleteVersioned deletes a record if it is still at the version it was read at
+func deleteVersioned(db *gorm.DB, record interface{}, version uint) error {
+	ok, err := claimed(db.Where("version = ?", version).Delete(record))
+	if err == nil && !ok {
+		return ErrStale
+	}
+	return err
+}
+
+// claimed reports whether a conditional update changed exactly one row
+func claimed(result *gorm.DB) (bool, error) {
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
@@ -0,0 +1,284 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"foodcourt-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type UserFilter struct {
+	Role     models.UserRole
+	KiosID   *uint
+	IsActive *bool
+	Search   string // Part of the username, full name or email
+}
+
+// UserSortFields are the fields users can be sorted by
+var UserSortFields = []string{"username", "full_name", "role", "created_at"}
+
+type UserRepository interface {
+	// List returns a page of users with their kios and the total number of matches
+	List(ctx context.Context, filter UserFilter, opts ListOptions) ([]models.User, int64, error)
+	// FindByID returns a user with its kios, including inactive users
+	FindByID(ctx context.Context, id uint) (*models.User, error)
+	FindActiveByID(ctx context.Context, id uint) (*models.User, error)
+	FindActiveByUsername(ctx context.Context, username string) (*models.User, error)
+	// FindActiveByIdentifier looks up an active user by username or email
+	FindActiveByIdentifier(ctx context.Context, identifier string) (*models.User, error)
+	ExistsByUsernameOrEmail(ctx context.Context, username, email string) (bool, error)
+	Create(ctx context.Context, user *models.User) error
+	// UpdateProfile stores the email, name, role, kios and status of a user
+	UpdateProfile(ctx context.Context, user *models.User) error
+
+	// UpdatePassword stores a new password hash, lifts a required password change
+	// and increments the token version, returning the new version
+	UpdatePassword(ctx context.Context, id uint, hash string, changedAt time.Time) (uint, error)
+	// RevokeTokens increments the token version, ending all sessions and login
+	// challenges of a user, and returns the new version
+	RevokeTokens(ctx context.Context, id uint) (uint, error)
+
+	// SetTOTPSecret stores a pending TOTP secret, disabling two-factor authentication until confirmed
+	SetTOTPSecret(ctx context.Context, id uint, secret string) error
+	EnableTOTP(ctx context.Context, id uint) error
+	ClearTOTP(ctx context.Context, id uint) error
+	// ClaimTOTPCounter records counter as the last used time step unless it was already used
+	ClaimTOTPCounter(ctx context.Context, id uint, counter int64) (bool, error)
+}
+
+type userRepository struct {
+	db *gorm.DB
+}
+
+func (r *userRepository) List(ctx context.Context, filter UserFilter, opts ListOptions) ([]models.User, int64, error) {
+	scope := func(db *gorm.DB) *gorm.DB {
+		if filter.Role != "" {
+			db = db.Where("role = ?", filter.Role)
+		}
+		if filter.KiosID != nil {
+			db = db.Where("kios_id = ?", *filter.KiosID)
+		}
+		if filter.IsActive != nil {
+			db = db.Where("is_active = ?", *filter.IsActive)
+		}
+		if filter.Search != "" {
+			db = whereContains(db, filter.Search, "username", "full_name", "email")
+		}
+		return db
+	}
+
+	var total int64
+	if err := r.db.WithContext(ctx).Model(&models.User{}).Scopes(scope).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var users []models.User
+	query := opts.apply(r.db.WithContext(ctx).Preload("Kios").Scopes(scope), UserSortFields, "id ASC")
+	if err := query.Find(&users).Error; err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+func (r *userRepository) FindByID(ctx context.Context, id uint) (*models.User, error) {
+	var user models.User
+	if err := r.db.WithContext(ctx).Preload("Kios").First(&user, id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &user, nil
+}
+
+func (r *userRepository) FindActiveByID(ctx context.Context, id uint) (*models.User, error) {
+	var user models.User
+	if err := r.db.WithContext(ctx).Preload("Kios").
+		Where("id = ? AND is_active = ?", id, true).First(&user).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &user, nil
+}
+
+func (r *userRepository) FindActiveByUsername(ctx context.Context, username string) (*models.User, error) {
+	var user models.User
+	if err := r.db.WithContext(ctx).Preload("Kios").
+		Where("username = ? AND is_active = ?", username, true).First(&user).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &user, nil
+}
+
+func (r *userRepository) FindActiveByIdentifier(ctx context.Context, identifier string) (*models.User, error) {
+	var user models.User
+	if err := r.db.WithContext(ctx).
+		Where("(username = ? OR email = ?) AND is_active = ?", identifier, identifier, true).
+		First(&user).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &user, nil
+}
+
+func (r *userRepository) ExistsByUsernameOrEmail(ctx context.Context, username, email string) (bool, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&models.User{}).
+		Where("username = ? OR email = ?", username, email).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *userRepository) Create(ctx context.Context, user *models.User) error {
+	return r.db.WithContext(ctx).Create(user).Error
+}
+
+func (r *userRepository) UpdateProfile(ctx context.Context, user *models.User) error {
+	return r.db.WithContext(ctx).Model(user).
+		Select("email", "full_name", "role", "kios_id", "is_active").
+		Updates(user).Error
+}
+
+func (r *userRepository) UpdatePassword(ctx context.Context, id uint, hash string, changedAt time.Time) (uint, error) {
+	db := r.db.WithContext(ctx)
+	if err := db.Model(&models.User{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"password":             hash,
+		"password_changed_at":  changedAt,
+		"token_version":        gorm.Expr("token_version + 1"),
+		"must_change_password": false,
+	}).Error; err != nil {
+		return 0, err
+	}
+	return tokenVersion(db, id)
+}
+
+func (r *userRepository) RevokeTokens(ctx context.Context, id uint) (uint, error) {
+	db := r.db.WithContext(ctx)
+	if err := db.Model(&models.User{}).Where("id = ?", id).
+		Update("token_version", gorm.Expr("token_version + 1")).Error; err != nil {
+		return 0, err
+	}
+	return tokenVersion(db, id)
+}
+
+// tokenVersion reads the current token version of a user
+func tokenVersion(db *gorm.DB, id uint) (uint, error) {
+	var version uint
+	if err := db.Model(&models.User{}).Where("id = ?", id).Select("token_version").Scan(&version).Error; err != nil {
+		return 0, err
+	}
+	return version, nil
+}
+
+func (r *userRepository) SetTOTPSecret(ctx context.Context, id uint, secret string) error {
+	return r.resetTOTP(ctx, id, secret)
+}
+
+func (r *userRepository) EnableTOTP(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", id).
+		Update("totp_enabled", true).Error
+}
+
+func (r *userRepository) ClearTOTP(ctx context.Context, id uint) error {
+	return r.resetTOTP(ctx, id, "")
+}
+
+// resetTOTP replaces the secret and disables two-factor authentication
+func (r *userRepository) resetTOTP(ctx context.Context, id uint, secret string) error {
+	return r.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"totp_secret":       secret,
+		"totp_enabled":      false,
+		"totp_last_counter": 0,
+	}).Error
+}
+
+func (r *userRepository) ClaimTOTPCounter(ctx context.Context, id uint, counter int64) (bool, error) {
+	return claimed(r.db.WithContext(ctx).Model(&models.User{}).
+		Where("id = ? AND totp_last_counter < ?", id, counter).
+		Update("totp_last_counter", counter))
+}
+
+type RecoveryCodeRepository interface {
+	// Replace deletes all recovery codes of a user and stores the given hashes
+	Replace(ctx context.Context, userID uint, hashes []string) error
+	DeleteByUser(ctx context.Context, userID uint) error
+	// Use marks an unused code as used, reporting whether it was valid
+	Use(ctx context.Context, userID uint, hash string) (bool, error)
+}
+
+type recoveryCodeRepository struct {
+	db *gorm.DB
+}
+
+func (r *recoveryCodeRepository) Replace(ctx context.Context, userID uint, hashes []string) error {
+	if err := r.DeleteByUser(ctx, userID); err != nil {
+		return err
+	}
+
+	records := make([]models.RecoveryCode, len(hashes))
+	for i, hash := range hashes {
+		records[i] = models.RecoveryCode{
+			UserID:   userID,
+			CodeHash: hash,
+		}
+	}
+	return r.db.WithContext(ctx).Create(&records).Error
+}
+
+func (r *recoveryCodeRepository) DeleteByUser(ctx context.Context, userID uint) error {
+	return r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error
+}
+
+func (r *recoveryCodeRepository) Use(ctx context.Context, userID uint, hash string) (bool, error) {
+	return claimed(r.db.WithContext(ctx).Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hash).
+		Update("used_at", time.Now()))
+}
+
+type PasswordResetRepository interface {
+	Create(ctx context.Context, token *models.PasswordResetToken) error
+	// FindValidByHash returns an unused token that has not expired at now
+	FindValidByHash(ctx context.Context, hash string, now time.Time) (*models.PasswordResetToken, error)
+	// Use marks a token as used, reporting false if it was used concurrently
+	Use(ctx context.Context, id uint) (bool, error)
+	// InvalidateByUser marks all unused tokens of a user as used
+	InvalidateByUser(ctx context.Context, userID uint) error
+	// RequestedSince reports whether a token was created for a user after since
+	RequestedSince(ctx context.Context, userID uint, since time.Time) (bool, error)
+}
+
+type passwordResetRepository struct {
+	db *gorm.DB
+}
+
+func (r *passwordResetRepository) Create(ctx context.Context, token *models.PasswordResetToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+func (r *passwordResetRepository) FindValidByHash(ctx context.Context, hash string, now time.Time) (*models.PasswordResetToken, error) {
+	var token models.PasswordResetToken
+	if err := r.db.WithContext(ctx).
+		Where("token_hash = ? AND used_at IS NULL AND expires_at > ?", hash, now).
+		First(&token).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &token, nil
+}
+
+func (r *passwordResetRepository) Use(ctx context.Context, id uint) (bool, error) {
+	return claimed(r.db.WithContext(ctx).Model(&models.PasswordResetToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now()))
+}
+
+func (r *passwordResetRepository) InvalidateByUser(ctx context.Context, userID uint) error {
+	return r.db.WithContext(ctx).Model(&models.PasswordResetToken{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Update("used_at", time.Now()).Error
+}
+
+func (r *passwordResetRepository) RequestedSince(ctx context.Context, userID uint, since time.Time) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.PasswordResetToken{}).
+		Where("user_id = ? AND created_at > ?", userID, since).
+		Count(&count).Error
+	return count > 0, err
+}
@@ -0,0 +1,41 @@
+// Package requestinfo carries the authenticated caller and client details of a
+// request through context.Context, so services do not depend on the HTTP layer.
+package requestinfo
+
+import (
+	"context"
+
+	"foodcourt-backend/internal/models"
+)
+
+// Info describes who made a request and from where
+type Info struct {
+	UserID   *uint
+	Username string
+	Role     models.UserRole
+	KiosID   *uint // Kios of a kios user or of the calling device
+	DeviceID *uint // Set when the request was authenticated with a device key
+
+	ClientIP  string
+	UserAgent string
+	Method    string
+	Path      string
+}
+
+// IsDevice reports whether the request was made by a registered device
+func (i Info) IsDevice() bool {
+	return i.DeviceID != nil
+}
+
+type contextKey struct{}
+
+// With returns a copy of ctx carrying info
+func With(ctx context.Context, info Info) context.Context {
+	return context.WithValue(ctx, contextKey{}, info)
+}
+
+// From returns the request info of ctx, or an empty Info for background work
+func From(ctx context.Context) Info {
+	info, _ := ctx.Value(contextKey{}).(Info)
+	return info
+}
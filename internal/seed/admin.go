@@ -0,0 +1,53 @@
+package seed
+
+import (
+	"context"
+
+	"foodcourt-backend/internal/config"
+	"foodcourt-backend/internal/models"
+	"foodcourt-backend/pkg/auth"
+
+	"gorm.io/gorm"
+)
+
+// Bootstrap creates the initial cashier account when the database has no
+// users. It returns the random password of the account, which has to be changed
+// on first login, or an empty string when users already exist.
+func Bootstrap(ctx context.Context, db *gorm.DB, cfg config.AdminConfig) (string, error) {
+	var password string
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Unscoped().Model(&models.User{}).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return nil
+		}
+
+		generated, err := auth.GeneratePassword()
+		if err != nil {
+			return err
+		}
+		hashed, err := auth.HashPassword(generated)
+		if err != nil {
+			return err
+		}
+
+		admin := models.User{
+			Username:           cfg.Username,
+			Email:              cfg.Email,
+			Password:           hashed,
+			FullName:           "Administrator",
+			Role:               models.RoleCashier,
+			IsActive:           true,
+			MustChangePassword: true,
+		}
+		if err := tx.Create(&admin).Error; err != nil {
+			return err
+		}
+
+		password = generated
+		return nil
+	})
+	return password, err
+}
@@ -0,0 +1,317 @@
+// Package seed loads fixture data into the database and generates synthetic
+// order history for load testing and report development.
+package seed
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"foodcourt-backend/internal/i18n"
+	"foodcourt-backend/internal/models"
+	"foodcourt-backend/pkg/auth"
+
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+)
+
+//go:embed fixtures/*.yaml
+var fixtures embed.FS
+
+// Environments are the names of the built-in fixtures
+var Environments = []string{"demo", "test", "empty"}
+
+// Fixture is the data loaded into the database. Kios are matched by name,
+// menus by name within their kios and users by username, so loading a fixture
+// again only adds what is missing.
+type Fixture struct {
+	Kios  []KiosFixture `json:"kios" yaml:"kios"`
+	Users []UserFixture `json:"users" yaml:"users"`
+}
+
+type KiosFixture struct {
+	Name        string        `json:"name" yaml:"name"`
+	Description string        `json:"description" yaml:"description"`
+	Location    string        `json:"location" yaml:"location"`
+	IsActive    *bool         `json:"is_active" yaml:"is_active"` // Defaults to true
+	Menus       []MenuFixture `json:"menus" yaml:"menus"`
+
+	// Name and description by locale, for locales other than the one above
+	Translations map[string]models.Translation `json:"translations" yaml:"translations"`
+}
+
+type MenuFixture struct {
+	Name        string              `json:"name" yaml:"name"`
+	Description string              `json:"description" yaml:"description"`
+	Price       float64             `json:"price" yaml:"price"`
+	Category    models.MenuCategory `json:"category" yaml:"category"`
+	ImageURL    string              `json:"image_url" yaml:"image_url"`
+	IsAvailable *bool               `json:"is_available" yaml:"is_available"` // Defaults to true
+
+	// Name and description by locale, for locales other than the one above
+	Translations map[string]models.Translation `json:"translations" yaml:"translations"`
+}
+
+type UserFixture struct {
+	Username string          `json:"username" yaml:"username"`
+	Email    string          `json:"email" yaml:"email"`
+	FullName string          `json:"full_name" yaml:"full_name"`
+	Role     models.UserRole `json:"role" yaml:"role"`
+	Kios     string          `json:"kios" yaml:"kios"` // Name of the kios of a kios user
+	IsActive *bool           `json:"is_active" yaml:"is_active"`
+
+	// Either a plain password, hashed while loading, or a bcrypt hash
+	Password     string `json:"password" yaml:"password"`
+	PasswordHash string `json:"password_hash" yaml:"password_hash"`
+
+	// Fixture passwords are known, so they must be changed on first login unless
+	// this is set to false
+	MustChangePassword *bool `json:"must_change_password" yaml:"must_change_password"`
+}
+
+// Result counts the records created by Apply and those that already existed
+type Result struct {
+	Created int
+	Skipped int
+}
+
+// Builtin returns the built-in fixture of an environment
+func Builtin(env string) (*Fixture, error) {
+	data, err := fixtures.ReadFile("fixtures/" + env + ".yaml")
+	if err != nil {
+		return nil, fmt.Errorf("unknown seed environment %q, expected one of %s", env, strings.Join(Environments, ", "))
+	}
+	return parse(data, ".yaml")
+}
+
+// LoadFile reads a fixture from a YAML or JSON file
+func LoadFile(path string) (*Fixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	fixture, err := parse(data, strings.ToLower(filepath.Ext(path)))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return fixture, nil
+}
+
+func parse(data []byte, ext string) (*Fixture, error) {
+	var fixture Fixture
+	switch ext {
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&fixture); err != nil {
+			return nil, err
+		}
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(&fixture); err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported fixture format %q, expected .yaml, .yml or .json", ext)
+	}
+
+	if err := fixture.validate(); err != nil {
+		return nil, err
+	}
+	return &fixture, nil
+}
+
+func (f *Fixture) validate() error {
+	kios := make(map[string]bool, len(f.Kios))
+	for _, k := range f.Kios {
+		if k.Name == "" {
+			return errors.New("kios without a name")
+		}
+		kios[k.Name] = true
+		if err := validateTranslations(k.Translations); err != nil {
+			return fmt.Errorf("kios %s: %w", k.Name, err)
+		}
+
+		for _, menu := range k.Menus {
+			if menu.Name == "" {
+				return fmt.Errorf("kios %s: menu without a name", k.Name)
+			}
+			if err := validateTranslations(menu.Translations); err != nil {
+				return fmt.Errorf("kios %s: menu %s: %w", k.Name, menu.Name, err)
+			}
+			if menu.Price < 0 {
+				return fmt.Errorf("kios %s: menu %s has a negative price", k.Name, menu.Name)
+			}
+			switch menu.Category {
+			case models.CategoryFood, models.CategoryDrink, models.CategorySnack, models.CategoryDessert:
+			default:
+				return fmt.Errorf("kios %s: menu %s has unknown category %q", k.Name, menu.Name, menu.Category)
+			}
+		}
+	}
+
+	for _, user := range f.Users {
+		if user.Username == "" || user.Email == "" || user.FullName == "" {
+			return errors.New("users need a username, email and full_name")
+		}
+		if (user.Password == "") == (user.PasswordHash == "") {
+			return fmt.Errorf("user %s: set either password or password_hash", user.Username)
+		}
+		switch user.Role {
+		case models.RoleCashier:
+		case models.RoleKios:
+			if !kios[user.Kios] {
+				return fmt.Errorf("user %s: kios %q is not in the fixture", user.Username, user.Kios)
+			}
+		default:
+			return fmt.Errorf("user %s: unknown role %q", user.Username, user.Role)
+		}
+	}
+	return nil
+}
+
+func validateTranslations(translations map[string]models.Translation) error {
+	for locale, t := range translations {
+		if !i18n.IsSupported(locale) {
+			return fmt.Errorf("unsupported translation locale %q, expected one of %s", locale, strings.Join(i18n.Supported, ", "))
+		}
+		if t.Name == "" {
+			return fmt.Errorf("translation %s without a name", locale)
+		}
+	}
+	return nil
+}
+
+// Apply creates the records of the fixture that do not exist yet, in a single
+// transaction
+func Apply(ctx context.Context, db *gorm.DB, fixture *Fixture) (Result, error) {
+	var result Result
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		kiosIDs := make(map[string]uint, len(fixture.Kios))
+		for _, k := range fixture.Kios {
+			kios := models.Kios{
+				Name:        k.Name,
+				Description: k.Description,
+				Location:    k.Location,
+				IsActive:    true,
+			}
+			kios.SetTranslations(translations(k.Translations))
+			created, err := firstOrCreate(tx, &kios, "name = ?", k.Name)
+			if err != nil {
+				return fmt.Errorf("failed to create kios %s: %w", k.Name, err)
+			}
+			result.count(created)
+			kiosIDs[k.Name] = kios.ID
+
+			// Columns defaulting to true are only set to false after creating
+			if created && k.IsActive != nil && !*k.IsActive {
+				if err := tx.Model(&kios).Update("is_active", false).Error; err != nil {
+					return err
+				}
+			}
+
+			for _, m := range k.Menus {
+				menu := models.Menu{
+					KiosID:      kios.ID,
+					Name:        m.Name,
+					Description: m.Description,
+					Price:       m.Price,
+					Category:    m.Category,
+					ImageURL:    m.ImageURL,
+					IsAvailable: true,
+				}
+				menu.SetTranslations(translations(m.Translations))
+				created, err := firstOrCreate(tx, &menu, "kios_id = ? AND name = ?", kios.ID, m.Name)
+				if err != nil {
+					return fmt.Errorf("failed to create menu %s: %w", m.Name, err)
+				}
+				result.count(created)
+
+				if created && m.IsAvailable != nil && !*m.IsAvailable {
+					if err := tx.Model(&menu).Update("is_available", false).Error; err != nil {
+						return err
+					}
+				}
+			}
+		}
+
+		for _, u := range fixture.Users {
+			password := u.PasswordHash
+			if password == "" {
+				hashed, err := auth.HashPassword(u.Password)
+				if err != nil {
+					return err
+				}
+				password = hashed
+			}
+
+			user := models.User{
+				Username:           u.Username,
+				Email:              u.Email,
+				Password:           password,
+				FullName:           u.FullName,
+				Role:               u.Role,
+				IsActive:           true,
+				MustChangePassword: u.MustChangePassword == nil || *u.MustChangePassword,
+			}
+			if u.Role == models.RoleKios {
+				kiosID := kiosIDs[u.Kios]
+				user.KiosID = &kiosID
+			}
+
+			// Deleted users still hold their username
+			created, err := firstOrCreate(tx.Unscoped(), &user, "username = ?", u.Username)
+			if err != nil {
+				return fmt.Errorf("failed to create user %s: %w", u.Username, err)
+			}
+			result.count(created)
+
+			if created && u.IsActive != nil && !*u.IsActive {
+				if err := tx.Model(&user).Update("is_active", false).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	return result, err
+}
+
+// firstOrCreate loads the record matching the condition into dest, or creates
+// dest when there is none. It reports whether the record was created.
+func firstOrCreate(tx *gorm.DB, dest interface{}, query string, args ...interface{}) (bool, error) {
+	var count int64
+	if err := tx.Model(dest).Where(query, args...).Count(&count).Error; err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return false, tx.Where(query, args...).First(dest).Error
+	}
+	return true, tx.Create(dest).Error
+}
+
+// translations returns the translations of a fixture as a request setting them
+func translations(fixture map[string]models.Translation) models.TranslationsRequest {
+	req := make(models.TranslationsRequest, len(fixture))
+	for locale, t := range fixture {
+		req[locale] = &t
+	}
+	return req
+}
+
+func (r *Result) count(created bool) {
+	if created {
+		r.Created++
+	} else {
+		r.Skipped++
+	}
+}
@@ -0,0 +1,247 @@
+package seed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"time"
+
+	"foodcourt-backend/internal/businessday"
+	"foodcourt-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// hourWeights is the relative number of orders per opening hour, peaking at
+// lunch and dinner
+var hourWeights = map[int]float64{
+	8: 2, 9: 3, 10: 4, 11: 9, 12: 14, 13: 10, 14: 4,
+	15: 3, 16: 4, 17: 7, 18: 12, 19: 10, 20: 5, 21: 2,
+}
+
+var customerNames = []string{
+	"Budi", "Siti", "Agus", "Dewi", "Rina", "Andi", "Putri", "Joko",
+	"Wati", "Dimas", "Ayu", "Fajar", "Lestari", "Hendra", "Nur", "Yoga",
+}
+
+var paymentMethods = []models.PaymentMethod{
+	models.PaymentCash, models.PaymentCash, models.PaymentDigital, models.PaymentDigital, models.PaymentCard,
+}
+
+// HistoryOptions configures GenerateHistory
+type HistoryOptions struct {
+	Days         int        // Days of history before the day of Now
+	OrdersPerDay int        // Average orders per weekday across all kios, weekends get more
+	Seed         int64      // Seed of the random generator, the same seed gives the same history
+	Now          time.Time  // Defaults to the current time
+	Tax          models.Tax // Applied to the orders like to real ones, no tax by default
+
+	// Business days numbering the orders, defaults to days from midnight in
+	// the time zone of Now
+	Calendar *businessday.Calendar
+}
+
+// GenerateHistory creates completed and cancelled orders of active kios with
+// available menus for the days before today. Orders follow the opening hours
+// with lunch and dinner peaks, and popular kios get more of them. It returns
+// the number of orders created.
+func GenerateHistory(ctx context.Context, db *gorm.DB, opts HistoryOptions) (int, error) {
+	if opts.Days < 1 || opts.OrdersPerDay < 1 {
+		return 0, errors.New("history needs at least one day and one order per day")
+	}
+	if opts.Now.IsZero() {
+		opts.Now = time.Now()
+	}
+	calendar := businessday.New(opts.Now.Location(), 0)
+	if opts.Calendar != nil {
+		calendar = *opts.Calendar
+	}
+	rng := rand.New(rand.NewSource(opts.Seed))
+	db = db.WithContext(ctx)
+
+	var kios []models.Kios
+	if err := db.Where("is_active = ?", true).
+		Preload("Menus", "is_available = ?", true).
+		Order("id").Find(&kios).Error; err != nil {
+		return 0, err
+	}
+
+	// Kios staff create the orders of their kios, the cashier those of the others
+	creators, fallback, err := orderCreators(db)
+	if err != nil {
+		return 0, err
+	}
+
+	var stalls []stall
+	var totalPopularity float64
+	for _, k := range kios {
+		if len(k.Menus) == 0 {
+			continue
+		}
+		creator, ok := creators[k.ID]
+		if !ok {
+			creator = fallback
+		}
+		popularity := 0.5 + rng.Float64()
+		stalls = append(stalls, stall{kios: k, creator: creator, popularity: popularity, tax: opts.Tax})
+		totalPopularity += popularity
+	}
+	if len(stalls) == 0 {
+		return 0, errors.New("history needs an active kios with available menus")
+	}
+
+	today := calendar.Date(opts.Now)
+	created := 0
+	for day := opts.Days; day >= 1; day-- {
+		date := today.AddDate(0, 0, -day)
+
+		dayOrders := float64(opts.OrdersPerDay)
+		if weekday := date.Weekday(); weekday == time.Saturday || weekday == time.Sunday {
+			dayOrders *= 1.4
+		}
+		dayOrders *= 0.8 + 0.4*rng.Float64()
+
+		var orders []models.Order
+		for _, s := range stalls {
+			n := int(dayOrders*s.popularity/totalPopularity + 0.5)
+			kiosOrders, err := s.orders(db, rng, calendar, date, n)
+			if err != nil {
+				return created, err
+			}
+			orders = append(orders, kiosOrders...)
+		}
+
+		if len(orders) == 0 {
+			continue
+		}
+		if err := db.Transaction(func(tx *gorm.DB) error {
+			return tx.CreateInBatches(orders, 200).Error
+		}); err != nil {
+			return created, fmt.Errorf("failed to create orders of %s: %w", date.Format("2006-01-02"), err)
+		}
+		created += len(orders)
+	}
+
+	return created, nil
+}
+
+type stall struct {
+	kios       models.Kios
+	creator    uint
+	popularity float64
+	tax        models.Tax
+}
+
+// orders generates n orders of the kios on the business day date, numbered
+// after the orders the kios already has that day
+func (s stall) orders(db *gorm.DB, rng *rand.Rand, calendar businessday.Calendar, date time.Time, n int) ([]models.Order, error) {
+	var existing int64
+	if err := db.Unscoped().Model(&models.Order{}).
+		Where("kios_id = ? AND created_at >= ? AND created_at < ?", s.kios.ID, calendar.StartOf(date), calendar.StartOf(date.AddDate(0, 0, 1))).
+		Count(&existing).Error; err != nil {
+		return nil, err
+	}
+
+	times := make([]time.Time, n)
+	for i := range times {
+		times[i] = date.Add(time.Duration(randomHour(rng))*time.Hour + time.Duration(rng.Intn(3600))*time.Second)
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+
+	orders := make([]models.Order, n)
+	for i, createdAt := range times {
+		order := models.Order{
+			QueueNumber: fmt.Sprintf("K%d-%s-%03d", s.kios.ID, date.Format("20060102"), existing+int64(i)+1),
+			KiosID:      s.kios.ID,
+			CreatedBy:   s.creator,
+			CreatedAt:   createdAt,
+		}
+		if rng.Intn(10) < 7 {
+			order.CustomerName = customerNames[rng.Intn(len(customerNames))]
+		}
+
+		// One to three different menus
+		var itemsAmount float64
+		picked := rng.Perm(len(s.kios.Menus))
+		for _, index := range picked[:1+rng.Intn(min(3, len(picked)))] {
+			menu := s.kios.Menus[index]
+			quantity := 1 + rng.Intn(3)
+			order.OrderItems = append(order.OrderItems, models.OrderItem{
+				MenuID:   menu.ID,
+				Quantity: quantity,
+				Price:    menu.Price,
+				Subtotal: menu.Price * float64(quantity),
+			})
+			itemsAmount += menu.Price * float64(quantity)
+		}
+		order.TaxAmount, order.TotalAmount = s.tax.Apply(itemsAmount)
+
+		// Most orders are collected, some are cancelled before payment
+		at := createdAt
+		next := func(minMinutes, maxMinutes int) *time.Time {
+			at = at.Add(time.Duration(minMinutes*60+rng.Intn((maxMinutes-minMinutes)*60)) * time.Second)
+			t := at
+			return &t
+		}
+		if rng.Intn(100) < 7 {
+			order.Status = models.StatusCancelled
+			order.UpdatedAt = *next(1, 10)
+		} else {
+			method := paymentMethods[rng.Intn(len(paymentMethods))]
+			order.Status = models.StatusCompleted
+			order.PaymentMethod = &method
+			order.PaidAt = next(1, 3)
+			order.PreparedAt = next(1, 5)
+			order.ReadyAt = next(5, 15)
+			order.CompletedAt = next(1, 10)
+			order.UpdatedAt = *order.CompletedAt
+		}
+
+		orders[i] = order
+	}
+	return orders, nil
+}
+
+func randomHour(rng *rand.Rand) int {
+	var total float64
+	for _, weight := range hourWeights {
+		total += weight
+	}
+
+	r := rng.Float64() * total
+	for hour := 0; hour < 24; hour++ {
+		r -= hourWeights[hour]
+		if r < 0 {
+			return hour
+		}
+	}
+	return 12
+}
+
+// orderCreators returns the first active user of each kios and an active
+// cashier for kios without users
+func orderCreators(db *gorm.DB) (map[uint]uint, uint, error) {
+	var users []models.User
+	if err := db.Where("is_active = ?", true).Order("id").Find(&users).Error; err != nil {
+		return nil, 0, err
+	}
+
+	creators := make(map[uint]uint)
+	var fallback uint
+	for _, user := range users {
+		switch {
+		case user.Role == models.RoleKios && user.KiosID != nil:
+			if _, ok := creators[*user.KiosID]; !ok {
+				creators[*user.KiosID] = user.ID
+			}
+		case user.Role == models.RoleCashier && fallback == 0:
+			fallback = user.ID
+		}
+	}
+	if fallback == 0 {
+		return nil, 0, errors.New("history needs an active cashier user")
+	}
+	return creators, fallback, nil
+}
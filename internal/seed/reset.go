@@ -0,0 +1,48 @@
+package seed
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// tables are emptied by Reset, dependents before the tables they reference
+var tables = []string{
+	"audit_logs",
+	"password_reset_tokens",
+	"recovery_codes",
+	"device_api_keys",
+	"devices",
+	"order_items",
+	"orders",
+	"menu_translations",
+	"menus",
+	"users",
+	"kios_translations",
+	"kios",
+}
+
+// Reset deletes all data, keeping the schema. On PostgreSQL the ID sequences
+// restart as well.
+func Reset(ctx context.Context, db *gorm.DB) error {
+	db = db.WithContext(ctx)
+
+	if db.Dialector.Name() == "postgres" {
+		quoted := make([]string, len(tables))
+		for i, table := range tables {
+			quoted[i] = `"` + table + `"`
+		}
+		return db.Exec("TRUNCATE TABLE " + strings.Join(quoted, ", ") + " RESTART IDENTITY CASCADE").Error
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, table := range tables {
+			if err := tx.Exec(`DELETE FROM "` + table + `"`).Error; err != nil {
+				return fmt.Errorf("failed to empty %s: %w", table, err)
+			}
+		}
+		return nil
+	})
+}
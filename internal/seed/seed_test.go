@@ -0,0 +1,293 @@
+package seed_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"foodcourt-backend/internal/config"
+	"foodcourt-backend/internal/database"
+	"foodcourt-backend/internal/models"
+	"foodcourt-backend/internal/seed"
+	"foodcourt-backend/pkg/auth"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var testDatabases int
+
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	testDatabases++
+	db, err := gorm.Open(sqlite.Open(fmt.Sprintf("file:seed%d?mode=memory&cache=shared", testDatabases)), &gorm.Config{
+		Logger: logger.Discard,
+	})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("database instance: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err := (&database.Database{DB: db}).AutoMigrate(); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+func count(t *testing.T, db *gorm.DB, model interface{}) int64 {
+	t.Helper()
+
+	var n int64
+	if err := db.Model(model).Count(&n).Error; err != nil {
+		t.Fatalf("count: %v", err)
+	}
+	return n
+}
+
+func TestApplyBuiltinFixtures(t *testing.T) {
+	for _, env := range seed.Environments {
+		t.Run(env, func(t *testing.T) {
+			db := newTestDB(t)
+			fixture, err := seed.Builtin(env)
+			if err != nil {
+				t.Fatalf("load fixture: %v", err)
+			}
+
+			first, err := seed.Apply(context.Background(), db, fixture)
+			if err != nil {
+				t.Fatalf("apply: %v", err)
+			}
+			if first.Skipped != 0 {
+				t.Fatalf("expected nothing skipped on an empty database, got %+v", first)
+			}
+
+			// Applying again only adds what is missing
+			second, err := seed.Apply(context.Background(), db, fixture)
+			if err != nil {
+				t.Fatalf("apply again: %v", err)
+			}
+			if second.Created != 0 || second.Skipped != first.Created {
+				t.Fatalf("expected all %d records skipped, got %+v", first.Created, second)
+			}
+		})
+	}
+
+	if _, err := seed.Builtin("production"); err == nil {
+		t.Fatal("expected an unknown environment to fail")
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	path := write("fixture.json", `{
+		"kios": [{"name": "Soto Betawi", "is_active": false, "menus": [{"name": "Soto Daging", "price": 28000, "category": "food", "is_available": false, "translations": {"en": {"name": "Beef Soto"}}}]}],
+		"users": [{"username": "soto_user", "email": "soto@foodcourt.com", "full_name": "Pelayan Soto", "role": "kios", "kios": "Soto Betawi", "password": "rahasia-soto"}]
+	}`)
+	fixture, err := seed.LoadFile(path)
+	if err != nil {
+		t.Fatalf("load json: %v", err)
+	}
+
+	db := newTestDB(t)
+	if _, err := seed.Apply(context.Background(), db, fixture); err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+
+	var kios models.Kios
+	if err := db.Preload("Menus.Translations").First(&kios, "name = ?", "Soto Betawi").Error; err != nil {
+		t.Fatalf("find kios: %v", err)
+	}
+	if kios.IsActive || len(kios.Menus) != 1 || kios.Menus[0].IsAvailable {
+		t.Fatalf("expected an inactive kios with an unavailable menu, got %+v", kios)
+	}
+	if name := kios.Menus[0].Translated("en").Name; name != "Beef Soto" {
+		t.Fatalf("expected the menu translated to English, got %q", name)
+	}
+
+	var user models.User
+	if err := db.First(&user, "username = ?", "soto_user").Error; err != nil {
+		t.Fatalf("find user: %v", err)
+	}
+	if user.KiosID == nil || *user.KiosID != kios.ID || user.Password == "rahasia-soto" {
+		t.Fatalf("expected a hashed password and the kios assigned, got %+v", user)
+	}
+
+	invalid := map[string]string{
+		"unknown field.yaml":   "kios:\n  - name: A\n    colour: red\n",
+		"unknown role.yaml":    "users:\n  - {username: a, email: a@b.c, full_name: A, role: admin, password: x}\n",
+		"unknown kios.yaml":    "users:\n  - {username: a, email: a@b.c, full_name: A, role: kios, kios: B, password: x}\n",
+		"no password.yaml":     "users:\n  - {username: a, email: a@b.c, full_name: A, role: cashier}\n",
+		"unknown category.yml": "kios:\n  - name: A\n    menus: [{name: B, price: 1, category: coffee}]\n",
+		"unknown locale.yaml":  "kios:\n  - name: A\n    translations: {fr: {name: B}}\n",
+		"fixture.txt":          "kios: []\n",
+	}
+	for name, content := range invalid {
+		t.Run(name, func(t *testing.T) {
+			if _, err := seed.LoadFile(write(name, content)); err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
+
+func TestGenerateHistory(t *testing.T) {
+	db := newTestDB(t)
+	fixture, err := seed.Builtin("test")
+	if err != nil {
+		t.Fatalf("load fixture: %v", err)
+	}
+	if _, err := seed.Apply(context.Background(), db, fixture); err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+
+	now := time.Date(2026, 3, 16, 10, 0, 0, 0, time.Local) // A Monday
+	tax := models.Tax{Rate: 0.11}
+	opts := seed.HistoryOptions{Days: 14, OrdersPerDay: 100, Seed: 42, Now: now, Tax: tax}
+	created, err := seed.GenerateHistory(context.Background(), db, opts)
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	if created < 14*80 || created > 14*140 {
+		t.Fatalf("expected about 100 orders per day, got %d over 14 days", created)
+	}
+	if n := count(t, db, &models.Order{}); n != int64(created) {
+		t.Fatalf("expected %d stored orders, got %d", created, n)
+	}
+
+	var orders []models.Order
+	if err := db.Preload("OrderItems").Find(&orders).Error; err != nil {
+		t.Fatalf("find orders: %v", err)
+	}
+
+	today := time.Date(2026, 3, 16, 0, 0, 0, 0, time.Local)
+	byHour := map[int]int{}
+	for _, order := range orders {
+		createdAt := order.CreatedAt.In(time.Local)
+		if !createdAt.Before(today) || createdAt.Before(today.AddDate(0, 0, -14)) {
+			t.Fatalf("order %s created outside the history: %s", order.QueueNumber, createdAt)
+		}
+		byHour[createdAt.Hour()]++
+
+		var total float64
+		for _, item := range order.OrderItems {
+			total += item.Subtotal
+		}
+		wantTax, wantTotal := tax.Apply(total)
+		if len(order.OrderItems) == 0 || order.TaxAmount != wantTax || order.TotalAmount != wantTotal || wantTax == 0 {
+			t.Fatalf("order %s tax %v and total %v do not match its items", order.QueueNumber, order.TaxAmount, order.TotalAmount)
+		}
+
+		switch order.Status {
+		case models.StatusCompleted:
+			if order.PaidAt == nil || order.CompletedAt == nil || !order.CompletedAt.After(*order.PaidAt) {
+				t.Fatalf("completed order %s without payment and completion times", order.QueueNumber)
+			}
+		case models.StatusCancelled:
+			if order.PaidAt != nil {
+				t.Fatalf("cancelled order %s was paid", order.QueueNumber)
+			}
+		default:
+			t.Fatalf("unexpected status %s of a past order", order.Status)
+		}
+	}
+
+	// Lunch is busier than the morning and nothing happens at night
+	if byHour[12] <= byHour[9] || byHour[3] != 0 {
+		t.Fatalf("unexpected orders per hour %v", byHour)
+	}
+
+	// More history continues the queue numbers of the days already generated
+	if _, err := seed.GenerateHistory(context.Background(), db, seed.HistoryOptions{Days: 2, OrdersPerDay: 50, Seed: 7, Now: now}); err != nil {
+		t.Fatalf("generate more: %v", err)
+	}
+}
+
+func TestReset(t *testing.T) {
+	db := newTestDB(t)
+	fixture, err := seed.Builtin("test")
+	if err != nil {
+		t.Fatalf("load fixture: %v", err)
+	}
+	if _, err := seed.Apply(context.Background(), db, fixture); err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if _, err := seed.GenerateHistory(context.Background(), db, seed.HistoryOptions{Days: 1, OrdersPerDay: 10}); err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+
+	if err := seed.Reset(context.Background(), db); err != nil {
+		t.Fatalf("reset: %v", err)
+	}
+	for _, model := range []interface{}{&models.User{}, &models.Kios{}, &models.Menu{}, &models.Order{}, &models.OrderItem{}} {
+		if n := count(t, db.Unscoped(), model); n != 0 {
+			t.Fatalf("expected %T emptied, got %d rows", model, n)
+		}
+	}
+}
+
+func TestBootstrap(t *testing.T) {
+	db := newTestDB(t)
+	cfg := config.AdminConfig{Username: "admin", Email: "admin@foodcourt.local"}
+
+	password, err := seed.Bootstrap(context.Background(), db, cfg)
+	if err != nil {
+		t.Fatalf("bootstrap: %v", err)
+	}
+	if err := auth.ValidatePasswordStrength(password); err != nil {
+		t.Fatalf("expected a strong random password, got %q: %v", password, err)
+	}
+
+	var admin models.User
+	if err := db.First(&admin, "username = ?", "admin").Error; err != nil {
+		t.Fatalf("find admin: %v", err)
+	}
+	if admin.Role != models.RoleCashier || !admin.MustChangePassword || auth.CheckPassword(admin.Password, password) != nil {
+		t.Fatalf("unexpected admin %+v", admin)
+	}
+
+	// The password is only generated once
+	again, err := seed.Bootstrap(context.Background(), db, cfg)
+	if err != nil || again != "" {
+		t.Fatalf("expected no second admin, got %q, %v", again, err)
+	}
+	if n := count(t, db, &models.User{}); n != 1 {
+		t.Fatalf("expected a single user, got %d", n)
+	}
+}
+
+func TestFixtureUsersMustChangePassword(t *testing.T) {
+	db := newTestDB(t)
+	fixture, err := seed.Builtin("demo")
+	if err != nil {
+		t.Fatalf("load fixture: %v", err)
+	}
+	if _, err := seed.Apply(context.Background(), db, fixture); err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+
+	var exempt int64
+	if err := db.Model(&models.User{}).Where("must_change_password = ?", false).Count(&exempt).Error; err != nil {
+		t.Fatalf("count: %v", err)
+	}
+	if exempt != 0 {
+		t.Fatalf("expected every demo user to change the known password, %d do not", exempt)
+	}
+}
@@ -0,0 +1,25 @@
+package service
+
+import (
+	"context"
+
+	"foodcourt-backend/internal/models"
+	"foodcourt-backend/internal/repository"
+)
+
+type AuditService interface {
+	// List returns a page of audit entries and the total number of matches
+	List(ctx context.Context, filter repository.AuditLogFilter, opts repository.ListOptions) ([]models.AuditLog, int64, error)
+}
+
+type auditService struct {
+	store repository.Store
+}
+
+func NewAuditService(store repository.Store) AuditService {
+	return &auditService{store: store}
+}
+
+func (s *auditService) List(ctx context.Context, filter repository.AuditLogFilter, opts repository.ListOptions) ([]models.AuditLog, int64, error) {
+	return s.store.AuditLogs().List(ctx, filter, opts)
+}
@@ -0,0 +1,290 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"foodcourt-backend/internal/audit"
+	"foodcourt-backend/internal/config"
+	"foodcourt-backend/internal/logging"
+	"foodcourt-backend/internal/loginguard"
+	"foodcourt-backend/internal/models"
+	"foodcourt-backend/internal/notify"
+	"foodcourt-backend/internal/repository"
+	"foodcourt-backend/internal/requestinfo"
+	"foodcourt-backend/pkg/auth"
+)
+
+// LoginResult is either an access token or, when a second factor is needed to
+// finish the login, a challenge token
+type LoginResult struct {
+	Token string
+	User  *models.User
+
+	TwoFactorRequired      bool
+	TwoFactorSetupRequired bool
+	ChallengeToken         string
+
+	// Only set once, right after two-factor enrollment
+	RecoveryCodes []string
+}
+
+// TwoFactorEnrollment is a pending TOTP secret waiting for confirmation with a first code
+type TwoFactorEnrollment struct {
+	Secret          string
+	ProvisioningURI string
+}
+
+// AuthService handles logins, sessions, two-factor authentication and passwords.
+// Login throttling rejections are returned as *loginguard.BlockedError.
+type AuthService interface {
+	Login(ctx context.Context, username, password string) (*LoginResult, error)
+	Register(ctx context.Context, req models.RegisterRequest) (*models.User, error)
+	Me(ctx context.Context, userID uint) (*models.User, error)
+	// RefreshToken issues a fresh token for a valid, non-revoked token
+	RefreshToken(ctx context.Context, token string) (string, error)
+	// Unlock clears failed login counters of a username and/or IP address
+	Unlock(ctx context.Context, req models.UnlockLoginRequest) error
+
+	// VerifyTwoFactor completes a login with a TOTP code or a recovery code
+	VerifyTwoFactor(ctx context.Context, req models.VerifyTwoFactorRequest) (*LoginResult, error)
+	// StartTwoFactorSetup enrolls a user whose role requires two-factor authentication during login
+	StartTwoFactorSetup(ctx context.Context, challengeToken string) (*TwoFactorEnrollment, error)
+	// ConfirmTwoFactorSetup enables two-factor authentication with a first code and finishes the login
+	ConfirmTwoFactorSetup(ctx context.Context, challengeToken, code string) (*LoginResult, error)
+	EnrollTwoFactor(ctx context.Context, userID uint) (*TwoFactorEnrollment, error)
+	ConfirmTwoFactor(ctx context.Context, userID uint, code string) ([]string, error)
+	DisableTwoFactor(ctx context.Context, userID uint, password, code string) error
+	RegenerateRecoveryCodes(ctx context.Context, userID uint, code string) ([]string, error)
+	// ResetTwoFactor removes two-factor authentication from a user who lost their device
+	ResetTwoFactor(ctx context.Context, userID uint) error
+
+	// ChangePassword revokes all sessions and returns a new token for the caller
+	ChangePassword(ctx context.Context, userID uint, currentPassword, newPassword string) (string, error)
+	// ForgotPassword sends a reset token if an active account matches. It never reveals whether it does.
+	ForgotPassword(ctx context.Context, identifier string) error
+	ResetPassword(ctx context.Context, token, newPassword string) error
+}
+
+type authService struct {
+	store      repository.Store
+	jwtService *auth.JWTService
+	guard      *loginguard.Guard
+	notifier   notify.Notifier
+	twoFactor  config.TwoFactorConfig
+	password   config.PasswordConfig
+}
+
+func NewAuthService(store repository.Store, jwtService *auth.JWTService, guard *loginguard.Guard, notifier notify.Notifier, cfg *config.Config) AuthService {
+	return &authService{
+		store:      store,
+		jwtService: jwtService,
+		guard:      guard,
+		notifier:   notifier,
+		twoFactor:  cfg.TwoFactor,
+		password:   cfg.Password,
+	}
+}
+
+func (s *authService) Login(ctx context.Context, username, password string) (*LoginResult, error) {
+	// Reject attempts while the username or IP is throttled
+	attempt, err := s.loginAttempt(ctx, username)
+	if err != nil {
+		var blocked *loginguard.BlockedError
+		if errors.As(err, &blocked) {
+			s.recordLogin(ctx, audit.ActionLoginBlocked, nil, username, map[string]interface{}{
+				"retry_after": blocked.RetryAfterSeconds(),
+			})
+		}
+		return nil, err
+	}
+
+	// Find user by username
+	user, err := s.store.Users().FindActiveByUsername(ctx, username)
+	if err != nil {
+		if !errors.Is(err, repository.ErrNotFound) {
+			return nil, err
+		}
+		s.loginFailed(ctx, attempt, username, nil, "unknown_user")
+		return nil, withCode(unauthorized("Invalid credentials"), CodeInvalidCredentials)
+	}
+
+	// Check password
+	if err := auth.CheckPassword(user.Password, password); err != nil {
+		s.loginFailed(ctx, attempt, username, &user.ID, "invalid_password")
+		return nil, withCode(unauthorized("Invalid credentials"), CodeInvalidCredentials)
+	}
+	s.loginVerified(ctx, attempt, user.Username)
+
+	// Require the second factor before issuing an access token
+	if user.TOTPEnabled || s.twoFactor.IsRequiredFor(string(user.Role)) {
+		return s.twoFactorChallenge(user)
+	}
+
+	return s.completeLogin(ctx, user, nil)
+}
+
+// completeLogin issues the access token once all login factors are verified
+func (s *authService) completeLogin(ctx context.Context, user *models.User, metadata map[string]interface{}) (*LoginResult, error) {
+	token, err := s.jwtService.GenerateToken(user)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.guard.Succeed(ctx, user.Username); err != nil {
+		logging.FromContext(ctx).Error("Failed to reset login attempts", "username", user.Username, "error", err)
+	}
+	s.recordLogin(ctx, audit.ActionLoginSucceeded, &user.ID, user.Username, metadata)
+
+	return &LoginResult{
+		Token: token,
+		User:  user,
+	}, nil
+}
+
+// loginAttempt counts an attempt to log in as username from the client IP
+// before its credentials are checked. It returns a *loginguard.BlockedError
+// while the username or IP is throttled.
+func (s *authService) loginAttempt(ctx context.Context, username string) (*loginguard.Attempt, error) {
+	attempt, err := s.guard.Attempt(ctx, username, requestinfo.From(ctx).ClientIP)
+	if err != nil {
+		var blocked *loginguard.BlockedError
+		if errors.As(err, &blocked) {
+			return nil, blocked
+		}
+		// Fail open so that an unavailable store does not lock everybody out
+		logging.FromContext(ctx).Error("Login guard check failed", "username", username, "error", err)
+	}
+	return attempt, nil
+}
+
+// loginVerified takes back an attempt whose credentials were right
+func (s *authService) loginVerified(ctx context.Context, attempt *loginguard.Attempt, username string) {
+	if err := attempt.Verified(ctx); err != nil {
+		logging.FromContext(ctx).Error("Failed to release login attempt", "username", username, "error", err)
+	}
+}
+
+func (s *authService) loginFailed(ctx context.Context, attempt *loginguard.Attempt, username string, userID *uint, reason string) {
+	metadata := map[string]interface{}{"reason": reason}
+
+	// The attempt already counts as failed, this only reports what it earned
+	if blocked := attempt.Failed(); blocked != nil {
+		metadata["blocked_for"] = blocked.RetryAfterSeconds()
+		metadata["locked"] = blocked.Locked
+	}
+
+	s.recordLogin(ctx, audit.ActionLoginFailed, userID, username, metadata)
+}
+
+func (s *authService) recordLogin(ctx context.Context, action string, userID *uint, username string, metadata map[string]interface{}) {
+	recordBestEffort(ctx, s.store, audit.Entry{
+		Action:        action,
+		EntityType:    audit.EntityUser,
+		EntityID:      userID,
+		ActorID:       userID,
+		ActorUsername: username,
+		Metadata:      metadata,
+	})
+}
+
+func (s *authService) Unlock(ctx context.Context, req models.UnlockLoginRequest) error {
+	if err := s.guard.Unlock(ctx, req.Username, req.IPAddress); err != nil {
+		return err
+	}
+
+	recordBestEffort(ctx, s.store, audit.Entry{
+		Action:     audit.ActionLoginUnlocked,
+		EntityType: audit.EntityUser,
+		Metadata: map[string]interface{}{
+			"username":   req.Username,
+			"ip_address": req.IPAddress,
+		},
+	})
+	return nil
+}
+
+func (s *authService) Register(ctx context.Context, req models.RegisterRequest) (*models.User, error) {
+	// Check if username or email already exists
+	exists, err := s.store.Users().ExistsByUsernameOrEmail(ctx, req.Username, req.Email)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, withCode(conflict("Username or email already exists"), CodeAlreadyExists)
+	}
+
+	if err := auth.ValidatePasswordStrength(req.Password, req.Username, req.Email); err != nil {
+		return nil, weakPassword(err, "password")
+	}
+
+	hashedPassword, err := auth.HashPassword(req.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	user := models.User{
+		Username: req.Username,
+		Email:    req.Email,
+		Password: hashedPassword,
+		FullName: req.FullName,
+		Role:     req.Role,
+		KiosID:   req.KiosID,
+		IsActive: true,
+	}
+
+	err = s.store.Transaction(ctx, func(tx repository.Store) error {
+		if err := tx.Users().Create(ctx, &user); err != nil {
+			return err
+		}
+		return record(ctx, tx, audit.Entry{
+			Action:     audit.ActionUserRegistered,
+			EntityType: audit.EntityUser,
+			EntityID:   &user.ID,
+			After:      user.ToResponse(),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Load kios if exists
+	if user.KiosID != nil {
+		return s.store.Users().FindByID(ctx, user.ID)
+	}
+
+	return &user, nil
+}
+
+func (s *authService) Me(ctx context.Context, userID uint) (*models.User, error) {
+	user, err := s.store.Users().FindByID(ctx, userID)
+	if err != nil {
+		return nil, notFoundOr(err, "User not found")
+	}
+	return user, nil
+}
+
+func (s *authService) RefreshToken(ctx context.Context, token string) (string, error) {
+	claims, err := s.jwtService.ValidateToken(token)
+	if err != nil {
+		return "", unauthorized("Failed to refresh token")
+	}
+
+	// Revoked sessions cannot be refreshed
+	user, err := s.store.Users().FindActiveByID(ctx, claims.UserID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return "", unauthorized("Failed to refresh token")
+		}
+		return "", err
+	}
+	if user.TokenVersion != claims.Version {
+		return "", unauthorized("Failed to refresh token")
+	}
+
+	return s.jwtService.GenerateToken(user)
+}
+
+func weakPassword(err error, field string) *Error {
+	return &Error{Kind: KindInvalid, Code: CodeWeakPassword, Message: "Password does not meet requirements", Field: field, Err: err}
+}
@@ -0,0 +1,281 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"foodcourt-backend/internal/audit"
+	"foodcourt-backend/internal/models"
+	"foodcourt-backend/internal/repository"
+	"foodcourt-backend/internal/requestinfo"
+	"foodcourt-backend/pkg/auth"
+)
+
+// IssuedDeviceKey is a newly created key together with its plain text value,
+// which is not stored and can only be shown once
+type IssuedDeviceKey struct {
+	Key      *models.DeviceAPIKey
+	PlainKey string
+}
+
+type DeviceService interface {
+	List(ctx context.Context, kiosID *uint) ([]models.Device, error)
+	Get(ctx context.Context, id uint) (*models.Device, error)
+	Create(ctx context.Context, req models.CreateDeviceRequest) (*models.Device, error)
+	Update(ctx context.Context, id uint, req models.UpdateDeviceRequest) (*models.Device, error)
+	// Delete removes a device and revokes all of its keys
+	Delete(ctx context.Context, id uint) error
+
+	CreateKey(ctx context.Context, deviceID uint, req models.CreateDeviceKeyRequest) (*IssuedDeviceKey, error)
+	// RotateKey revokes an active key and issues a replacement with the same scopes and expiry
+	RotateKey(ctx context.Context, deviceID, keyID uint) (*IssuedDeviceKey, error)
+	RevokeKey(ctx context.Context, deviceID, keyID uint) (*models.DeviceAPIKey, error)
+}
+
+type deviceService struct {
+	store repository.Store
+}
+
+func NewDeviceService(store repository.Store) DeviceService {
+	return &deviceService{store: store}
+}
+
+func (s *deviceService) List(ctx context.Context, kiosID *uint) ([]models.Device, error) {
+	return s.store.Devices().List(ctx, kiosID)
+}
+
+func (s *deviceService) Get(ctx context.Context, id uint) (*models.Device, error) {
+	device, err := s.store.Devices().FindByID(ctx, id)
+	if err != nil {
+		return nil, notFoundOr(err, "Device not found")
+	}
+	return device, nil
+}
+
+func (s *deviceService) Create(ctx context.Context, req models.CreateDeviceRequest) (*models.Device, error) {
+	info := requestinfo.From(ctx)
+	if info.UserID == nil {
+		return nil, unauthorized("User required to register devices")
+	}
+
+	// Verify kios exists
+	kios, err := s.store.Kios().FindByID(ctx, req.KiosID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, invalid("Kios not found")
+		}
+		return nil, err
+	}
+
+	device := models.Device{
+		KiosID:    req.KiosID,
+		Kios:      *kios,
+		Name:      req.Name,
+		Type:      req.Type,
+		IsActive:  true,
+		CreatedBy: *info.UserID,
+	}
+
+	err = s.store.Transaction(ctx, func(tx repository.Store) error {
+		if err := tx.Devices().Create(ctx, &device); err != nil {
+			return err
+		}
+		return record(ctx, tx, audit.Entry{
+			Action:     audit.ActionDeviceCreated,
+			EntityType: audit.EntityDevice,
+			EntityID:   &device.ID,
+			After:      device.ToResponse(),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &device, nil
+}
+
+func (s *deviceService) Update(ctx context.Context, id uint, req models.UpdateDeviceRequest) (*models.Device, error) {
+	device, err := s.store.Devices().FindByID(ctx, id)
+	if err != nil {
+		return nil, notFoundOr(err, "Device not found")
+	}
+
+	before := device.ToResponse()
+
+	// Update fields
+	if req.Name != "" {
+		device.Name = req.Name
+	}
+	if req.IsActive != nil {
+		device.IsActive = *req.IsActive
+	}
+
+	err = s.store.Transaction(ctx, func(tx repository.Store) error {
+		if err := tx.Devices().Update(ctx, device); err != nil {
+			return err
+		}
+		return record(ctx, tx, audit.Entry{
+			Action:     audit.ActionDeviceUpdated,
+			EntityType: audit.EntityDevice,
+			EntityID:   &device.ID,
+			Before:     before,
+			After:      device.ToResponse(),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return device, nil
+}
+
+func (s *deviceService) Delete(ctx context.Context, id uint) error {
+	device, err := s.store.Devices().FindByID(ctx, id)
+	if err != nil {
+		return notFoundOr(err, "Device not found")
+	}
+
+	// Keys are not part of the audit snapshot of a deleted device
+	device.APIKeys = nil
+
+	return s.store.Transaction(ctx, func(tx repository.Store) error {
+		// Revoke all keys so they stop working even if the device is restored
+		if err := tx.DeviceKeys().RevokeAllForDevice(ctx, device.ID, time.Now()); err != nil {
+			return err
+		}
+		if err := tx.Devices().Delete(ctx, device); err != nil {
+			return err
+		}
+		return record(ctx, tx, audit.Entry{
+			Action:     audit.ActionDeviceDeleted,
+			EntityType: audit.EntityDevice,
+			EntityID:   &device.ID,
+			Before:     device.ToResponse(),
+		})
+	})
+}
+
+func (s *deviceService) CreateKey(ctx context.Context, deviceID uint, req models.CreateDeviceKeyRequest) (*IssuedDeviceKey, error) {
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, invalid("Expiry must be in the future")
+	}
+
+	device, err := s.store.Devices().FindByID(ctx, deviceID)
+	if err != nil {
+		return nil, notFoundOr(err, "Device not found")
+	}
+
+	var issued *IssuedDeviceKey
+	err = s.store.Transaction(ctx, func(tx repository.Store) error {
+		var err error
+		issued, err = issueKey(ctx, tx, device.ID, models.JoinScopes(req.Scopes), req.ExpiresAt)
+		if err != nil {
+			return err
+		}
+		return record(ctx, tx, audit.Entry{
+			Action:     audit.ActionDeviceKeyCreated,
+			EntityType: audit.EntityDeviceKey,
+			EntityID:   &issued.Key.ID,
+			After:      issued.Key.ToResponse(),
+			Metadata:   map[string]interface{}{"device_id": device.ID},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return issued, nil
+}
+
+func (s *deviceService) RotateKey(ctx context.Context, deviceID, keyID uint) (*IssuedDeviceKey, error) {
+	apiKey, err := s.store.DeviceKeys().FindByDevice(ctx, deviceID, keyID)
+	if err != nil {
+		return nil, notFoundOr(err, "Device key not found")
+	}
+
+	if !apiKey.IsUsable(time.Now()) {
+		return nil, invalid("Only active keys can be rotated")
+	}
+
+	var issued *IssuedDeviceKey
+	err = s.store.Transaction(ctx, func(tx repository.Store) error {
+		if err := tx.DeviceKeys().Revoke(ctx, apiKey, time.Now()); err != nil {
+			return err
+		}
+
+		var err error
+		issued, err = issueKey(ctx, tx, apiKey.DeviceID, apiKey.Scopes, apiKey.ExpiresAt)
+		if err != nil {
+			return err
+		}
+		return record(ctx, tx, audit.Entry{
+			Action:     audit.ActionDeviceKeyRotated,
+			EntityType: audit.EntityDeviceKey,
+			EntityID:   &issued.Key.ID,
+			After:      issued.Key.ToResponse(),
+			Metadata: map[string]interface{}{
+				"device_id":       apiKey.DeviceID,
+				"replaced_key_id": apiKey.ID,
+			},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return issued, nil
+}
+
+func (s *deviceService) RevokeKey(ctx context.Context, deviceID, keyID uint) (*models.DeviceAPIKey, error) {
+	apiKey, err := s.store.DeviceKeys().FindByDevice(ctx, deviceID, keyID)
+	if err != nil {
+		return nil, notFoundOr(err, "Device key not found")
+	}
+
+	// Revoking twice is not an error
+	if apiKey.RevokedAt != nil {
+		return apiKey, nil
+	}
+
+	before := apiKey.ToResponse()
+	err = s.store.Transaction(ctx, func(tx repository.Store) error {
+		if err := tx.DeviceKeys().Revoke(ctx, apiKey, time.Now()); err != nil {
+			return err
+		}
+		return record(ctx, tx, audit.Entry{
+			Action:     audit.ActionDeviceKeyRevoked,
+			EntityType: audit.EntityDeviceKey,
+			EntityID:   &apiKey.ID,
+			Before:     before,
+			After:      apiKey.ToResponse(),
+			Metadata:   map[string]interface{}{"device_id": apiKey.DeviceID},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return apiKey, nil
+}
+
+func issueKey(ctx context.Context, tx repository.Store, deviceID uint, scopes string, expiresAt *time.Time) (*IssuedDeviceKey, error) {
+	plainKey, prefix, hash, err := auth.GenerateAPIKey()
+	if err != nil {
+		return nil, err
+	}
+
+	apiKey := models.DeviceAPIKey{
+		DeviceID:  deviceID,
+		Prefix:    prefix,
+		KeyHash:   hash,
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+	}
+
+	if err := tx.DeviceKeys().Create(ctx, &apiKey); err != nil {
+		return nil, err
+	}
+
+	return &IssuedDeviceKey{Key: &apiKey, PlainKey: plainKey}, nil
+}
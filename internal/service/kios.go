@@ -0,0 +1,217 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"foodcourt-backend/internal/audit"
+	"foodcourt-backend/internal/businessday"
+	"foodcourt-backend/internal/models"
+	"foodcourt-backend/internal/repository"
+)
+
+type KiosService interface {
+	// List returns a page of kios, without their figures, and the total number of matches
+	List(ctx context.Context, filter repository.KiosFilter, opts repository.ListOptions) ([]models.Kios, int64, error)
+	// Today returns the business day in progress
+	Today() time.Time
+	// Stats computes the figures of the given kios as of the business day day
+	Stats(ctx context.Context, ids []uint, day time.Time) (map[uint]models.KiosStats, error)
+	Get(ctx context.Context, id uint) (*models.Kios, error)
+	Create(ctx context.Context, req models.CreateKiosRequest) (*models.Kios, error)
+	// Update changes a kios at a version accepted by match
+	Update(ctx context.Context, id uint, req models.UpdateKiosRequest, match Precondition) (*models.Kios, error)
+	// Patch changes the fields of a kios at a version accepted by match with
+	// apply, which receives their current values
+	Patch(ctx context.Context, id uint, apply func(*models.KiosPatch) error, match Precondition) (*models.Kios, error)
+	// Delete deletes a kios at a version accepted by match
+	Delete(ctx context.Context, id uint, match Precondition) error
+}
+
+// kiosChanged is reported when a kios changed since the caller read it
+const kiosChanged = "Kios has been changed by someone else, reload it and try again"
+
+type kiosService struct {
+	store    repository.Store
+	calendar businessday.Calendar
+	now      func() time.Time
+}
+
+// NewKiosService creates the kios service. The figures of today follow the
+// business days of calendar.
+func NewKiosService(store repository.Store, calendar businessday.Calendar) KiosService {
+	return &kiosService{
+		store:    store,
+		calendar: calendar,
+		now:      time.Now,
+	}
+}
+
+func (s *kiosService) List(ctx context.Context, filter repository.KiosFilter, opts repository.ListOptions) ([]models.Kios, int64, error) {
+	return s.store.Kios().List(ctx, filter, opts)
+}
+
+func (s *kiosService) Today() time.Time {
+	return s.calendar.Date(s.now())
+}
+
+func (s *kiosService) Stats(ctx context.Context, ids []uint, day time.Time) (map[uint]models.KiosStats, error) {
+	return s.store.Kios().Stats(ctx, ids, s.calendar.StartOf(day))
+}
+
+func (s *kiosService) Get(ctx context.Context, id uint) (*models.Kios, error) {
+	kios, err := s.store.Kios().FindByID(ctx, id)
+	if err != nil {
+		return nil, notFoundOr(err, "Kios not found")
+	}
+
+	kiosList := []models.Kios{*kios}
+	if err := s.attachStats(ctx, kiosList); err != nil {
+		return nil, err
+	}
+	return &kiosList[0], nil
+}
+
+// attachStats computes the aggregate figures of a page of kios in a fixed number of queries
+func (s *kiosService) attachStats(ctx context.Context, kios []models.Kios) error {
+	ids := make([]uint, len(kios))
+	for i, k := range kios {
+		ids[i] = k.ID
+	}
+
+	stats, err := s.Stats(ctx, ids, s.Today())
+	if err != nil {
+		return err
+	}
+	for i := range kios {
+		kios[i].Stats = stats[kios[i].ID]
+	}
+	return nil
+}
+
+func (s *kiosService) Create(ctx context.Context, req models.CreateKiosRequest) (*models.Kios, error) {
+	kios := models.Kios{
+		Name:        req.Name,
+		Description: req.Description,
+		Location:    req.Location,
+		IsActive:    true,
+	}
+	kios.SetTranslations(req.Translations)
+
+	err := s.store.Transaction(ctx, func(tx repository.Store) error {
+		if err := tx.Kios().Create(ctx, &kios); err != nil {
+			return err
+		}
+		return record(ctx, tx, audit.Entry{
+			Action:     audit.ActionKiosCreated,
+			EntityType: audit.EntityKios,
+			EntityID:   &kios.ID,
+			After:      kios.ToResponse(),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &kios, nil
+}
+
+func (s *kiosService) Update(ctx context.Context, id uint, req models.UpdateKiosRequest, match Precondition) (*models.Kios, error) {
+	kios, err := s.store.Kios().FindByID(ctx, id)
+	if err != nil {
+		return nil, notFoundOr(err, "Kios not found")
+	}
+	if err := match.check(kios.Version, kiosChanged); err != nil {
+		return nil, err
+	}
+
+	before := kios.ToResponse()
+
+	// Update fields
+	if req.Name != "" {
+		kios.Name = req.Name
+	}
+	if req.Description != "" {
+		kios.Description = req.Description
+	}
+	if req.Location != "" {
+		kios.Location = req.Location
+	}
+	if req.IsActive != nil {
+		kios.IsActive = *req.IsActive
+	}
+	if req.Translations != nil {
+		kios.SetTranslations(req.Translations)
+	}
+
+	return s.save(ctx, kios, before, req.Translations != nil, match)
+}
+
+func (s *kiosService) Patch(ctx context.Context, id uint, apply func(*models.KiosPatch) error, match Precondition) (*models.Kios, error) {
+	kios, err := s.store.Kios().FindByID(ctx, id)
+	if err != nil {
+		return nil, notFoundOr(err, "Kios not found")
+	}
+	if err := match.check(kios.Version, kiosChanged); err != nil {
+		return nil, err
+	}
+
+	patch := kios.Patchable()
+	if err := apply(&patch); err != nil {
+		return nil, err
+	}
+
+	before := kios.ToResponse()
+	kios.ApplyPatch(patch)
+	return s.save(ctx, kios, before, true, match)
+}
+
+// save stores an updated kios, whose previous state is before, and its
+// translations if they changed
+func (s *kiosService) save(ctx context.Context, kios *models.Kios, before *models.KiosResponse, translationsChanged bool, match Precondition) (*models.Kios, error) {
+	err := s.store.Transaction(ctx, func(tx repository.Store) error {
+		if err := tx.Kios().Update(ctx, kios); err != nil {
+			return err
+		}
+		if translationsChanged {
+			if err := tx.Kios().ReplaceTranslations(ctx, kios); err != nil {
+				return err
+			}
+		}
+		return record(ctx, tx, audit.Entry{
+			Action:     audit.ActionKiosUpdated,
+			EntityType: audit.EntityKios,
+			EntityID:   &kios.ID,
+			Before:     before,
+			After:      kios.ToResponse(),
+		})
+	})
+	if err != nil {
+		return nil, staleOr(err, match, kiosChanged)
+	}
+
+	return s.Get(ctx, kios.ID)
+}
+
+func (s *kiosService) Delete(ctx context.Context, id uint, match Precondition) error {
+	kios, err := s.store.Kios().FindByID(ctx, id)
+	if err != nil {
+		return notFoundOr(err, "Kios not found")
+	}
+	if err := match.check(kios.Version, kiosChanged); err != nil {
+		return err
+	}
+
+	err = s.store.Transaction(ctx, func(tx repository.Store) error {
+		if err := tx.Kios().Delete(ctx, kios); err != nil {
+			return err
+		}
+		return record(ctx, tx, audit.Entry{
+			Action:     audit.ActionKiosDeleted,
+			EntityType: audit.EntityKios,
+			EntityID:   &kios.ID,
+			Before:     kios.ToResponse(),
+		})
+	})
+	return staleOr(err, match, kiosChanged)
+}
@@ -0,0 +1,198 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"foodcourt-backend/internal/audit"
+	"foodcourt-backend/internal/models"
+	"foodcourt-backend/internal/repository"
+)
+
+type MenuService interface {
+	// ListByKios returns a page of the menus of a kios and the total number of matches
+	ListByKios(ctx context.Context, kiosID uint, filter repository.MenuFilter, opts repository.ListOptions) ([]models.Menu, int64, error)
+	Get(ctx context.Context, id uint) (*models.Menu, error)
+	Create(ctx context.Context, kiosID uint, req models.CreateMenuRequest) (*models.Menu, error)
+	// Update changes a menu at a version accepted by match
+	Update(ctx context.Context, id uint, req models.UpdateMenuRequest, match Precondition) (*models.Menu, error)
+	// Patch changes the fields of a menu at a version accepted by match with
+	// apply, which receives their current values
+	Patch(ctx context.Context, id uint, apply func(*models.MenuPatch) error, match Precondition) (*models.Menu, error)
+	// Delete deletes a menu at a version accepted by match
+	Delete(ctx context.Context, id uint, match Precondition) error
+}
+
+// menuChanged is reported when a menu changed since the caller read it
+const menuChanged = "Menu has been changed by someone else, reload it and try again"
+
+type menuService struct {
+	store repository.Store
+}
+
+func NewMenuService(store repository.Store) MenuService {
+	return &menuService{store: store}
+}
+
+func (s *menuService) ListByKios(ctx context.Context, kiosID uint, filter repository.MenuFilter, opts repository.ListOptions) ([]models.Menu, int64, error) {
+	return s.store.Menus().ListByKios(ctx, kiosID, filter, opts)
+}
+
+func (s *menuService) Get(ctx context.Context, id uint) (*models.Menu, error) {
+	menu, err := s.store.Menus().FindByID(ctx, id)
+	if err != nil {
+		return nil, notFoundOr(err, "Menu not found")
+	}
+	return menu, nil
+}
+
+func (s *menuService) Create(ctx context.Context, kiosID uint, req models.CreateMenuRequest) (*models.Menu, error) {
+	// Verify kios exists
+	kios, err := s.store.Kios().FindByID(ctx, kiosID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, invalid("Kios not found")
+		}
+		return nil, err
+	}
+
+	isAvailable := true
+	if req.IsAvailable != nil {
+		isAvailable = *req.IsAvailable
+	}
+
+	menu := models.Menu{
+		KiosID:      kiosID,
+		Kios:        *kios,
+		Name:        req.Name,
+		Description: req.Description,
+		Price:       req.Price,
+		Category:    req.Category,
+		ImageURL:    req.ImageURL,
+		IsAvailable: isAvailable,
+	}
+	menu.SetTranslations(req.Translations)
+
+	err = s.store.Transaction(ctx, func(tx repository.Store) error {
+		if err := tx.Menus().Create(ctx, &menu); err != nil {
+			return err
+		}
+		return record(ctx, tx, audit.Entry{
+			Action:     audit.ActionMenuCreated,
+			EntityType: audit.EntityMenu,
+			EntityID:   &menu.ID,
+			After:      menu.ToResponse(),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &menu, nil
+}
+
+func (s *menuService) Update(ctx context.Context, id uint, req models.UpdateMenuRequest, match Precondition) (*models.Menu, error) {
+	menu, err := s.store.Menus().FindByID(ctx, id)
+	if err != nil {
+		return nil, notFoundOr(err, "Menu not found")
+	}
+	if err := match.check(menu.Version, menuChanged); err != nil {
+		return nil, err
+	}
+
+	before := menu.ToResponse()
+
+	// Update fields
+	if req.Name != "" {
+		menu.Name = req.Name
+	}
+	if req.Description != "" {
+		menu.Description = req.Description
+	}
+	if req.Price != nil {
+		menu.Price = *req.Price
+	}
+	if req.Category != "" {
+		menu.Category = req.Category
+	}
+	if req.ImageURL != "" {
+		menu.ImageURL = req.ImageURL
+	}
+	if req.IsAvailable != nil {
+		menu.IsAvailable = *req.IsAvailable
+	}
+	if req.Translations != nil {
+		menu.SetTranslations(req.Translations)
+	}
+
+	return s.save(ctx, menu, before, req.Translations != nil, match)
+}
+
+func (s *menuService) Patch(ctx context.Context, id uint, apply func(*models.MenuPatch) error, match Precondition) (*models.Menu, error) {
+	menu, err := s.store.Menus().FindByID(ctx, id)
+	if err != nil {
+		return nil, notFoundOr(err, "Menu not found")
+	}
+	if err := match.check(menu.Version, menuChanged); err != nil {
+		return nil, err
+	}
+
+	patch := menu.Patchable()
+	if err := apply(&patch); err != nil {
+		return nil, err
+	}
+
+	before := menu.ToResponse()
+	menu.ApplyPatch(patch)
+	return s.save(ctx, menu, before, true, match)
+}
+
+// save stores an updated menu, whose previous state is before, and its
+// translations if they changed
+func (s *menuService) save(ctx context.Context, menu *models.Menu, before *models.MenuResponse, translationsChanged bool, match Precondition) (*models.Menu, error) {
+	err := s.store.Transaction(ctx, func(tx repository.Store) error {
+		if err := tx.Menus().Update(ctx, menu); err != nil {
+			return err
+		}
+		if translationsChanged {
+			if err := tx.Menus().ReplaceTranslations(ctx, menu); err != nil {
+				return err
+			}
+		}
+		return record(ctx, tx, audit.Entry{
+			Action:     audit.ActionMenuUpdated,
+			EntityType: audit.EntityMenu,
+			EntityID:   &menu.ID,
+			Before:     before,
+			After:      menu.ToResponse(),
+		})
+	})
+	if err != nil {
+		return nil, staleOr(err, match, menuChanged)
+	}
+
+	return menu, nil
+}
+
+func (s *menuService) Delete(ctx context.Context, id uint, match Precondition) error {
+	menu, err := s.store.Menus().FindByID(ctx, id)
+	if err != nil {
+		return notFoundOr(err, "Menu not found")
+	}
+	if err := match.check(menu.Version, menuChanged); err != nil {
+		return err
+	}
+
+	err = s.store.Transaction(ctx, func(tx repository.Store) error {
+		if err := tx.Menus().Delete(ctx, menu); err != nil {
+			return err
+		}
+		return record(ctx, tx, audit.Entry{
+			Action:     audit.ActionMenuDeleted,
+			EntityType: audit.EntityMenu,
+			EntityID:   &menu.ID,
+			Before:     menu.ToResponse(),
+		})
+	})
+	return staleOr(err, match, menuChanged)
+}
@@ -0,0 +1,272 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"foodcourt-backend/internal/audit"
+	"foodcourt-backend/internal/businessday"
+	"foodcourt-backend/internal/models"
+	"foodcourt-backend/internal/repository"
+	"foodcourt-backend/internal/requestinfo"
+)
+
+type OrderService interface {
+	Create(ctx context.Context, kiosID uint, req models.CreateOrderRequest) (*models.Order, error)
+	// List returns a page of orders and the total number of matches
+	List(ctx context.Context, filter repository.OrderFilter, opts repository.ListOptions) ([]models.Order, int64, error)
+	// Get returns an order, denying devices access to orders of other kios
+	Get(ctx context.Context, id uint) (*models.Order, error)
+	// UpdateStatus moves an order at a version accepted by match to a new status.
+	// Devices may only mark orders of their kios as ready. Of concurrent updates
+	// of one order only the first succeeds.
+	UpdateStatus(ctx context.Context, id uint, req models.UpdateOrderStatusRequest, match Precondition) (*models.Order, error)
+	// Queue returns the active orders of a kios, oldest first
+	Queue(ctx context.Context, kiosID uint) ([]models.Order, error)
+}
+
+// OrderObserver is told about order changes after they have been committed,
+// e.g. to count them in metrics
+type OrderObserver interface {
+	OrderCreated(order *models.Order)
+	OrderStatusChanged(order *models.Order, from models.OrderStatus)
+}
+
+type noopOrderObserver struct{}
+
+func (noopOrderObserver) OrderCreated(*models.Order)                           {}
+func (noopOrderObserver) OrderStatusChanged(*models.Order, models.OrderStatus) {}
+
+// orderChanged is reported when an order changed since the caller read it
+const orderChanged = "Order has been changed by someone else, reload it and try again"
+
+type orderService struct {
+	store    repository.Store
+	observer OrderObserver
+	calendar businessday.Calendar
+	tax      models.Tax
+	now      func() time.Time
+}
+
+// NewOrderService creates the order service. observer may be nil. Queue
+// numbers restart every business day of calendar, and totals include tax.
+func NewOrderService(store repository.Store, observer OrderObserver, calendar businessday.Calendar, tax models.Tax) OrderService {
+	if observer == nil {
+		observer = noopOrderObserver{}
+	}
+	return &orderService{
+		store:    store,
+		observer: observer,
+		calendar: calendar,
+		tax:      tax,
+		now:      time.Now,
+	}
+}
+
+// queueNumber generates the next queue number of a kios: K{kiosID}-{business date}-{sequence}
+func (s *orderService) queueNumber(ctx context.Context, tx repository.Store, kiosID uint, now time.Time) (string, error) {
+	start, end := s.calendar.Bounds(now)
+	count, err := tx.Orders().CountCreatedBetween(ctx, kiosID, start, end)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("K%d-%s-%03d", kiosID, s.calendar.Date(now).Format("20060102"), count+1), nil
+}
+
+func (s *orderService) Create(ctx context.Context, kiosID uint, req models.CreateOrderRequest) (*models.Order, error) {
+	info := requestinfo.From(ctx)
+	if info.UserID == nil {
+		return nil, unauthorized("User required to create orders")
+	}
+
+	// Verify kios exists
+	kios, err := s.store.Kios().FindByID(ctx, kiosID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, invalid("Kios not found")
+		}
+		return nil, err
+	}
+
+	var order models.Order
+	err = s.store.Transaction(ctx, func(tx repository.Store) error {
+		// Price items and calculate total
+		var itemsAmount float64
+		items := make([]models.OrderItem, 0, len(req.Items))
+		menus := make([]models.Menu, 0, len(req.Items))
+		for _, item := range req.Items {
+			menu, err := tx.Menus().FindByID(ctx, item.MenuID)
+			if err != nil {
+				if errors.Is(err, repository.ErrNotFound) {
+					return invalidf("Menu with ID %d not found", item.MenuID)
+				}
+				return err
+			}
+
+			if !menu.IsAvailable {
+				return withCode(invalidf("Menu '%s' is not available", menu.Name), CodeMenuUnavailable)
+			}
+
+			subtotal := menu.Price * float64(item.Quantity)
+			itemsAmount += subtotal
+
+			items = append(items, models.OrderItem{
+				MenuID:   item.MenuID,
+				Quantity: item.Quantity,
+				Price:    menu.Price,
+				Subtotal: subtotal,
+				Notes:    item.Notes,
+			})
+			menus = append(menus, *menu)
+		}
+
+		// Serialize order creation per kios so concurrent orders get distinct queue numbers
+		if err := tx.Kios().Lock(ctx, kiosID); err != nil {
+			return err
+		}
+
+		taxAmount, totalAmount := s.tax.Apply(itemsAmount)
+
+		now := s.now()
+		queueNumber, err := s.queueNumber(ctx, tx, kiosID, now)
+		if err != nil {
+			return err
+		}
+
+		order = models.Order{
+			QueueNumber:  queueNumber,
+			KiosID:       kiosID,
+			CustomerName: req.CustomerName,
+			Status:       models.StatusPending,
+			TotalAmount:  totalAmount,
+			TaxAmount:    taxAmount,
+			Notes:        req.Notes,
+			OrderItems:   items,
+			CreatedBy:    *info.UserID,
+			CreatedAt:    now,
+		}
+
+		if err := tx.Orders().Create(ctx, &order); err != nil {
+			return err
+		}
+
+		// Record audit entry as part of the order transaction
+		order.Kios = *kios
+		for i := range order.OrderItems {
+			order.OrderItems[i].Menu = menus[i]
+		}
+		return record(ctx, tx, audit.Entry{
+			Action:     audit.ActionOrderCreated,
+			EntityType: audit.EntityOrder,
+			EntityID:   &order.ID,
+			After:      order.ToResponse(),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.observer.OrderCreated(&order)
+
+	// Load complete order data
+	return s.store.Orders().FindByID(ctx, order.ID)
+}
+
+func (s *orderService) List(ctx context.Context, filter repository.OrderFilter, opts repository.ListOptions) ([]models.Order, int64, error) {
+	return s.store.Orders().List(ctx, filter, opts)
+}
+
+func (s *orderService) Get(ctx context.Context, id uint) (*models.Order, error) {
+	order, err := s.store.Orders().FindByID(ctx, id)
+	if err != nil {
+		return nil, notFoundOr(err, "Order not found")
+	}
+
+	// Devices can only read orders of their own kios
+	if !canAccessKios(ctx, order.KiosID) {
+		return nil, forbidden("Access denied to this order")
+	}
+
+	return order, nil
+}
+
+func (s *orderService) UpdateStatus(ctx context.Context, id uint, req models.UpdateOrderStatusRequest, match Precondition) (*models.Order, error) {
+	// Devices can only mark orders as ready
+	if requestinfo.From(ctx).IsDevice() && req.Status != models.StatusReady {
+		return nil, forbidden("Devices can only mark orders as ready")
+	}
+
+	order, err := s.store.Orders().FindByID(ctx, id)
+	if err != nil {
+		return nil, notFoundOr(err, "Order not found")
+	}
+
+	if !canAccessKios(ctx, order.KiosID) {
+		return nil, forbidden("Access denied to this order")
+	}
+	if err := match.check(order.Version, orderChanged); err != nil {
+		return nil, err
+	}
+
+	before := order.ToResponse()
+	from := order.Status
+
+	// Update status and timestamps
+	now := s.now()
+	order.Status = req.Status
+
+	switch req.Status {
+	case models.StatusPaid:
+		order.PaidAt = &now
+		order.PaymentMethod = req.PaymentMethod
+	case models.StatusPreparing:
+		order.PreparedAt = &now
+	case models.StatusReady:
+		order.ReadyAt = &now
+	case models.StatusCompleted:
+		order.CompletedAt = &now
+	}
+
+	err = s.store.Transaction(ctx, func(tx repository.Store) error {
+		if err := tx.Orders().Update(ctx, order); err != nil {
+			return err
+		}
+		return record(ctx, tx, audit.Entry{
+			Action:     audit.ActionOrderStatusUpdated,
+			EntityType: audit.EntityOrder,
+			EntityID:   &order.ID,
+			Before:     before,
+			After:      order.ToResponse(),
+		})
+	})
+	if err != nil {
+		return nil, staleOr(err, match, orderChanged)
+	}
+	s.observer.OrderStatusChanged(order, from)
+
+	return order, nil
+}
+
+func (s *orderService) Queue(ctx context.Context, kiosID uint) ([]models.Order, error) {
+	// Devices can only read the queue of their own kios
+	if !canAccessKios(ctx, kiosID) {
+		return nil, forbidden("Access denied to this kios")
+	}
+
+	orders, _, err := s.store.Orders().List(ctx, repository.OrderFilter{
+		KiosID:   &kiosID,
+		Statuses: models.QueueStatuses,
+	}, repository.ListOptions{Sort: "created_at"})
+	return orders, err
+}
+
+// canAccessKios reports whether the caller may access data of a kios. Devices are
+// bound to a single kios; user access is enforced by the HTTP layer.
+func canAccessKios(ctx context.Context, kiosID uint) bool {
+	info := requestinfo.From(ctx)
+	if !info.IsDevice() {
+		return true
+	}
+	return info.KiosID != nil && *info.KiosID == kiosID
+}
@@ -0,0 +1,205 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"time"
+
+	"foodcourt-backend/internal/audit"
+	"foodcourt-backend/internal/logging"
+	"foodcourt-backend/internal/models"
+	"foodcourt-backend/internal/notify"
+	"foodcourt-backend/internal/repository"
+	"foodcourt-backend/internal/requestinfo"
+	"foodcourt-backend/internal/tracing"
+	"foodcourt-backend/pkg/auth"
+)
+
+func (s *authService) ChangePassword(ctx context.Context, userID uint, currentPassword, newPassword string) (string, error) {
+	user, err := s.Me(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+
+	// Count as a login attempt so a stolen session cannot be used to guess the password
+	attempt, err := s.loginAttempt(ctx, user.Username)
+	if err != nil {
+		return "", err
+	}
+
+	if err := auth.CheckPassword(user.Password, currentPassword); err != nil {
+		return "", unauthorized("Current password is incorrect")
+	}
+	s.loginVerified(ctx, attempt, user.Username)
+
+	if newPassword == currentPassword {
+		return "", invalid("New password must differ from the current password")
+	}
+
+	if err := s.setPassword(ctx, user, newPassword, audit.Entry{Action: audit.ActionPasswordChanged}, nil); err != nil {
+		return "", err
+	}
+
+	// Keep the session that changed the password
+	return s.jwtService.GenerateToken(user)
+}
+
+func (s *authService) ForgotPassword(ctx context.Context, identifier string) error {
+	user, err := s.store.Users().FindActiveByIdentifier(ctx, identifier)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	// The IP rate limit alone would let many addresses flood one mailbox
+	if s.password.ResetCooldown > 0 {
+		recent, err := s.store.PasswordResets().RequestedSince(ctx, user.ID, time.Now().Add(-s.password.ResetCooldown))
+		if err != nil {
+			return err
+		}
+		if recent {
+			logging.FromContext(ctx).Info("Password reset throttled", "username", user.Username)
+			return nil
+		}
+	}
+
+	token, tokenHash, err := auth.GenerateResetToken()
+	if err != nil {
+		return err
+	}
+
+	resetToken := models.PasswordResetToken{
+		UserID:      user.ID,
+		TokenHash:   tokenHash,
+		ExpiresAt:   time.Now().Add(s.password.ResetExpiresIn),
+		RequestedIP: requestinfo.From(ctx).ClientIP,
+	}
+
+	err = s.store.Transaction(ctx, func(tx repository.Store) error {
+		// Only the most recent token stays valid
+		if err := tx.PasswordResets().InvalidateByUser(ctx, user.ID); err != nil {
+			return err
+		}
+		if err := tx.PasswordResets().Create(ctx, &resetToken); err != nil {
+			return err
+		}
+		return record(ctx, tx, audit.Entry{
+			Action:        audit.ActionPasswordResetRequested,
+			EntityType:    audit.EntityUser,
+			EntityID:      &user.ID,
+			ActorID:       &user.ID,
+			ActorUsername: user.Username,
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	reset := notify.PasswordReset{
+		User:      user,
+		Token:     token,
+		ExpiresAt: resetToken.ExpiresAt,
+	}
+	if s.password.ResetURL != "" {
+		reset.ResetURL = s.password.ResetURL + "?token=" + url.QueryEscape(token)
+	}
+
+	// Delivery failures are not reported to the caller, who must not learn whether the account exists
+	sendCtx, span := tracing.Start(context.WithoutCancel(ctx), "notify.password_reset")
+	err = s.notifier.SendPasswordReset(sendCtx, reset)
+	tracing.End(span, err)
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to send password reset", "username", user.Username, "error", err)
+	}
+
+	return nil
+}
+
+func (s *authService) ResetPassword(ctx context.Context, token, newPassword string) error {
+	resetToken, err := s.store.PasswordResets().FindValidByHash(ctx, auth.HashResetToken(token), time.Now())
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return invalid("Invalid or expired reset token")
+		}
+		return err
+	}
+
+	user, err := s.store.Users().FindActiveByID(ctx, resetToken.UserID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return invalid("Invalid or expired reset token")
+		}
+		return err
+	}
+
+	// Claim the token so it can only be used once, even by concurrent requests
+	consume := func(tx repository.Store) error {
+		used, err := tx.PasswordResets().Use(ctx, resetToken.ID)
+		if err != nil {
+			return err
+		}
+		if !used {
+			return invalid("Invalid or expired reset token")
+		}
+		return nil
+	}
+
+	entry := audit.Entry{Action: audit.ActionPasswordReset, ActorID: &user.ID, ActorUsername: user.Username}
+	if err := s.setPassword(ctx, user, newPassword, entry, consume); err != nil {
+		return err
+	}
+
+	// Proving access to the account also lifts a lockout
+	if err := s.guard.Unlock(ctx, user.Username, ""); err != nil {
+		logging.FromContext(ctx).Error("Failed to unlock login", "username", user.Username, "error", err)
+	}
+
+	return nil
+}
+
+// setPassword validates and stores a new password, bumping the token version so that
+// every previously issued token is rejected. Outstanding reset tokens are invalidated.
+// The optional consume step runs first inside the same transaction.
+func (s *authService) setPassword(ctx context.Context, user *models.User, password string, entry audit.Entry, consume func(tx repository.Store) error) error {
+	if err := auth.ValidatePasswordStrength(password, user.Username, user.Email); err != nil {
+		return weakPassword(err, "new_password")
+	}
+
+	hashedPassword, err := auth.HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	return s.store.Transaction(ctx, func(tx repository.Store) error {
+		if consume != nil {
+			if err := consume(tx); err != nil {
+				return err
+			}
+		}
+
+		version, err := tx.Users().UpdatePassword(ctx, user.ID, hashedPassword, now)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.PasswordResets().InvalidateByUser(ctx, user.ID); err != nil {
+			return err
+		}
+
+		entry.EntityType = audit.EntityUser
+		entry.EntityID = &user.ID
+		if err := record(ctx, tx, entry); err != nil {
+			return err
+		}
+
+		user.Password = hashedPassword
+		user.PasswordChangedAt = &now
+		user.TokenVersion = version
+		user.MustChangePassword = false
+		return nil
+	})
+}
@@ -0,0 +1,155 @@
+// Package service contains the business logic of the food court. Services are
+// independent of HTTP and GORM: callers pass the request info through the
+// context (see requestinfo) and data access goes through repository.Store.
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"foodcourt-backend/internal/audit"
+	"foodcourt-backend/internal/logging"
+	"foodcourt-backend/internal/repository"
+)
+
+// ErrorKind classifies errors that are caused by the caller rather than the system
+type ErrorKind int
+
+const (
+	KindInvalid ErrorKind = iota + 1
+	KindUnauthorized
+	KindForbidden
+	KindNotFound
+	KindConflict
+	KindPreconditionFailed
+)
+
+// Codes of errors that clients handle on their own. Errors without a code are
+// identified by their kind.
+const (
+	CodeInvalidCredentials   = "invalid_credentials"
+	CodeInvalidTwoFactorCode = "invalid_two_factor_code"
+	CodeWeakPassword         = "weak_password"
+	CodeAlreadyExists        = "already_exists"
+	CodeMenuUnavailable      = "menu_unavailable"
+	CodeModifiedConcurrently = "modified_concurrently"
+)
+
+// Error is returned for expected failures. Message is safe to show to clients;
+// it is written in English and formatted with Args, if any, so that it can be
+// translated before formatting. Err optionally carries the underlying validation
+// error, which is safe to show as well. Field names the request field at fault.
+type Error struct {
+	Kind    ErrorKind
+	Code    string
+	Message string
+	Args    []any
+	Field   string
+	Err     error
+}
+
+func (e *Error) Error() string {
+	message := e.Message
+	if len(e.Args) > 0 {
+		message = fmt.Sprintf(message, e.Args...)
+	}
+	if e.Err != nil {
+		return message + ": " + e.Err.Error()
+	}
+	return message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func invalid(message string) *Error {
+	return &Error{Kind: KindInvalid, Message: message}
+}
+
+// invalidf is invalid with a message formatted like fmt.Sprintf
+func invalidf(format string, args ...any) *Error {
+	return &Error{Kind: KindInvalid, Message: format, Args: args}
+}
+
+func unauthorized(message string) *Error {
+	return &Error{Kind: KindUnauthorized, Message: message}
+}
+
+func forbidden(message string) *Error {
+	return &Error{Kind: KindForbidden, Message: message}
+}
+
+func notFound(message string) *Error {
+	return &Error{Kind: KindNotFound, Message: message}
+}
+
+func conflict(message string) *Error {
+	return &Error{Kind: KindConflict, Message: message}
+}
+
+func preconditionFailed(message string) *Error {
+	return &Error{Kind: KindPreconditionFailed, Message: message}
+}
+
+// withCode sets the code of err
+func withCode(err *Error, code string) *Error {
+	err.Code = code
+	return err
+}
+
+// notFoundOr turns a repository.ErrNotFound into a not found error with the given message
+func notFoundOr(err error, message string) error {
+	if errors.Is(err, repository.ErrNotFound) {
+		return notFound(message)
+	}
+	return err
+}
+
+// Precondition is the version a caller expects a resource to be at before
+// changing it, as sent in If-Match. The zero value accepts any version.
+type Precondition struct {
+	Conditional bool   // Whether the caller expects a version at all
+	Versions    []uint // The versions the caller accepts, possibly none
+}
+
+// check returns a precondition failed error with message unless p accepts version
+func (p Precondition) check(version uint, message string) error {
+	if !p.Conditional || slices.Contains(p.Versions, version) {
+		return nil
+	}
+	return preconditionFailed(message)
+}
+
+// staleOr turns a repository.ErrStale into the error of a resource changed by
+// someone else since it was read. Callers with a precondition get it reported
+// as failed, as their version is no longer current.
+func staleOr(err error, p Precondition, message string) error {
+	if !errors.Is(err, repository.ErrStale) {
+		return err
+	}
+	if p.Conditional {
+		return preconditionFailed(message)
+	}
+	return withCode(conflict(message), CodeModifiedConcurrently)
+}
+
+// record stores an audit entry. Pass the transaction store so the entry is only
+// kept if the change commits.
+func record(ctx context.Context, store repository.Store, entry audit.Entry) error {
+	entryLog, err := audit.NewLog(ctx, entry)
+	if err != nil {
+		return err
+	}
+	return store.AuditLogs().Create(ctx, entryLog)
+}
+
+// recordBestEffort stores an audit entry outside of a transaction, logging instead
+// of failing on errors. It is also kept when the client cancels the request.
+func recordBestEffort(ctx context.Context, store repository.Store, entry audit.Entry) {
+	if err := record(context.WithoutCancel(ctx), store, entry); err != nil {
+		logging.FromContext(ctx).Error("Failed to record audit entry", "action", entry.Action, "error", err)
+	}
+}
@@ -0,0 +1,379 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"foodcourt-backend/internal/audit"
+	"foodcourt-backend/internal/models"
+	"foodcourt-backend/internal/repository"
+	"foodcourt-backend/internal/requestinfo"
+	"foodcourt-backend/pkg/auth"
+)
+
+const recoveryCodeCount = 10
+
+// twoFactorChallenge answers a login with a correct password but a missing second factor
+func (s *authService) twoFactorChallenge(user *models.User) (*LoginResult, error) {
+	purpose := auth.PurposeTwoFactor
+	if !user.TOTPEnabled {
+		purpose = auth.PurposeTwoFactorSetup
+	}
+
+	challengeToken, err := s.jwtService.GenerateChallengeToken(user, purpose, s.twoFactor.ChallengeExpires)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LoginResult{
+		TwoFactorRequired:      user.TOTPEnabled,
+		TwoFactorSetupRequired: !user.TOTPEnabled,
+		ChallengeToken:         challengeToken,
+	}, nil
+}
+
+func (s *authService) VerifyTwoFactor(ctx context.Context, req models.VerifyTwoFactorRequest) (*LoginResult, error) {
+	user, err := s.challengeUser(ctx, req.ChallengeToken, auth.PurposeTwoFactor)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.TOTPEnabled {
+		return nil, unauthorized("Invalid or expired challenge token")
+	}
+
+	// Codes are counted like passwords, so that parallel guesses cannot get past the limits
+	attempt, err := s.loginAttempt(ctx, user.Username)
+	if err != nil {
+		return nil, err
+	}
+
+	method := "totp"
+	var verified bool
+	if req.RecoveryCode != "" {
+		method = "recovery_code"
+		verified, err = s.store.RecoveryCodes().Use(ctx, user.ID, auth.HashRecoveryCode(req.RecoveryCode))
+	} else {
+		verified, err = checkTOTP(ctx, s.store, user, req.Code)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !verified {
+		s.loginFailed(ctx, attempt, user.Username, &user.ID, "invalid_two_factor_code")
+		return nil, withCode(unauthorized("Invalid two-factor code"), CodeInvalidTwoFactorCode)
+	}
+	s.loginVerified(ctx, attempt, user.Username)
+
+	return s.completeLogin(ctx, user, map[string]interface{}{"two_factor": method})
+}
+
+func (s *authService) StartTwoFactorSetup(ctx context.Context, challengeToken string) (*TwoFactorEnrollment, error) {
+	user, err := s.challengeUser(ctx, challengeToken, auth.PurposeTwoFactorSetup)
+	if err != nil {
+		return nil, err
+	}
+
+	// Replacing the secret would turn two-factor authentication off again
+	if user.TOTPEnabled {
+		return nil, conflict("Two-factor authentication is already enabled")
+	}
+
+	return s.startEnrollment(ctx, user)
+}
+
+func (s *authService) ConfirmTwoFactorSetup(ctx context.Context, challengeToken, code string) (*LoginResult, error) {
+	if code == "" {
+		return nil, invalid("Two-factor code required")
+	}
+
+	user, err := s.challengeUser(ctx, challengeToken, auth.PurposeTwoFactorSetup)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.TOTPEnabled {
+		return nil, conflict("Two-factor authentication is already enabled")
+	}
+
+	// The setup challenge must not outlive the enrollment it was issued for
+	recoveryCodes, err := s.confirmEnrollment(ctx, user, code, true)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.completeLogin(ctx, user, map[string]interface{}{"two_factor": "setup"})
+	if err != nil {
+		return nil, err
+	}
+	result.RecoveryCodes = recoveryCodes
+
+	return result, nil
+}
+
+func (s *authService) EnrollTwoFactor(ctx context.Context, userID uint) (*TwoFactorEnrollment, error) {
+	user, err := s.Me(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.TOTPEnabled {
+		return nil, conflict("Two-factor authentication is already enabled")
+	}
+
+	return s.startEnrollment(ctx, user)
+}
+
+func (s *authService) ConfirmTwoFactor(ctx context.Context, userID uint, code string) ([]string, error) {
+	user, err := s.Me(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.TOTPEnabled {
+		return nil, conflict("Two-factor authentication is already enabled")
+	}
+
+	return s.confirmEnrollment(ctx, user, code, false)
+}
+
+func (s *authService) DisableTwoFactor(ctx context.Context, userID uint, password, code string) error {
+	user, err := s.Me(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if s.twoFactor.IsRequiredFor(string(user.Role)) {
+		return forbidden("Two-factor authentication is required for your role")
+	}
+
+	if !user.TOTPEnabled {
+		return invalid("Two-factor authentication is not enabled")
+	}
+
+	// Count the check like a login, so a stolen session cannot be used to guess the password or code
+	attempt, err := s.loginAttempt(ctx, user.Username)
+	if err != nil {
+		return err
+	}
+	if auth.CheckPassword(user.Password, password) != nil {
+		s.loginFailed(ctx, attempt, user.Username, &user.ID, "invalid_password")
+		return withCode(unauthorized("Invalid password or two-factor code"), CodeInvalidCredentials)
+	}
+	verified, err := checkTOTP(ctx, s.store, user, code)
+	if err != nil {
+		return err
+	}
+	if !verified {
+		s.loginFailed(ctx, attempt, user.Username, &user.ID, "invalid_two_factor_code")
+		return withCode(unauthorized("Invalid password or two-factor code"), CodeInvalidCredentials)
+	}
+	s.loginVerified(ctx, attempt, user.Username)
+
+	return s.store.Transaction(ctx, func(tx repository.Store) error {
+		if err := clearTwoFactor(ctx, tx, user.ID); err != nil {
+			return err
+		}
+		return record(ctx, tx, audit.Entry{
+			Action:     audit.ActionTwoFactorDisabled,
+			EntityType: audit.EntityUser,
+			EntityID:   &user.ID,
+		})
+	})
+}
+
+func (s *authService) RegenerateRecoveryCodes(ctx context.Context, userID uint, code string) ([]string, error) {
+	user, err := s.Me(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.TOTPEnabled {
+		return nil, withCode(unauthorized("Invalid two-factor code"), CodeInvalidTwoFactorCode)
+	}
+
+	// Count the check like a login, so a stolen session cannot be used to guess the code
+	attempt, err := s.loginAttempt(ctx, user.Username)
+	if err != nil {
+		return nil, err
+	}
+	verified, err := checkTOTP(ctx, s.store, user, code)
+	if err != nil {
+		return nil, err
+	}
+	if !verified {
+		s.loginFailed(ctx, attempt, user.Username, &user.ID, "invalid_two_factor_code")
+		return nil, withCode(unauthorized("Invalid two-factor code"), CodeInvalidTwoFactorCode)
+	}
+	s.loginVerified(ctx, attempt, user.Username)
+
+	var recoveryCodes []string
+	err = s.store.Transaction(ctx, func(tx repository.Store) error {
+		var err error
+		if recoveryCodes, err = replaceRecoveryCodes(ctx, tx, user.ID); err != nil {
+			return err
+		}
+		return record(ctx, tx, audit.Entry{
+			Action:     audit.ActionRecoveryCodesRegenerated,
+			EntityType: audit.EntityUser,
+			EntityID:   &user.ID,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return recoveryCodes, nil
+}
+
+func (s *authService) ResetTwoFactor(ctx context.Context, userID uint) error {
+	user, err := s.Me(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	return s.store.Transaction(ctx, func(tx repository.Store) error {
+		if err := clearTwoFactor(ctx, tx, user.ID); err != nil {
+			return err
+		}
+		return record(ctx, tx, audit.Entry{
+			Action:     audit.ActionTwoFactorReset,
+			EntityType: audit.EntityUser,
+			EntityID:   &user.ID,
+			Metadata:   map[string]interface{}{"username": user.Username},
+		})
+	})
+}
+
+// challengeUser resolves the user of a login challenge token, applying login throttling
+func (s *authService) challengeUser(ctx context.Context, challengeToken, purpose string) (*models.User, error) {
+	claims, err := s.jwtService.ValidateChallengeToken(challengeToken, purpose)
+	if err != nil {
+		return nil, unauthorized("Invalid or expired challenge token")
+	}
+
+	if err := s.guard.Check(ctx, claims.Username, requestinfo.From(ctx).ClientIP); err != nil {
+		return nil, err
+	}
+
+	user, err := s.store.Users().FindActiveByID(ctx, claims.UserID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, unauthorized("Invalid or expired challenge token")
+		}
+		return nil, err
+	}
+	if user.TokenVersion != claims.Version {
+		return nil, unauthorized("Invalid or expired challenge token")
+	}
+
+	return user, nil
+}
+
+// startEnrollment generates a new pending TOTP secret for the user
+func (s *authService) startEnrollment(ctx context.Context, user *models.User) (*TwoFactorEnrollment, error) {
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.store.Users().SetTOTPSecret(ctx, user.ID, secret); err != nil {
+		return nil, err
+	}
+
+	return &TwoFactorEnrollment{
+		Secret:          secret,
+		ProvisioningURI: auth.TOTPProvisioningURI(s.twoFactor.Issuer, user.Username, secret),
+	}, nil
+}
+
+// confirmEnrollment enables the pending secret after checking a first code and issues recovery codes.
+// With revokeTokens, earlier sessions and login challenges of the user end as well.
+func (s *authService) confirmEnrollment(ctx context.Context, user *models.User, code string, revokeTokens bool) ([]string, error) {
+	if user.TOTPSecret == "" {
+		return nil, invalid("Two-factor enrollment has not been started")
+	}
+
+	var recoveryCodes []string
+	var verified bool
+	err := s.store.Transaction(ctx, func(tx repository.Store) error {
+		var err error
+		if verified, err = checkTOTP(ctx, tx, user, code); err != nil || !verified {
+			return err
+		}
+
+		if err := tx.Users().EnableTOTP(ctx, user.ID); err != nil {
+			return err
+		}
+		if revokeTokens {
+			if user.TokenVersion, err = tx.Users().RevokeTokens(ctx, user.ID); err != nil {
+				return err
+			}
+		}
+
+		if recoveryCodes, err = replaceRecoveryCodes(ctx, tx, user.ID); err != nil {
+			return err
+		}
+		return record(ctx, tx, audit.Entry{
+			Action:        audit.ActionTwoFactorEnabled,
+			EntityType:    audit.EntityUser,
+			EntityID:      &user.ID,
+			ActorID:       &user.ID,
+			ActorUsername: user.Username,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !verified {
+		return nil, withCode(unauthorized("Invalid two-factor code"), CodeInvalidTwoFactorCode)
+	}
+
+	user.TOTPEnabled = true
+
+	return recoveryCodes, nil
+}
+
+// checkTOTP validates a code and marks its time step as used so it cannot be replayed
+func checkTOTP(ctx context.Context, store repository.Store, user *models.User, code string) (bool, error) {
+	counter, ok := auth.ValidateTOTP(user.TOTPSecret, code, time.Now())
+	if !ok || counter <= user.TOTPLastCounter {
+		return false, nil
+	}
+
+	claimed, err := store.Users().ClaimTOTPCounter(ctx, user.ID, counter)
+	if err != nil || !claimed {
+		return false, err
+	}
+
+	user.TOTPLastCounter = counter
+	return true, nil
+}
+
+func clearTwoFactor(ctx context.Context, tx repository.Store, userID uint) error {
+	if err := tx.Users().ClearTOTP(ctx, userID); err != nil {
+		return err
+	}
+	return tx.RecoveryCodes().DeleteByUser(ctx, userID)
+}
+
+func replaceRecoveryCodes(ctx context.Context, tx repository.Store, userID uint) ([]string, error) {
+	codes, err := auth.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = auth.HashRecoveryCode(code)
+	}
+
+	if err := tx.RecoveryCodes().Replace(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
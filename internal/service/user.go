@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"foodcourt-backend/internal/audit"
+	"foodcourt-backend/internal/models"
+	"foodcourt-backend/internal/repository"
+	"foodcourt-backend/internal/requestinfo"
+)
+
+type UserService interface {
+	// List returns a page of users and the total number of matches
+	List(ctx context.Context, filter repository.UserFilter, opts repository.ListOptions) ([]models.User, int64, error)
+	// Patch changes the profile of a user with apply, which receives its current
+	// values. Moving a user to another role or kios ends their sessions.
+	Patch(ctx context.Context, id uint, apply func(*models.UserPatch) error) (*models.User, error)
+}
+
+type userService struct {
+	store repository.Store
+}
+
+func NewUserService(store repository.Store) UserService {
+	return &userService{store: store}
+}
+
+func (s *userService) List(ctx context.Context, filter repository.UserFilter, opts repository.ListOptions) ([]models.User, int64, error) {
+	return s.store.Users().List(ctx, filter, opts)
+}
+
+func (s *userService) Patch(ctx context.Context, id uint, apply func(*models.UserPatch) error) (*models.User, error) {
+	user, err := s.store.Users().FindByID(ctx, id)
+	if err != nil {
+		return nil, notFoundOr(err, "User not found")
+	}
+
+	patch := user.Patchable()
+	if err := apply(&patch); err != nil {
+		return nil, err
+	}
+
+	// Cashiers must not lock themselves out
+	if caller := requestinfo.From(ctx).UserID; caller != nil && *caller == user.ID &&
+		(patch.Role != user.Role || !*patch.IsActive) {
+		return nil, forbidden("You cannot change the role of or deactivate your own account")
+	}
+
+	before := user.ToResponse()
+	role, kiosID := user.Role, user.KiosID
+	user.ApplyPatch(patch)
+
+	kiosChanged := !sameID(user.KiosID, kiosID)
+	if kiosChanged && user.KiosID != nil {
+		if _, err := s.store.Kios().FindByID(ctx, *user.KiosID); err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return nil, invalid("Kios not found")
+			}
+			return nil, err
+		}
+	}
+
+	err = s.store.Transaction(ctx, func(tx repository.Store) error {
+		if err := tx.Users().UpdateProfile(ctx, user); err != nil {
+			return err
+		}
+		// Tokens carry the role and kios, so they must not outlive them
+		if kiosChanged || user.Role != role {
+			version, err := tx.Users().RevokeTokens(ctx, user.ID)
+			if err != nil {
+				return err
+			}
+			user.TokenVersion = version
+		}
+		return record(ctx, tx, audit.Entry{
+			Action:     audit.ActionUserUpdated,
+			EntityType: audit.EntityUser,
+			EntityID:   &user.ID,
+			Before:     before,
+			After:      user.ToResponse(),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Load the new kios
+	return s.store.Users().FindByID(ctx, user.ID)
+}
+
+// sameID reports whether two optional IDs are equal
+func sameID(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
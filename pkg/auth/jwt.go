@@ -9,11 +9,19 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// Token purposes. Access tokens have no purpose and are the only ones accepted by ValidateToken.
+const (
+	PurposeTwoFactor      = "2fa"
+	PurposeTwoFactorSetup = "2fa_setup"
+)
+
 type JWTClaims struct {
-	UserID   uint             `json:"user_id"`
-	Username string           `json:"username"`
-	Role     models.UserRole  `json:"role"`
-	KiosID   *uint            `json:"kios_id,omitempty"`
+	UserID   uint            `json:"user_id"`
+	Username string          `json:"username"`
+	Role     models.UserRole `json:"role"`
+	KiosID   *uint           `json:"kios_id,omitempty"`
+	Purpose  string          `json:"purpose,omitempty"`
+	Version  uint            `json:"ver"` // Must match the user's token version, see models.User.TokenVersion
 	jwt.RegisteredClaims
 }
 
@@ -22,15 +30,17 @@ type JWTService struct {
 	expiresIn time.Duration
 }
 
-func NewJWTService(secretKey string, expiresIn string) (*JWTService, error) {
-	duration, err := time.ParseDuration(expiresIn)
-	if err != nil {
-		return nil, err
+func NewJWTService(secretKey string, expiresIn time.Duration) (*JWTService, error) {
+	if secretKey == "" {
+		return nil, errors.New("JWT secret is required")
+	}
+	if expiresIn <= 0 {
+		return nil, errors.New("JWT expiry must be positive")
 	}
 
 	return &JWTService{
 		secretKey: secretKey,
-		expiresIn: duration,
+		expiresIn: expiresIn,
 	}, nil
 }
 
@@ -40,6 +50,7 @@ func (j *JWTService) GenerateToken(user *models.User) (string, error) {
 		Username: user.Username,
 		Role:     user.Role,
 		KiosID:   user.KiosID,
+		Version:  user.TokenVersion,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(j.expiresIn)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -54,6 +65,54 @@ func (j *JWTService) GenerateToken(user *models.User) (string, error) {
 }
 
 func (j *JWTService) ValidateToken(tokenString string) (*JWTClaims, error) {
+	claims, err := j.parse(tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.Purpose != "" {
+		return nil, errors.New("not an access token")
+	}
+
+	return claims, nil
+}
+
+// GenerateChallengeToken issues a short-lived token proving the password step of a login
+// succeeded. It cannot be used as an access token.
+func (j *JWTService) GenerateChallengeToken(user *models.User, purpose string, expiresIn time.Duration) (string, error) {
+	claims := &JWTClaims{
+		UserID:   user.ID,
+		Username: user.Username,
+		Role:     user.Role,
+		Purpose:  purpose,
+		Version:  user.TokenVersion,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiresIn)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			NotBefore: jwt.NewNumericDate(time.Now()),
+			Issuer:    "foodcourt-backend",
+			Subject:   user.Username,
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(j.secretKey))
+}
+
+func (j *JWTService) ValidateChallengeToken(tokenString string, purpose string) (*JWTClaims, error) {
+	claims, err := j.parse(tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.Purpose != purpose {
+		return nil, errors.New("invalid challenge token")
+	}
+
+	return claims, nil
+}
+
+func (j *JWTService) parse(tokenString string) (*JWTClaims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, errors.New("unexpected signing method")
@@ -84,6 +143,7 @@ func (j *JWTService) RefreshToken(tokenString string) (string, error) {
 		Username: claims.Username,
 		Role:     claims.Role,
 		KiosID:   claims.KiosID,
+		Version:  claims.Version,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(j.expiresIn)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -1,9 +1,40 @@
 package auth
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"strings"
+	"unicode"
+
 	"golang.org/x/crypto/bcrypt"
 )
 
+const (
+	MinPasswordLength = 8
+	MaxPasswordLength = 72 // bcrypt ignores everything after 72 bytes
+)
+
+var (
+	ErrPasswordTooShort    = errors.New("password must be at least 8 characters long")
+	ErrPasswordTooLong     = errors.New("password must be at most 72 characters long")
+	ErrPasswordTooSimple   = errors.New("password must contain both letters and digits")
+	ErrPasswordTooCommon   = errors.New("password is too common")
+	ErrPasswordHasIdentity = errors.New("password must not contain your username or email")
+)
+
+// Frequently used passwords that pass the length and character rules
+var commonPasswords = map[string]bool{
+	"password1": true, "password123": true, "passw0rd": true, "qwerty123": true,
+	"abc12345": true, "abcd1234": true, "12345678a": true, "a1234567": true,
+	"iloveyou1": true, "welcome1": true, "admin123": true, "letmein1": true,
+	"foodcourt1": true, "foodcourt123": true, "kasir123": true, "rahasia123": true,
+	"indonesia1": true, "bismillah1": true, "sayang123": true, "jakarta123": true,
+}
+
 // HashPassword hashes a plain text password
 func HashPassword(password string) (string, error) {
 	hashedBytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
@@ -17,3 +48,79 @@ func HashPassword(password string) (string, error) {
 func CheckPassword(hashedPassword, password string) error {
 	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
 }
+
+// ValidatePasswordStrength checks a new password against the password policy.
+// identities (username, email) must not appear in the password.
+func ValidatePasswordStrength(password string, identities ...string) error {
+	if len(password) < MinPasswordLength {
+		return ErrPasswordTooShort
+	}
+	if len(password) > MaxPasswordLength {
+		return ErrPasswordTooLong
+	}
+
+	var hasLetter, hasDigit bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLetter(r):
+			hasLetter = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		}
+	}
+	if !hasLetter || !hasDigit {
+		return ErrPasswordTooSimple
+	}
+
+	lower := strings.ToLower(password)
+	if commonPasswords[lower] {
+		return ErrPasswordTooCommon
+	}
+
+	for _, identity := range identities {
+		// Only check the local part of e-mail addresses
+		identity = strings.ToLower(strings.SplitN(identity, "@", 2)[0])
+		if len(identity) >= 3 && strings.Contains(lower, identity) {
+			return ErrPasswordHasIdentity
+		}
+	}
+
+	return nil
+}
+
+// passwordAlphabet leaves out characters that are easily confused when typed from a screen
+const passwordAlphabet = "abcdefghjkmnpqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// GeneratePassword creates a random password that meets the password policy
+func GeneratePassword() (string, error) {
+	for {
+		password := make([]byte, 16)
+		for i := range password {
+			n, err := rand.Int(rand.Reader, big.NewInt(int64(len(passwordAlphabet))))
+			if err != nil {
+				return "", err
+			}
+			password[i] = passwordAlphabet[n.Int64()]
+		}
+
+		if ValidatePasswordStrength(string(password)) == nil {
+			return string(password), nil
+		}
+	}
+}
+
+// GenerateResetToken creates a random single-use token and the hash to store for it
+func GenerateResetToken() (token, hash string, err error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", "", err
+	}
+	token = base64.RawURLEncoding.EncodeToString(raw)
+	return token, HashResetToken(token), nil
+}
+
+// HashResetToken hashes a password reset token for storage and lookup
+func HashResetToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+// Secret of the RFC 6238 test vectors, "12345678901234567890" in base32
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// The SHA-1 vectors of RFC 6238, appendix B, cut to the last six digits
+var rfcVectors = []struct {
+	unix int64
+	code string
+}{
+	{59, "287082"},
+	{1111111109, "081804"},
+	{1111111111, "050471"},
+	{1234567890, "005924"},
+	{2000000000, "279037"},
+	{20000000000, "353130"},
+}
+
+func TestGenerateTOTPCode(t *testing.T) {
+	for _, v := range rfcVectors {
+		code, err := GenerateTOTPCode(rfcSecret, TOTPCounter(time.Unix(v.unix, 0)))
+		if err != nil {
+			t.Fatalf("generate code at %d: %v", v.unix, err)
+		}
+		if code != v.code {
+			t.Errorf("at %d: expected %s, got %s", v.unix, v.code, code)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	for _, v := range rfcVectors {
+		at := time.Unix(v.unix, 0)
+		counter, ok := ValidateTOTP(rfcSecret, v.code, at)
+		if !ok || counter != TOTPCounter(at) {
+			t.Errorf("at %d: expected %s to match step %d, got %d, %v", v.unix, v.code, TOTPCounter(at), counter, ok)
+		}
+	}
+}
+
+func TestValidateTOTPAllowsClockSkew(t *testing.T) {
+	at := time.Unix(1111111111, 0)
+	step := TOTPCounter(at)
+	code := "050471"
+
+	// Clocks one step apart still agree, and report the step the code was made for
+	for _, offset := range []time.Duration{-totpPeriod * time.Second, totpPeriod * time.Second} {
+		counter, ok := ValidateTOTP(rfcSecret, code, at.Add(offset))
+		if !ok || counter != step {
+			t.Errorf("offset %s: expected step %d, got %d, %v", offset, step, counter, ok)
+		}
+	}
+
+	for _, offset := range []time.Duration{-2 * totpPeriod * time.Second, 2 * totpPeriod * time.Second} {
+		if _, ok := ValidateTOTP(rfcSecret, code, at.Add(offset)); ok {
+			t.Errorf("offset %s: expected the code to be rejected", offset)
+		}
+	}
+}
+
+func TestValidateTOTPRejectsMalformedCodes(t *testing.T) {
+	at := time.Unix(59, 0)
+	for _, code := range []string{"", "28708", "2870820", "287083", "94287082"} {
+		if _, ok := ValidateTOTP(rfcSecret, code, at); ok {
+			t.Errorf("expected %q to be rejected", code)
+		}
+	}
+	if _, ok := ValidateTOTP("not base32!", "287082", at); ok {
+		t.Error("expected an invalid secret to be rejected")
+	}
+	// Surrounding spaces are ignored
+	if _, ok := ValidateTOTP(rfcSecret, " 287082 ", at); !ok {
+		t.Error("expected a code with spaces to match")
+	}
+}
+
+func TestHashRecoveryCodeNormalizes(t *testing.T) {
+	if HashRecoveryCode(" ABCDE-12345 ") != HashRecoveryCode("abcde12345") {
+		t.Error("expected case, dashes and spaces to be ignored")
+	}
+	if HashRecoveryCode("abcde-12345") == HashRecoveryCode("abcde-12346") {
+		t.Error("expected different codes to differ")
+	}
+}